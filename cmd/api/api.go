@@ -1,19 +1,22 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	paramscmd "github.com/wakatime/wakatime-cli/cmd/params"
 	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
 	"github.com/wakatime/wakatime-cli/pkg/log"
 
 	timezone "github.com/gandarez/go-olson-timezone"
 )
 
 // NewClient initializes a new api client with all options following the
-// passed in parameters.
-func NewClient(params paramscmd.API) (*api.Client, error) {
+// passed in parameters and the passed in additional options.
+func NewClient(params paramscmd.API, opts ...api.Option) (*api.Client, error) {
 	withAuth, err := api.WithAuth(api.BasicAuth{
 		Secret: params.Key,
 	})
@@ -21,7 +24,23 @@ func NewClient(params paramscmd.API) (*api.Client, error) {
 		return nil, fmt.Errorf("failed to set up auth option on api client: %w", err)
 	}
 
-	return newClient(params, withAuth)
+	return newClient(params, append([]api.Option{withAuth}, opts...)...)
+}
+
+// NewSender initializes a new heartbeat sender following the passed in
+// parameters. For file api urls, heartbeats are appended to the local file,
+// otherwise an api client is returned.
+func NewSender(params paramscmd.API, opts ...api.Option) (heartbeat.Sender, error) {
+	if api.IsFileURL(params.URL) {
+		fp, err := api.FilepathFromURL(params.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up file sender: %s", err)
+		}
+
+		return api.NewFileSender(fp), nil
+	}
+
+	return NewClient(params, opts...)
 }
 
 // NewClientWithoutAuth initializes a new api client with all options following the
@@ -62,6 +81,28 @@ func newClient(params paramscmd.API, opts ...api.Option) (*api.Client, error) {
 		opts = append(opts, withSSLCert)
 	}
 
+	if params.SSLClientCert.CertFilepath != "" {
+		withClientCert, err := api.WithClientCertFile(
+			params.SSLClientCert.CertFilepath,
+			params.SSLClientCert.KeyFilepath,
+			[]byte(params.SSLClientCert.KeyPassphrase),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up ssl client cert option on api client: %s", err)
+		}
+
+		opts = append(opts, withClientCert)
+	}
+
+	if len(params.NoProxy) > 0 {
+		withNoProxy, err := api.WithNoProxy(params.NoProxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up no proxy option on api client: %w", err)
+		}
+
+		opts = append(opts, withNoProxy)
+	}
+
 	if params.ProxyURL != "" {
 		withProxy, err := api.WithProxy(params.ProxyURL)
 		if err != nil {
@@ -88,3 +129,31 @@ func newClient(params paramscmd.API, opts ...api.Option) (*api.Client, error) {
 
 	return api.NewClient(params.URL, opts...), nil
 }
+
+// ErrExitCode returns the exit code and a short reason for connection,
+// timeout, TLS, proxy auth and server errors of the api client. Returns false,
+// if err is none of those.
+func ErrExitCode(err error) (int, string, bool) {
+	var (
+		errconn      api.ErrConnection
+		errtimeout   api.ErrTimeout
+		errtls       api.ErrTLS
+		errproxyauth api.ErrProxyAuth
+		errserver    api.ErrServer
+	)
+
+	switch {
+	case errors.As(err, &errconn):
+		return exitcode.ErrConnection, "connection error", true
+	case errors.As(err, &errtimeout):
+		return exitcode.ErrTimeout, "timeout", true
+	case errors.As(err, &errtls):
+		return exitcode.ErrTLS, "tls error", true
+	case errors.As(err, &errproxyauth):
+		return exitcode.ErrProxyAuth, "proxy authentication error", true
+	case errors.As(err, &errserver):
+		return exitcode.ErrServer, "server error", true
+	}
+
+	return 0, "", false
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	paramscmd "github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/connectivity"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+)
+
+// WithConnectivityCheck returns a heartbeat handle option, which skips sending
+// heartbeats while the api host is known to be unreachable. Its reachability is
+// recorded next to the passed in offline queue file. The check is disabled for
+// a zero ttl and for senders, which cannot be probed, like file senders.
+func WithConnectivityCheck(
+	sender heartbeat.Sender,
+	paramAPI paramscmd.API,
+	paramOffline paramscmd.Offline,
+	queueFilepath string,
+) heartbeat.HandleOption {
+	prober, ok := sender.(connectivity.Prober)
+	if !ok || paramOffline.ConnectivityCheckTTL == 0 {
+		return func(next heartbeat.Handle) heartbeat.Handle { return next }
+	}
+
+	return connectivity.WithCheck(connectivity.Config{
+		Filepath: connectivity.StateFilepath(queueFilepath),
+		Prober:   prober,
+		TTL:      paramOffline.ConnectivityCheckTTL,
+		URL:      paramAPI.URL,
+	})
+}
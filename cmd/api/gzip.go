@@ -0,0 +1,32 @@
+package api
+
+import (
+	paramscmd "github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/ini"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+
+	"github.com/spf13/viper"
+)
+
+// WithGzip returns an api client option, which compresses heartbeat uploads,
+// if enabled. Once the api rejects compressed uploads, the api url gets
+// recorded in the passed in internal config section, to skip compression on
+// later runs.
+func WithGzip(v *viper.Viper, section string, params paramscmd.API) api.Option {
+	if !params.Gzip {
+		return api.Option(func(*api.Client) {})
+	}
+
+	return api.WithGzip(func() {
+		w, err := ini.NewIniWriter(v, ini.FilePath)
+		if err != nil {
+			log.Warnf("failed to parse config file: %s", err)
+			return
+		}
+
+		if err := w.Write(section, map[string]string{"gzip_unsupported": params.URL}); err != nil {
+			log.Warnf("failed to write to internal config file: %s", err)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package configwrite
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -19,7 +20,7 @@ type Params struct {
 }
 
 // Run loads wakatime config file and call Write().
-func Run(v *viper.Viper) (int, error) {
+func Run(_ context.Context, v *viper.Viper) (int, error) {
 	w, err := ini.NewIniWriter(v, ini.FilePath)
 	if err != nil {
 		return exitcode.ErrConfigFileParse, fmt.Errorf(
@@ -0,0 +1,137 @@
+package goals
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	cmdapi "github.com/wakatime/wakatime-cli/cmd/api"
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/goal"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/output"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+// Run executes the goals command.
+func Run(ctx context.Context, v *viper.Viper) (int, error) {
+	out, err := Goals(ctx, v)
+	if err != nil {
+		var errauth api.ErrAuth
+		if errors.As(err, &errauth) {
+			return exitcode.ErrAuth, fmt.Errorf(
+				"goals fetch failed: invalid api key... find yours at wakatime.com/api-key. %s",
+				errauth,
+			)
+		}
+
+		var errbadRequest api.ErrBadRequest
+		if errors.As(err, &errbadRequest) {
+			return exitcode.ErrGeneric, fmt.Errorf(
+				"goals fetch failed: bad request: %s",
+				err,
+			)
+		}
+
+		var errratelimit api.ErrRateLimit
+		if errors.As(err, &errratelimit) {
+			return exitcode.ErrRateLimit, fmt.Errorf(
+				"goals fetch failed: rate limited: %s",
+				err,
+			)
+		}
+
+		if code, reason, ok := cmdapi.ErrExitCode(err); ok {
+			return code, fmt.Errorf(
+				"goals fetch failed: %s: %s",
+				reason,
+				err,
+			)
+		}
+
+		var errapi api.Err
+		if errors.As(err, &errapi) {
+			return exitcode.ErrAPI, fmt.Errorf(
+				"goals fetch failed: api error: %s",
+				err,
+			)
+		}
+
+		return exitcode.ErrGeneric, fmt.Errorf(
+			"goals fetch failed: %s",
+			err,
+		)
+	}
+
+	log.Debugln("successfully fetched goals")
+	fmt.Print(out)
+
+	return exitcode.Success, nil
+}
+
+// Goals returns the rendered list of goals of the current user.
+func Goals(ctx context.Context, v *viper.Viper) (string, error) {
+	paramAPI, err := params.LoadAPIParams(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to load API parameters: %w", err)
+	}
+
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse output: %s", err)
+	}
+
+	apiClient, err := cmdapi.NewClient(paramAPI)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize api client: %w", err)
+	}
+
+	goals, err := apiClient.Goals(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed fetching goals from api: %w", err)
+	}
+
+	if out == output.JSONOutput {
+		data, err := json.Marshal(goals)
+		if err != nil {
+			return "", fmt.Errorf("failed to json marshal goals: %s", err)
+		}
+
+		return string(data) + "\n", nil
+	}
+
+	return renderTable(goals), nil
+}
+
+func renderTable(goals []goal.Goal) string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ID\tTITLE\tTARGET\tSTATUS\tPROGRESS")
+
+	for _, g := range goals {
+		target := g.Target
+		if target == "" {
+			target = "-"
+		}
+
+		progress := "-"
+		if g.Total != "" {
+			progress = goal.Render(&g)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", g.ID, g.Title, target, g.Status, progress)
+	}
+
+	_ = w.Flush()
+
+	return b.String()
+}
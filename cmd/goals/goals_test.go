@@ -0,0 +1,109 @@
+package goals_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/cmd/goals"
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/goal"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoals(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/goals", func(w http.ResponseWriter, req *http.Request) {
+		// check request
+		assert.Equal(t, []string{"Basic MDAwMDAwMDAtMDAwMC00MDAwLTgwMDAtMDAwMDAwMDAwMDAw"}, req.Header["Authorization"])
+
+		// write response
+		data, err := os.ReadFile("testdata/api_goals_response.json")
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+
+	output, err := goals.Goals(context.Background(), v)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		"ID                                    TITLE                           TARGET  STATUS   PROGRESS\n"+
+			"00000000-0000-4000-8000-000000000000  Code 4 hrs per day              4 hrs   pending  3 hrs 23 mins of 4 hrs (84.6%)\n"+
+			"00000000-0000-4000-8000-000000000001  Debug less than 2 hrs per week  2 hrs   success  1 hr of 2 hrs (50%), goal met\n"+
+			"00000000-0000-4000-8000-000000000002  Write docs 1 hr per day         -       ignored  -\n",
+		output,
+	)
+}
+
+func TestGoals_JSON(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/goals", func(w http.ResponseWriter, req *http.Request) {
+		data, err := os.ReadFile("testdata/api_goals_response.json")
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+	v.Set("output", "json")
+
+	output, err := goals.Goals(context.Background(), v)
+	require.NoError(t, err)
+
+	var gg []goal.Goal
+
+	err = json.Unmarshal([]byte(output), &gg)
+	require.NoError(t, err)
+
+	require.Len(t, gg, 3)
+
+	assert.Equal(t, "00000000-0000-4000-8000-000000000001", gg[1].ID)
+	assert.True(t, gg[1].IsMet)
+}
+
+func TestGoals_ErrAuth(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/goals", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+
+	_, err := goals.Goals(context.Background(), v)
+	require.Error(t, err)
+
+	var errauth api.ErrAuth
+
+	assert.True(t, errors.As(err, &errauth))
+}
+
+func setupTestServer() (string, *http.ServeMux, func()) {
+	router := http.NewServeMux()
+	srv := httptest.NewServer(router)
+
+	return srv.URL, router, func() { srv.Close() }
+}
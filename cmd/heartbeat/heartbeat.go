@@ -1,6 +1,7 @@
 package heartbeat
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -26,13 +27,13 @@ import (
 )
 
 // Run executes the heartbeat command.
-func Run(v *viper.Viper) (int, error) {
+func Run(ctx context.Context, v *viper.Viper) (int, error) {
 	queueFilepath, err := offline.QueueFilepath()
 	if err != nil {
 		log.Warnf("failed to load offline queue filepath: %s", err)
 	}
 
-	err = SendHeartbeats(v, queueFilepath)
+	err = SendHeartbeats(ctx, v, queueFilepath)
 	if err != nil {
 		var errauth api.ErrAuth
 		if errors.As(err, &errauth) {
@@ -50,6 +51,29 @@ func Run(v *viper.Viper) (int, error) {
 			)
 		}
 
+		var errratelimit api.ErrRateLimit
+		if errors.As(err, &errratelimit) {
+			return exitcode.ErrRateLimit, fmt.Errorf(
+				"sending heartbeat(s) later due to rate limit: %w",
+				err,
+			)
+		}
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return exitcode.ErrTimeout, fmt.Errorf(
+				"sending heartbeat(s) later due to run timeout: %w",
+				err,
+			)
+		}
+
+		if code, reason, ok := apicmd.ErrExitCode(err); ok {
+			return code, fmt.Errorf(
+				"sending heartbeat(s) later due to %s: %w",
+				reason,
+				err,
+			)
+		}
+
 		var errapi api.Err
 		if errors.As(err, &errapi) {
 			return exitcode.ErrAPI, fmt.Errorf(
@@ -72,7 +96,7 @@ func Run(v *viper.Viper) (int, error) {
 // SendHeartbeats sends a heartbeat to the wakatime api and includes additional
 // heartbeats from the offline queue, if available and offline sync is not
 // explicitly disabled.
-func SendHeartbeats(v *viper.Viper, queueFilepath string) error {
+func SendHeartbeats(ctx context.Context, v *viper.Viper, queueFilepath string) error {
 	params, err := paramscmd.Load(v)
 	if err != nil {
 		return fmt.Errorf("failed to load command parameters: %w", err)
@@ -91,7 +115,7 @@ func SendHeartbeats(v *viper.Viper, queueFilepath string) error {
 		log.Debugf("save %d extra heartbeat(s) to offline queue", len(extraHeartbeats))
 
 		go func() {
-			if err := offlinecmd.SaveHeartbeats(v, extraHeartbeats, queueFilepath); err != nil {
+			if err := offlinecmd.SaveHeartbeats(ctx, v, extraHeartbeats, queueFilepath); err != nil {
 				log.Errorf("failed to save extra heartbeats to offline queue: %s", err)
 			}
 		}()
@@ -99,26 +123,20 @@ func SendHeartbeats(v *viper.Viper, queueFilepath string) error {
 		heartbeats = heartbeats[:offline.SendLimit]
 	}
 
-	handleOpts := initHandleOptions(params)
+	var handleOpts []heartbeat.HandleOption
 
 	if !params.Offline.Disabled {
 		if params.Offline.QueueFile != "" {
 			queueFilepath = params.Offline.QueueFile
 		}
 
-		handleOpts = append(handleOpts, offline.WithQueue(queueFilepath))
+		handleOpts = append(handleOpts, offline.WithQueue(queueFilepath, params.Offline.QueueOptions()...))
 	}
 
-	handleOpts = append(handleOpts, backoff.WithBackoff(backoff.Config{
-		V:       v,
-		At:      params.API.BackoffAt,
-		Retries: params.API.BackoffRetries,
-	}))
-
-	apiClient, err := apicmd.NewClient(params.API)
+	sender, err := apicmd.NewSender(params.API, apicmd.WithGzip(v, "internal", params.API))
 	if err != nil {
 		if !params.Offline.Disabled {
-			if err := offlinecmd.SaveHeartbeats(v, heartbeats, queueFilepath); err != nil {
+			if err := offlinecmd.SaveHeartbeats(ctx, v, heartbeats, queueFilepath); err != nil {
 				log.Errorf("failed to save heartbeats to offline queue: %s", err)
 			}
 		}
@@ -126,9 +144,34 @@ func SendHeartbeats(v *viper.Viper, queueFilepath string) error {
 		return fmt.Errorf("failed to initialize api client: %w", err)
 	}
 
-	handle := heartbeat.NewHandle(apiClient, handleOpts...)
+	// skip waiting for requests to fail, while the api is known to be unreachable
+	if !params.Offline.Disabled {
+		handleOpts = append(handleOpts, apicmd.WithConnectivityCheck(sender, params.API, params.Offline, queueFilepath))
+	}
+
+	handleOpts = append(handleOpts, backoff.WithBackoff(backoff.Config{
+		V:       v,
+		At:      params.API.BackoffAt,
+		Retries: params.API.BackoffRetries,
+		Until:   params.API.BackoffUntil,
+	}))
+
+	var destinations []heartbeat.Destination
+
+	for _, d := range params.Destinations {
+		destinations = append(destinations, heartbeat.Destination{
+			Name:   d.Name,
+			Handle: newDestinationHandle(v, params, d, queueFilepath),
+		})
+	}
+
+	// process heartbeats once and pass them on to all destinations
+	handle := heartbeat.NewHandle(
+		heartbeat.NewFanOut(heartbeat.NewHandle(sender, handleOpts...), destinations...),
+		initHandleOptions(params)...,
+	)
 
-	results, err := handle(heartbeats)
+	results, err := handle(ctx, heartbeats)
 	if err != nil {
 		return err
 	}
@@ -197,6 +240,50 @@ func buildHeartbeats(params paramscmd.Params) []heartbeat.Heartbeat {
 	return heartbeats
 }
 
+// newDestinationHandle returns the handle sending heartbeats to an additional
+// api destination, with its own api key, offline queue and backoff state. If the api
+// client cannot be initialized, heartbeats are only saved to the offline queue.
+func newDestinationHandle(
+	v *viper.Viper,
+	params paramscmd.Params,
+	d paramscmd.Destination,
+	queueFilepath string,
+) heartbeat.Handle {
+	handleOpts := []heartbeat.HandleOption{
+		apikey.WithReplacing(apikey.Config{
+			DefaultApiKey: d.API.Key,
+		}),
+	}
+
+	if !params.Offline.Disabled {
+		handleOpts = append(handleOpts, offline.WithQueue(
+			d.QueueFilepath(queueFilepath),
+			params.Offline.DestinationQueueOptions(d)...,
+		))
+	}
+
+	sender, err := apicmd.NewSender(d.API, apicmd.WithGzip(v, d.InternalSection(), d.API))
+	if err != nil {
+		log.Errorf("failed to initialize api client of destination %q: %s", d.Name, err)
+
+		return heartbeat.NewHandle(offline.Sender{}, handleOpts...)
+	}
+
+	if !params.Offline.Disabled {
+		handleOpts = append(handleOpts, apicmd.WithConnectivityCheck(sender, d.API, params.Offline, queueFilepath))
+	}
+
+	handleOpts = append(handleOpts, backoff.WithBackoff(backoff.Config{
+		V:       v,
+		At:      d.API.BackoffAt,
+		Retries: d.API.BackoffRetries,
+		Section: d.InternalSection(),
+		Until:   d.API.BackoffUntil,
+	}))
+
+	return heartbeat.NewHandle(sender, handleOpts...)
+}
+
 func initHandleOptions(params paramscmd.Params) []heartbeat.HandleOption {
 	return []heartbeat.HandleOption{
 		heartbeat.WithFormatting(heartbeat.FormatConfig{
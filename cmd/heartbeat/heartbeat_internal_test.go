@@ -0,0 +1,68 @@
+package heartbeat
+
+import (
+	"context"
+	"testing"
+
+	paramscmd "github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/regex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitHandleOptions_ApiKeyBeforeSanitization(t *testing.T) {
+	params := paramscmd.Params{
+		API: paramscmd.API{
+			Key: "00000000-0000-4000-8000-000000000000",
+			KeyPatterns: []apikey.MapPattern{
+				{
+					ApiKey: "00000000-0000-4000-8000-000000000001",
+					Regex:  regex.MustCompile("testdata"),
+				},
+			},
+		},
+		Heartbeat: paramscmd.Heartbeat{
+			Entity: "testdata/main.go",
+			Sanitize: paramscmd.SanitizeParams{
+				HideFileNames: []regex.Regex{regex.MustCompile(".*")},
+			},
+		},
+	}
+
+	var sent []heartbeat.Heartbeat
+
+	sender := mockSender{
+		SendHeartbeatsFn: func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+			sent = hh
+
+			return nil, nil
+		},
+	}
+
+	handle := heartbeat.NewHandle(&sender, initHandleOptions(params)...)
+
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{
+		{
+			Category:   heartbeat.CodingCategory,
+			Entity:     "testdata/main.go",
+			EntityType: heartbeat.FileType,
+			Time:       1585598059.1,
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sent, 1)
+	assert.Equal(t, "HIDDEN.go", sent[0].Entity)
+	assert.Equal(t, "00000000-0000-4000-8000-000000000001", sent[0].ApiKey)
+}
+
+type mockSender struct {
+	SendHeartbeatsFn func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error)
+}
+
+func (m *mockSender) SendHeartbeats(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	return m.SendHeartbeatsFn(ctx, hh)
+}
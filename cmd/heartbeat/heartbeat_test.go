@@ -1,19 +1,25 @@
 package heartbeat_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/wakatime/wakatime-cli/cmd"
 	cmdheartbeat "github.com/wakatime/wakatime-cli/cmd/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/api"
 	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
 	"github.com/wakatime/wakatime-cli/pkg/log"
 	"github.com/wakatime/wakatime-cli/pkg/offline"
@@ -24,6 +30,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	bolt "go.etcd.io/bbolt"
+	"gopkg.in/ini.v1"
 )
 
 func TestSendHeartbeats(t *testing.T) {
@@ -100,12 +107,303 @@ func TestSendHeartbeats(t *testing.T) {
 	offlineQueueFile, err := os.CreateTemp(t.TempDir(), "")
 	require.NoError(t, err)
 
-	err = cmdheartbeat.SendHeartbeats(v, offlineQueueFile.Name())
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
 	require.NoError(t, err)
 
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestSendHeartbeats_Destinations(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	wakapiServerURL, wakapiRouter, wakapiTearDown := setupTestServer()
+	defer wakapiTearDown()
+
+	var (
+		numCalls       int
+		numWakapiCalls int
+	)
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+
+		assert.Equal(t, []string{"Basic MDAwMDAwMDAtMDAwMC00MDAwLTgwMDAtMDAwMDAwMDAwMDAw"}, req.Header["Authorization"])
+
+		// send response
+		w.WriteHeader(http.StatusCreated)
+
+		f, err := os.Open("testdata/api_heartbeats_response.json")
+		require.NoError(t, err)
+		defer f.Close()
+
+		_, err = io.Copy(w, f)
+		require.NoError(t, err)
+	})
+
+	wakapiRouter.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		numWakapiCalls++
+
+		assert.Equal(t, []string{"Basic MDAwMDAwMDAtMDAwMC00MDAwLTgwMDAtMDAwMDAwMDAwMDAx"}, req.Header["Authorization"])
+
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime.cfg")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v := viper.New()
+	v.SetDefault("sync-offline-activity", 1000)
+	v.Set("api-url", testServerURL)
+	v.Set("config", tmpFile.Name())
+	v.Set("entity", "testdata/main.go")
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("time", 1585598059.1)
+	v.Set("settings.destinations", "wakapi")
+	v.Set("destination_wakapi.api_url", wakapiServerURL)
+	v.Set("destination_wakapi.api_key", "00000000-0000-4000-8000-000000000001")
+
+	offlineQueueFile, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, numCalls)
+	assert.Equal(t, 1, numWakapiCalls)
+
+	// failed heartbeats are only queued for the failing destination
+	count, err := offline.CountHeartbeats(offlineQueueFile.Name())
+	require.NoError(t, err)
+
+	assert.Zero(t, count)
+
+	count, err = offline.CountHeartbeats(offlineQueueFile.Name() + "_wakapi")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count)
+
+	// backoff is only applied to the failing destination
+	data, err := os.ReadFile(tmpFile.Name())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), "[internal_destination_wakapi]")
+	assert.NotContains(t, string(data), "[internal]")
+}
+
+func TestSendHeartbeats_Destinations_ConcurrentBackoff(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		arrived int
+		all     = make(chan struct{})
+	)
+
+	// every api fails once all requests arrived, so backoff of all handles is
+	// written to the config file at the same time
+	failing := func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		arrived++
+
+		if arrived == 3 {
+			close(all)
+		}
+		mu.Unlock()
+
+		select {
+		case <-all:
+		case <-time.After(time.Second):
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	v := viper.New()
+	v.SetDefault("sync-offline-activity", 1000)
+	v.Set("entity", "testdata/main.go")
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("time", 1585598059.1)
+	v.Set("settings.destinations", "wakapi,hakatime")
+
+	for _, name := range []string{"", "wakapi", "hakatime"} {
+		serverURL, router, tearDown := setupTestServer()
+		defer tearDown()
+
+		router.HandleFunc("/users/current/heartbeats.bulk", failing)
+
+		if name == "" {
+			v.Set("api-url", serverURL)
+			continue
+		}
+
+		v.Set("destination_"+name+".api_url", serverURL)
+		v.Set("destination_"+name+".api_key", "00000000-0000-4000-8000-000000000001")
+	}
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime.cfg")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v.Set("config", tmpFile.Name())
+
+	offlineQueueFile, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
+	require.Error(t, err)
+
+	cfg, err := ini.Load(tmpFile.Name())
+	require.NoError(t, err)
+
+	for _, section := range []string{"internal", "internal_destination_wakapi", "internal_destination_hakatime"} {
+		assert.NotEmpty(t, cfg.Section(section).Key("backoff_at").String(), section)
+	}
+}
+
+func TestSendHeartbeats_RunTimeout(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime.cfg")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v := viper.New()
+	v.SetDefault("sync-offline-activity", 1000)
+	v.Set("api-url", testServerURL)
+	v.Set("config", tmpFile.Name())
+	v.Set("entity", "testdata/main.go")
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("time", 1585598059.1)
+
+	offlineQueueFile, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+
+	<-ctx.Done()
+
+	err = cmdheartbeat.SendHeartbeats(ctx, v, offlineQueueFile.Name())
+	require.Error(t, err)
+
+	assert.Zero(t, numCalls)
+
+	// heartbeats, which could not be sent in time, are queued
+	count, err := offline.CountHeartbeats(offlineQueueFile.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count)
+}
+
+func TestSendHeartbeats_ErrServer(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime.cfg")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v := viper.New()
+	v.SetDefault("sync-offline-activity", 1000)
+	v.Set("api-url", testServerURL)
+	v.Set("config", tmpFile.Name())
+	v.Set("entity", "testdata/main.go")
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("time", 1585598059.1)
+
+	offlineQueueFile, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
+	require.Error(t, err)
+
+	var errserver api.ErrServer
+
+	assert.True(t, errors.As(err, &errserver))
+	assert.Equal(t, 1, numCalls)
+
+	// heartbeats, which failed due to a server error, are queued
+	count, err := offline.CountHeartbeats(offlineQueueFile.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count)
+}
+
+func TestSendHeartbeats_Unreachable(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+	})
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime.cfg")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v := viper.New()
+	v.SetDefault("sync-offline-activity", 1000)
+	v.Set("api-url", testServerURL)
+	v.Set("config", tmpFile.Name())
+	v.Set("entity", "testdata/main.go")
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("time", 1585598059.1)
+
+	tmpDir := t.TempDir()
+
+	offlineQueueFile, err := os.CreateTemp(tmpDir, "")
+	require.NoError(t, err)
+
+	// api host is known to be unreachable
+	u, err := url.Parse(testServerURL)
+	require.NoError(t, err)
+
+	err = os.WriteFile(
+		filepath.Join(tmpDir, ".wakatime-connectivity.json"),
+		[]byte(fmt.Sprintf(`{%q:{"checked_at":%q,"reachable":false}}`, u.Host, time.Now().Format(time.RFC3339))),
+		0600,
+	)
+	require.NoError(t, err)
+
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
+	require.Error(t, err)
+
+	var errconn api.ErrConnection
+
+	assert.True(t, errors.As(err, &errconn))
+	assert.Zero(t, numCalls)
+
+	// heartbeats go straight to the offline queue
+	count, err := offline.CountHeartbeats(offlineQueueFile.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count)
+}
+
 func TestSendHeartbeats_WithFiltering_Exclude(t *testing.T) {
 	testServerURL, router, tearDown := setupTestServer()
 	defer tearDown()
@@ -134,7 +432,7 @@ func TestSendHeartbeats_WithFiltering_Exclude(t *testing.T) {
 	offlineQueueFile, err := os.CreateTemp(t.TempDir(), "")
 	require.NoError(t, err)
 
-	err = cmdheartbeat.SendHeartbeats(v, offlineQueueFile.Name())
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
 	require.NoError(t, err)
 
 	assert.Equal(t, 0, numCalls)
@@ -264,7 +562,7 @@ func TestSendHeartbeats_ExtraHeartbeats(t *testing.T) {
 
 	defer offlineQueueFile.Close()
 
-	err = cmdheartbeat.SendHeartbeats(v, offlineQueueFile.Name())
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
 	require.NoError(t, err)
 
 	offlineCount, err := offline.CountHeartbeats(offlineQueueFile.Name())
@@ -347,7 +645,7 @@ func TestSendHeartbeats_ExtraHeartbeats_Sanitize(t *testing.T) {
 
 	defer offlineQueueFile.Close()
 
-	err = cmdheartbeat.SendHeartbeats(v, offlineQueueFile.Name())
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
 	require.NoError(t, err)
 
 	offlineCount, err := offline.CountHeartbeats(offlineQueueFile.Name())
@@ -363,14 +661,14 @@ func TestSendHeartbeats_ExtraHeartbeats_Sanitize(t *testing.T) {
 
 	q := offline.NewQueue(tx)
 
-	hh, err := q.PopMany(1)
+	records, err := q.PopMany(1)
 	require.NoError(t, err)
 
 	err = tx.Commit()
 	require.NoError(t, err)
 
 	assert.Equal(t, 1, offlineCount)
-	assert.Len(t, hh, 1)
+	require.Len(t, records, 1)
 
 	info, err := goInfo.GetInfo()
 	require.NoError(t, err)
@@ -400,7 +698,7 @@ func TestSendHeartbeats_ExtraHeartbeats_Sanitize(t *testing.T) {
 			Project:        heartbeat.PointerTo("wakatime-cli"),
 			Time:           1585598059,
 			UserAgent:      userAgent,
-		}}, hh)
+		}}, []heartbeat.Heartbeat{records[0].Heartbeat})
 
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
@@ -437,7 +735,7 @@ func TestSendHeartbeats_NonExistingEntity(t *testing.T) {
 
 	defer f.Close()
 
-	err = cmdheartbeat.SendHeartbeats(v, f.Name())
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, f.Name())
 	require.NoError(t, err)
 
 	output, err := io.ReadAll(logFile)
@@ -564,7 +862,7 @@ func TestSendHeartbeats_IsUnsavedEntity(t *testing.T) {
 		}
 	}()
 
-	err = cmdheartbeat.SendHeartbeats(v, offlineQueueFile.Name())
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
 	require.NoError(t, err)
 
 	output, err := io.ReadAll(logFile)
@@ -681,7 +979,7 @@ func TestSendHeartbeats_NonExistingExtraHeartbeatsEntity(t *testing.T) {
 		}
 	}()
 
-	err = cmdheartbeat.SendHeartbeats(v, offlineQueueFile.Name())
+	err = cmdheartbeat.SendHeartbeats(context.Background(), v, offlineQueueFile.Name())
 	require.NoError(t, err)
 
 	output, err := io.ReadAll(logFile)
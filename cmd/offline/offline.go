@@ -1,6 +1,7 @@
 package offline
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -21,7 +22,7 @@ import (
 // SaveHeartbeats saves heartbeats to the offline db, when we haven't
 // tried sending them to the API. If we tried sending to API already,
 // to the API. Used when we have heartbeats unsent to API.
-func SaveHeartbeats(v *viper.Viper, heartbeats []heartbeat.Heartbeat, queueFilepath string) error {
+func SaveHeartbeats(ctx context.Context, v *viper.Viper, heartbeats []heartbeat.Heartbeat, queueFilepath string) error {
 	params, err := loadParams(v)
 	if err != nil {
 		return fmt.Errorf("failed to load command parameters: %w", err)
@@ -47,12 +48,19 @@ func SaveHeartbeats(v *viper.Viper, heartbeats []heartbeat.Heartbeat, queueFilep
 		queueFilepath = params.Offline.QueueFile
 	}
 
-	handleOpts = append(handleOpts, offline.WithQueue(queueFilepath))
+	handleOpts = append(handleOpts, offline.WithQueue(queueFilepath, params.Offline.QueueOptions()...))
+
+	for _, d := range params.Destinations {
+		handleOpts = append(handleOpts, offline.WithQueue(
+			d.QueueFilepath(queueFilepath),
+			params.Offline.DestinationQueueOptions(d)...,
+		))
+	}
 
 	sender := offline.Sender{}
 	handle := heartbeat.NewHandle(sender, handleOpts...)
 
-	_, _ = handle(heartbeats)
+	_, _ = handle(ctx, heartbeats)
 
 	return nil
 }
@@ -68,9 +76,15 @@ func loadParams(v *viper.Viper) (paramscmd.Params, error) {
 		log.Warnf("failed to load offline parameters: %s", err)
 	}
 
+	paramDestinations, err := paramscmd.LoadDestinationParams(v, paramAPI)
+	if err != nil {
+		log.Warnf("failed to load destination parameters: %s", err)
+	}
+
 	params := paramscmd.Params{
-		API:     paramAPI,
-		Offline: paramOffline,
+		API:          paramAPI,
+		Destinations: paramDestinations,
+		Offline:      paramOffline,
 	}
 
 	paramHeartbeat, err := paramscmd.LoadHeartbeatParams(v)
@@ -1,6 +1,7 @@
 package offlinecount
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/wakatime/wakatime-cli/cmd/params"
@@ -11,7 +12,7 @@ import (
 )
 
 // Run executes the offline-count command.
-func Run(v *viper.Viper) (int, error) {
+func Run(_ context.Context, v *viper.Viper) (int, error) {
 	queueFilepath, err := offline.QueueFilepath()
 	if err != nil {
 		return exitcode.ErrGeneric, fmt.Errorf(
@@ -29,7 +30,7 @@ func Run(v *viper.Viper) (int, error) {
 		queueFilepath = p.QueueFile
 	}
 
-	count, err := offline.CountHeartbeats(queueFilepath)
+	count, err := offline.CountHeartbeats(queueFilepath, p.QueueOptions()...)
 	if err != nil {
 		fmt.Println(err)
 		return exitcode.ErrGeneric, fmt.Errorf("failed to count offline heartbeats: %w", err)
@@ -2,6 +2,7 @@ package offlinecount_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -39,7 +40,7 @@ func TestOfflineCount_Empty(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	code, err := offlinecount.Run(v)
+	code, err := offlinecount.Run(context.Background(), v)
 	assert.Equal(t, exitcode.Success, code)
 	require.NoError(t, err)
 
@@ -100,7 +101,7 @@ func TestOfflineCount(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	code, err := offlinecount.Run(v)
+	code, err := offlinecount.Run(context.Background(), v)
 
 	outC := make(chan string)
 	// copy the output in a separate goroutine so printing can't block indefinitely
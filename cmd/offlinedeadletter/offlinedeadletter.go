@@ -0,0 +1,150 @@
+package offlinedeadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+	"github.com/wakatime/wakatime-cli/pkg/output"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+// RunList executes the offline-dead-letter command.
+func RunList(_ context.Context, v *viper.Viper) (int, error) {
+	out, err := List(v)
+	if err != nil {
+		return exitcode.ErrGeneric, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	fmt.Print(out)
+
+	return exitcode.Success, nil
+}
+
+// RunRetry executes the offline-dead-letter-retry command.
+func RunRetry(_ context.Context, v *viper.Viper) (int, error) {
+	queueFilepath, opts, err := loadQueue(v)
+	if err != nil {
+		return exitcode.ErrGeneric, err
+	}
+
+	count, err := offline.RetryDeadLetters(queueFilepath, opts...)
+	if err != nil {
+		return exitcode.ErrGeneric, fmt.Errorf("failed to retry dead letters: %w", err)
+	}
+
+	fmt.Println(count)
+
+	return exitcode.Success, nil
+}
+
+// RunPurge executes the offline-dead-letter-purge command.
+func RunPurge(_ context.Context, v *viper.Viper) (int, error) {
+	queueFilepath, opts, err := loadQueue(v)
+	if err != nil {
+		return exitcode.ErrGeneric, err
+	}
+
+	count, err := offline.PurgeDeadLetters(queueFilepath, opts...)
+	if err != nil {
+		return exitcode.ErrGeneric, fmt.Errorf("failed to purge dead letters: %w", err)
+	}
+
+	fmt.Println(count)
+
+	return exitcode.Success, nil
+}
+
+// List returns the rendered dead letters from the offline queue.
+func List(v *viper.Viper) (string, error) {
+	queueFilepath, opts, err := loadQueue(v)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse output: %s", err)
+	}
+
+	entries, err := offline.ListDeadLetters(queueFilepath, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	if out == output.JSONOutput {
+		if entries == nil {
+			entries = []offline.DeadLetter{}
+		}
+
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return "", fmt.Errorf("failed to json marshal dead letters: %s", err)
+		}
+
+		return string(data) + "\n", nil
+	}
+
+	return renderTable(entries), nil
+}
+
+// loadQueue returns the offline queue filepath and the options to access the
+// configured offline backend with.
+func loadQueue(v *viper.Viper) (string, []offline.Option, error) {
+	queueFilepath, err := offline.QueueFilepath()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load offline queue filepath: %s", err)
+	}
+
+	p, err := params.LoadOfflineParams(v)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load offline parameters: %w", err)
+	}
+
+	if p.QueueFile != "" {
+		queueFilepath = p.QueueFile
+	}
+
+	return queueFilepath, p.QueueOptions(), nil
+}
+
+func renderTable(entries []offline.DeadLetter) string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "FAILED AT\tSTATUS\tPROJECT\tENTITY\tERRORS")
+
+	for _, entry := range entries {
+		var project string
+		if entry.Heartbeat.Project != nil {
+			project = *entry.Heartbeat.Project
+		}
+
+		status := "-"
+		if entry.Status > 0 {
+			status = strconv.Itoa(entry.Status)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			entry.FailedAt.Local().Format(time.RFC3339),
+			status,
+			project,
+			entry.Heartbeat.Entity,
+			strings.Join(entry.Errors, "; "),
+		)
+	}
+
+	_ = w.Flush()
+
+	return b.String()
+}
@@ -0,0 +1,215 @@
+package offlinedeadletter_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/cmd/offlinedeadletter"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestList(t *testing.T) {
+	v := setupDeadLetters(t)
+
+	out, err := offlinedeadletter.List(v)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+
+	assert.Equal(t, []string{"FAILED", "AT", "STATUS", "PROJECT", "ENTITY", "ERRORS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{
+		time.Date(2022, 4, 10, 12, 0, 0, 0, time.UTC).Local().Format(time.RFC3339),
+		"400",
+		"wakatime-cli",
+		"/tmp/main.go",
+		"Invalid",
+		"entity",
+	}, strings.Fields(lines[1]))
+}
+
+func TestList_JSON(t *testing.T) {
+	v := setupDeadLetters(t)
+	v.Set("output", "json")
+
+	out, err := offlinedeadletter.List(v)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `[{
+		"id": "1592868367.219124-file-coding-wakatime-cli--/tmp/main.go-true",
+		"heartbeat": {
+			"branch": null,
+			"category": "coding",
+			"cursorpos": null,
+			"dependencies": null,
+			"entity": "/tmp/main.go",
+			"is_write": true,
+			"language": null,
+			"lineno": null,
+			"lines": null,
+			"project": "wakatime-cli",
+			"type": "file",
+			"time": 1592868367.219124,
+			"user_agent": ""
+		},
+		"errors": ["Invalid entity"],
+		"status": 400,
+		"failed_at": "2022-04-10T12:00:00Z"
+	}]`, out)
+}
+
+func TestRunRetry(t *testing.T) {
+	v := setupDeadLetters(t)
+
+	output := captureStdout(t, func() {
+		code, err := offlinedeadletter.RunRetry(context.Background(), v)
+		require.NoError(t, err)
+
+		assert.Equal(t, exitcode.Success, code)
+	})
+
+	assert.Equal(t, "1\n", output)
+
+	count, err := offline.CountHeartbeats(v.GetString("offline-queue-file"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count)
+}
+
+func TestRunPurge(t *testing.T) {
+	v := setupDeadLetters(t)
+
+	output := captureStdout(t, func() {
+		code, err := offlinedeadletter.RunPurge(context.Background(), v)
+		require.NoError(t, err)
+
+		assert.Equal(t, exitcode.Success, code)
+	})
+
+	assert.Equal(t, "1\n", output)
+
+	entries, err := offline.ListDeadLetters(v.GetString("offline-queue-file"))
+	require.NoError(t, err)
+
+	assert.Empty(t, entries)
+}
+
+func TestRunRetry_Spool(t *testing.T) {
+	dir := t.TempDir()
+
+	h := heartbeat.Heartbeat{
+		Category:   heartbeat.CodingCategory,
+		Entity:     "/tmp/main.go",
+		EntityType: heartbeat.FileType,
+		IsWrite:    heartbeat.PointerTo(true),
+		Time:       1592868367.219124,
+	}
+
+	err := offline.NewSpoolStore(dir).PushDeadLetters([]offline.DeadLetter{
+		{
+			ID:        h.ID(),
+			Heartbeat: h,
+			Status:    http.StatusBadRequest,
+			FailedAt:  time.Date(2022, 4, 10, 12, 0, 0, 0, time.UTC),
+		},
+	})
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("settings.offline_backend", "spool")
+	v.Set("settings.offline_spool_dir", dir)
+
+	out, err := offlinedeadletter.List(v)
+	require.NoError(t, err)
+
+	assert.Len(t, strings.Split(strings.TrimSpace(out), "\n"), 2)
+
+	output := captureStdout(t, func() {
+		code, err := offlinedeadletter.RunRetry(context.Background(), v)
+		require.NoError(t, err)
+
+		assert.Equal(t, exitcode.Success, code)
+	})
+
+	assert.Equal(t, "1\n", output)
+
+	count, err := offline.CountHeartbeats("", offline.WithSpool(dir))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count)
+}
+
+func setupDeadLetters(t *testing.T) *viper.Viper {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	h := heartbeat.Heartbeat{
+		Category:   heartbeat.CodingCategory,
+		Entity:     "/tmp/main.go",
+		EntityType: heartbeat.FileType,
+		IsWrite:    heartbeat.PointerTo(true),
+		Project:    heartbeat.PointerTo("wakatime-cli"),
+		Time:       1592868367.219124,
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).PushDeadLetters([]offline.DeadLetter{
+			{
+				ID:        h.ID(),
+				Heartbeat: h,
+				Errors:    []string{"Invalid entity"},
+				Status:    http.StatusBadRequest,
+				FailedAt:  time.Date(2022, 4, 10, 12, 0, 0, 0, time.UTC),
+			},
+		})
+	})
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("offline-queue-file", f.Name())
+
+	return v
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	stdout := os.Stdout // keep backup of the real stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	fn()
+
+	outC := make(chan string)
+	// copy the output in a separate goroutine so printing can't block indefinitely
+	go func() {
+		var buf bytes.Buffer
+		_, err := io.Copy(&buf, r)
+		require.NoError(t, err)
+		outC <- buf.String()
+	}()
+
+	w.Close()
+
+	os.Stdout = stdout
+
+	return <-outC
+}
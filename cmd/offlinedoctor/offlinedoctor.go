@@ -0,0 +1,121 @@
+package offlinedoctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+	"github.com/wakatime/wakatime-cli/pkg/output"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+// Report contains the result of checking and repairing the offline db.
+type Report struct {
+	Healthy  bool     `json:"healthy"`
+	Problems []string `json:"problems"`
+	MovedTo  string   `json:"moved_to,omitempty"`
+	Salvaged int      `json:"salvaged"`
+}
+
+// Run executes the offline-doctor command.
+func Run(_ context.Context, v *viper.Viper) (int, error) {
+	out, err := Doctor(v)
+	if err != nil {
+		return exitcode.ErrGeneric, fmt.Errorf("failed to repair offline db: %w", err)
+	}
+
+	fmt.Print(out)
+
+	return exitcode.Success, nil
+}
+
+// Doctor checks the consistency of the offline db. A broken db is moved aside
+// and all readable records are salvaged into a fresh db. Returns the rendered
+// report.
+func Doctor(v *viper.Viper) (string, error) {
+	queueFilepath, err := offline.QueueFilepath()
+	if err != nil {
+		return "", fmt.Errorf("failed to load offline queue filepath: %s", err)
+	}
+
+	p, err := params.LoadOfflineParams(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to load offline parameters: %w", err)
+	}
+
+	if p.Backend != offline.BackendBolt {
+		return "", fmt.Errorf("offline doctor is not supported by offline backend %q", p.Backend)
+	}
+
+	if p.QueueFile != "" {
+		queueFilepath = p.QueueFile
+	}
+
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse output: %s", err)
+	}
+
+	problems, err := offline.CheckDB(queueFilepath)
+	if err != nil {
+		return "", err
+	}
+
+	report := Report{
+		Healthy:  len(problems) == 0,
+		Problems: problems,
+	}
+
+	if !report.Healthy {
+		repaired, err := offline.RepairDB(queueFilepath)
+		if err != nil {
+			return "", err
+		}
+
+		report.MovedTo = repaired.MovedTo
+		report.Salvaged = repaired.Salvaged
+	}
+
+	if out == output.JSONOutput {
+		if report.Problems == nil {
+			report.Problems = []string{}
+		}
+
+		data, err := json.Marshal(report)
+		if err != nil {
+			return "", fmt.Errorf("failed to json marshal report: %s", err)
+		}
+
+		return string(data) + "\n", nil
+	}
+
+	return renderText(queueFilepath, report), nil
+}
+
+func renderText(queueFilepath string, report Report) string {
+	if report.Healthy {
+		return fmt.Sprintf("offline db %s is healthy\n", queueFilepath)
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "offline db %s is broken:\n", queueFilepath)
+
+	for _, problem := range report.Problems {
+		fmt.Fprintf(&b, "  %s\n", problem)
+	}
+
+	if report.MovedTo != "" {
+		fmt.Fprintf(&b, "moved broken db to %s\n", report.MovedTo)
+	}
+
+	fmt.Fprintf(&b, "salvaged %d record(s) into fresh db\n", report.Salvaged)
+
+	return b.String()
+}
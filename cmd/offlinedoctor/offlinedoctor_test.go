@@ -0,0 +1,73 @@
+package offlinedoctor_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/cmd/offlinedoctor"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDoctor(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	db, err := bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("offline-queue-file", fp)
+
+	out, err := offlinedoctor.Doctor(v)
+	require.NoError(t, err)
+
+	assert.Equal(t, "offline db "+fp+" is healthy\n", out)
+}
+
+func TestDoctor_Broken(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	err := os.WriteFile(fp, []byte("not a bolt db, but long enough to look like one at first sight"), 0600)
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("offline-queue-file", fp)
+	v.Set("output", "json")
+
+	out, err := offlinedoctor.Doctor(v)
+	require.NoError(t, err)
+
+	var report offlinedoctor.Report
+
+	err = json.Unmarshal([]byte(out), &report)
+	require.NoError(t, err)
+
+	assert.False(t, report.Healthy)
+	assert.NotEmpty(t, report.Problems)
+	assert.Zero(t, report.Salvaged)
+	assert.FileExists(t, report.MovedTo)
+
+	// fresh db is healthy
+	out, err = offlinedoctor.Doctor(v)
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"healthy":true,"problems":[],"salvaged":0}`+"\n", out)
+}
+
+func TestDoctor_SpoolBackend(t *testing.T) {
+	v := viper.New()
+	v.Set("settings.offline_backend", "spool")
+
+	_, err := offlinedoctor.Doctor(v)
+	require.Error(t, err)
+
+	assert.Equal(t, `offline doctor is not supported by offline backend "spool"`, err.Error())
+}
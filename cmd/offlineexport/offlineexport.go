@@ -0,0 +1,75 @@
+package offlineexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+// stdout is the export target to write to standard output instead of a file.
+const stdout = "-"
+
+// Run executes the offline-export command.
+func Run(_ context.Context, v *viper.Viper) (int, error) {
+	count, err := Export(v)
+	if err != nil {
+		return exitcode.ErrGeneric, fmt.Errorf("failed to export offline heartbeats: %w", err)
+	}
+
+	log.Debugf("exported %d heartbeat(s)", count)
+
+	if vipertools.GetString(v, "offline-export") != stdout {
+		fmt.Println(count)
+	}
+
+	return exitcode.Success, nil
+}
+
+// Export writes all queued heartbeats as newline delimited json to the file
+// passed in via --offline-export, or to stdout if it is "-". Returns the
+// number of exported heartbeats.
+func Export(v *viper.Viper) (int, error) {
+	target := vipertools.GetString(v, "offline-export")
+	if target == "" {
+		return 0, errors.New("missing export file")
+	}
+
+	queueFilepath, err := offline.QueueFilepath()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load offline queue filepath: %s", err)
+	}
+
+	p, err := params.LoadOfflineParams(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load offline parameters: %w", err)
+	}
+
+	if p.QueueFile != "" {
+		queueFilepath = p.QueueFile
+	}
+
+	var w io.Writer = os.Stdout
+
+	if target != stdout {
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open export file: %s", err)
+		}
+
+		defer f.Close()
+
+		w = f
+	}
+
+	return offline.ExportHeartbeats(queueFilepath, w, p.QueueOptions()...)
+}
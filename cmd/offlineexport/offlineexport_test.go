@@ -0,0 +1,78 @@
+package offlineexport_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/cmd/offlineexport"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestExport(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("../testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	dataPy, err := os.ReadFile("../testdata/heartbeat_py.json")
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("heartbeats"))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %s", err)
+		}
+
+		if err := b.Put([]byte("1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true"), dataGo); err != nil {
+			return err
+		}
+
+		return b.Put([]byte("1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false"), dataPy)
+	})
+	require.NoError(t, err)
+
+	db.Close()
+
+	exportFile := filepath.Join(t.TempDir(), "export.ndjson")
+
+	v := viper.New()
+	v.Set("offline-export", exportFile)
+	v.Set("offline-queue-file", f.Name())
+
+	count, err := offlineexport.Export(v)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, count)
+
+	data, err := os.ReadFile(exportFile)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	assert.JSONEq(t, string(dataGo), lines[0])
+	assert.JSONEq(t, string(dataPy), lines[1])
+}
+
+func TestExport_MissingFile(t *testing.T) {
+	v := viper.New()
+	v.Set("offline-queue-file", filepath.Join(t.TempDir(), "offline.bdb"))
+
+	_, err := offlineexport.Export(v)
+	require.Error(t, err)
+
+	assert.Equal(t, "missing export file", err.Error())
+}
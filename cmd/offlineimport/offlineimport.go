@@ -0,0 +1,157 @@
+package offlineimport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// stdin is the import source to read from standard input instead of a file.
+	stdin = "-"
+	// maxLineSize is the maximum size of a single json encoded heartbeat.
+	maxLineSize = 1024 * 1024
+)
+
+// Run executes the offline-import command.
+func Run(_ context.Context, v *viper.Viper) (int, error) {
+	imported, err := Import(v)
+	if err != nil {
+		return exitcode.ErrGeneric, fmt.Errorf("failed to import offline heartbeats: %w", err)
+	}
+
+	fmt.Println(imported)
+
+	return exitcode.Success, nil
+}
+
+// Import reads newline delimited json heartbeats from the file passed in via
+// --offline-import, or from stdin if it is "-", and pushes them to the offline
+// queue. Invalid records are skipped. Returns the number of heartbeats, which
+// have not been queued before.
+func Import(v *viper.Viper) (int, error) {
+	source := vipertools.GetString(v, "offline-import")
+	if source == "" {
+		return 0, errors.New("missing import file")
+	}
+
+	queueFilepath, err := offline.QueueFilepath()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load offline queue filepath: %s", err)
+	}
+
+	p, err := params.LoadOfflineParams(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load offline parameters: %w", err)
+	}
+
+	if p.QueueFile != "" {
+		queueFilepath = p.QueueFile
+	}
+
+	var r io.Reader = os.Stdin
+
+	if source != stdin {
+		f, err := os.Open(source)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open import file: %s", err)
+		}
+
+		defer f.Close()
+
+		r = f
+	}
+
+	hh, err := readHeartbeats(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(hh) == 0 {
+		return 0, nil
+	}
+
+	return offline.ImportHeartbeats(queueFilepath, hh, p.QueueOptions()...)
+}
+
+// readHeartbeats parses newline delimited json heartbeats. Each record is
+// validated with the same rules as extra heartbeats and skipped if invalid.
+func readHeartbeats(r io.Reader) ([]heartbeat.Heartbeat, error) {
+	var (
+		hh      []heartbeat.Heartbeat
+		lineNum int
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		lineNum++
+
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		h, err := parseHeartbeat(line)
+		if err != nil {
+			log.Warnf("skipping invalid heartbeat on line %d: %s", lineNum, err)
+
+			continue
+		}
+
+		hh = append(hh, h)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read heartbeats: %s", err)
+	}
+
+	return hh, nil
+}
+
+func parseHeartbeat(data []byte) (heartbeat.Heartbeat, error) {
+	var extra params.ExtraHeartbeat
+
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return heartbeat.Heartbeat{}, fmt.Errorf("failed to json decode: %s", err)
+	}
+
+	h, err := params.ParseExtraHeartbeat(extra)
+	if err != nil {
+		return heartbeat.Heartbeat{}, err
+	}
+
+	// exported heartbeats have been processed before queueing, so fields set
+	// during processing are taken over as well.
+	var processed struct {
+		Branch       *string  `json:"branch"`
+		Dependencies []string `json:"dependencies"`
+		Project      *string  `json:"project"`
+		UserAgent    string   `json:"user_agent"`
+	}
+
+	if err := json.Unmarshal(data, &processed); err != nil {
+		return heartbeat.Heartbeat{}, fmt.Errorf("failed to json decode: %s", err)
+	}
+
+	h.Branch = processed.Branch
+	h.Dependencies = processed.Dependencies
+	h.Project = processed.Project
+	h.UserAgent = processed.UserAgent
+
+	return *h, nil
+}
@@ -0,0 +1,118 @@
+package offlineimport_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/cmd/offlineimport"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	queueFile := filepath.Join(t.TempDir(), "offline.bdb")
+	importFile := filepath.Join(t.TempDir(), "import.ndjson")
+
+	dataGo, err := os.ReadFile("../testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	dataPy, err := os.ReadFile("../testdata/heartbeat_py.json")
+	require.NoError(t, err)
+
+	content := strings.Join([]string{
+		compact(t, dataGo),
+		`{"entity":"/tmp/notime.go","type":"file","category":"coding"}`,
+		`{"entity":"/tmp/main.go","type":"invalid","category":"coding","time":1592868367.219124}`,
+		`not json`,
+		"",
+		compact(t, dataPy),
+		compact(t, dataGo),
+	}, "\n")
+
+	err = os.WriteFile(importFile, []byte(content), 0600)
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("offline-import", importFile)
+	v.Set("offline-queue-file", queueFile)
+
+	imported, err := offlineimport.Import(v)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, imported)
+
+	records, err := offline.ListHeartbeats(queueFile, offline.Filter{})
+	require.NoError(t, err)
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true", records[0].ID)
+	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", records[1].ID)
+	assert.Equal(t, []string{"dep1", "dep2"}, records[0].Heartbeat.Dependencies)
+	assert.Equal(t, "wakatime/13.0.6", records[0].Heartbeat.UserAgent)
+
+	// importing again does not duplicate heartbeats
+	imported, err = offlineimport.Import(v)
+	require.NoError(t, err)
+
+	assert.Zero(t, imported)
+}
+
+func TestImport_Normalize(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	queueFile := filepath.Join(t.TempDir(), "offline.bdb")
+	importFile := filepath.Join(t.TempDir(), "import.ndjson")
+
+	content := `{"entity":"~/main.go","entity_type":"file","category":"coding",` +
+		`"time":"1592868367.219124","lineno":"42","is_write":"true","project":"wakatime-cli"}`
+
+	err = os.WriteFile(importFile, []byte(content), 0600)
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("offline-import", importFile)
+	v.Set("offline-queue-file", queueFile)
+
+	imported, err := offlineimport.Import(v)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, imported)
+
+	records, err := offline.ListHeartbeats(queueFile, offline.Filter{})
+	require.NoError(t, err)
+
+	require.Len(t, records, 1)
+
+	h := records[0].Heartbeat
+
+	assert.Equal(t, filepath.Join(home, "main.go"), h.Entity)
+	assert.Equal(t, heartbeat.FileType, h.EntityType)
+	assert.Equal(t, 1592868367.219124, h.Time)
+	assert.Equal(t, heartbeat.PointerTo(42), h.LineNumber)
+	assert.Equal(t, heartbeat.PointerTo(true), h.IsWrite)
+	assert.Equal(t, heartbeat.PointerTo("wakatime-cli"), h.Project)
+}
+
+func TestImport_FileNotExists(t *testing.T) {
+	v := viper.New()
+	v.Set("offline-import", filepath.Join(t.TempDir(), "nonexisting"))
+	v.Set("offline-queue-file", filepath.Join(t.TempDir(), "offline.bdb"))
+
+	_, err := offlineimport.Import(v)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "failed to open import file")
+}
+
+func compact(t *testing.T, data []byte) string {
+	t.Helper()
+
+	return strings.Join(strings.Fields(string(data)), "")
+}
@@ -0,0 +1,223 @@
+package offlinelist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+	"github.com/wakatime/wakatime-cli/pkg/output"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+// Params contains offline-list command parameters.
+type Params struct {
+	Filter       offline.Filter
+	Output       output.Output
+	QueueFile    string
+	QueueOptions []offline.Option
+}
+
+// Run executes the offline-list command.
+func Run(_ context.Context, v *viper.Viper) (int, error) {
+	out, err := List(v)
+	if err != nil {
+		return exitcode.ErrGeneric, fmt.Errorf("failed to list offline heartbeats: %w", err)
+	}
+
+	fmt.Print(out)
+
+	return exitcode.Success, nil
+}
+
+// List returns the rendered heartbeats from the offline queue, which
+// match the filters passed in via command parameters.
+func List(v *viper.Viper) (string, error) {
+	p, err := LoadParams(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to load command parameters: %w", err)
+	}
+
+	records, err := offline.ListHeartbeats(p.QueueFile, p.Filter, p.QueueOptions...)
+	if err != nil {
+		return "", err
+	}
+
+	if p.Output == output.JSONOutput {
+		return renderJSON(records)
+	}
+
+	return renderTable(records), nil
+}
+
+// LoadParams loads offline-list params from viper.Viper instance.
+func LoadParams(v *viper.Viper) (Params, error) {
+	queueFilepath, err := offline.QueueFilepath()
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to load offline queue filepath: %s", err)
+	}
+
+	paramOffline, err := params.LoadOfflineParams(v)
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to load offline parameters: %w", err)
+	}
+
+	if paramOffline.QueueFile != "" {
+		queueFilepath = paramOffline.QueueFile
+	}
+
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to parse output: %s", err)
+	}
+
+	filter := offline.Filter{
+		ApiKey:  vipertools.GetString(v, "offline-list-key"),
+		Entity:  vipertools.GetString(v, "offline-list-entity"),
+		Project: vipertools.GetString(v, "offline-list-project"),
+		Offset:  v.GetInt("offline-list-offset"),
+		Limit:   v.GetInt("offline-list-limit"),
+	}
+
+	if filter.Offset < 0 || filter.Limit < 0 {
+		return Params{}, fmt.Errorf("offset and limit must be positive integer numbers")
+	}
+
+	paramAPI, err := params.LoadAPIParams(v)
+	if err != nil {
+		if filter.ApiKey != "" {
+			return Params{}, fmt.Errorf("failed to load API parameters: %w", err)
+		}
+
+		log.Warnf("failed to load API parameters: %s", err)
+	}
+
+	filter.ApiKeys = apikey.Config{
+		DefaultApiKey: paramAPI.Key,
+		MapPatterns:   paramAPI.KeyPatterns,
+	}
+
+	if s := vipertools.GetString(v, "offline-list-start"); s != "" {
+		filter.Start, err = parseTime(s)
+		if err != nil {
+			return Params{}, fmt.Errorf("failed to parse start time: %s", err)
+		}
+	}
+
+	if s := vipertools.GetString(v, "offline-list-end"); s != "" {
+		filter.End, err = parseEndTime(s)
+		if err != nil {
+			return Params{}, fmt.Errorf("failed to parse end time: %s", err)
+		}
+	}
+
+	return Params{
+		Filter:       filter,
+		Output:       out,
+		QueueFile:    queueFilepath,
+		QueueOptions: paramOffline.QueueOptions(),
+	}, nil
+}
+
+// parseTime parses a unix epoch timestamp, a RFC3339 formatted time or a
+// YYYY-MM-DD formatted date in local time.
+func parseTime(s string) (time.Time, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		sec := int64(secs)
+		return time.Unix(sec, int64((secs-float64(sec))*float64(time.Second))), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q. must be unix epoch, RFC3339 or YYYY-MM-DD", s)
+	}
+
+	return t, nil
+}
+
+// parseEndTime parses the end of a time range like parseTime, but a
+// YYYY-MM-DD formatted date includes the whole day.
+func parseEndTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+
+	return parseTime(s)
+}
+
+type listItem struct {
+	ID        string      `json:"id"`
+	ApiKey    string      `json:"api_key,omitempty"`
+	Heartbeat interface{} `json:"heartbeat"`
+}
+
+func renderJSON(records []offline.Record) (string, error) {
+	items := make([]listItem, len(records))
+
+	for i, r := range records {
+		items[i] = listItem{
+			ID:        r.ID,
+			ApiKey:    apikey.Mask(r.Heartbeat.ApiKey),
+			Heartbeat: r.Heartbeat,
+		}
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", fmt.Errorf("failed to json marshal heartbeats: %s", err)
+	}
+
+	return string(data) + "\n", nil
+}
+
+func renderTable(records []offline.Record) string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "TIME\tPROJECT\tCATEGORY\tTYPE\tWRITE\tAPI KEY\tENTITY")
+
+	for _, r := range records {
+		h := r.Heartbeat
+
+		var project string
+		if h.Project != nil {
+			project = *h.Project
+		}
+
+		var isWrite bool
+		if h.IsWrite != nil {
+			isWrite = *h.IsWrite
+		}
+
+		sec := int64(h.Time)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\t%s\n",
+			time.Unix(sec, 0).Format(time.RFC3339),
+			project,
+			h.Category,
+			h.EntityType,
+			isWrite,
+			apikey.Mask(h.ApiKey),
+			h.Entity,
+		)
+	}
+
+	_ = w.Flush()
+
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package offlinelist_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/cmd/offlinelist"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestList(t *testing.T) {
+	v := setupQueue(t)
+	v.Set("offline-list-project", "wakatime")
+
+	out, err := offlinelist.List(v)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+
+	assert.Equal(t, []string{"TIME", "PROJECT", "CATEGORY", "TYPE", "WRITE", "API", "KEY", "ENTITY"},
+		strings.Fields(lines[0]))
+	assert.Equal(t, []string{
+		time.Unix(1592868386, 0).Format(time.RFC3339),
+		"wakatime",
+		"debugging",
+		"file",
+		"false",
+		"<hidden>0000",
+		"/tmp/main.py",
+	}, strings.Fields(lines[1]))
+}
+
+func TestList_JSON(t *testing.T) {
+	v := setupQueue(t)
+	v.Set("output", "json")
+	v.Set("offline-list-entity", "main.go")
+
+	out, err := offlinelist.List(v)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `[{
+		"id": "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		"api_key": "<hidden>0000",
+		"heartbeat": {
+			"branch": "heartbeat",
+			"category": "coding",
+			"cursorpos": 12,
+			"dependencies": ["dep1", "dep2"],
+			"entity": "/tmp/main.go",
+			"is_write": true,
+			"language": "Go",
+			"lineno": 42,
+			"lines": 100,
+			"project": "wakatime-cli",
+			"type": "file",
+			"time": 1592868367.219124,
+			"user_agent": "wakatime/13.0.6"
+		}
+	}]`, out)
+}
+
+func TestList_Paging(t *testing.T) {
+	v := setupQueue(t)
+	v.Set("output", "json")
+	v.Set("offline-list-offset", 1)
+	v.Set("offline-list-limit", 1)
+
+	out, err := offlinelist.List(v)
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false")
+	assert.NotContains(t, out, "/tmp/main.go")
+}
+
+func TestList_TimeRange(t *testing.T) {
+	v := setupQueue(t)
+	v.Set("output", "json")
+	v.Set("offline-list-start", "1592868380")
+	v.Set("offline-list-end", time.Unix(1592868390, 0).Format(time.RFC3339))
+
+	out, err := offlinelist.List(v)
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "/tmp/main.py")
+	assert.NotContains(t, out, "/tmp/main.go")
+}
+
+func TestList_TimeRange_EndDate(t *testing.T) {
+	v := setupQueue(t)
+	v.Set("output", "json")
+	v.Set("offline-list-start", time.Unix(1592868380, 0).Format("2006-01-02"))
+	v.Set("offline-list-end", time.Unix(1592868386, 0).Format("2006-01-02"))
+
+	out, err := offlinelist.List(v)
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "/tmp/main.py")
+	assert.Contains(t, out, "/tmp/main.go")
+}
+
+func TestList_InvalidTime(t *testing.T) {
+	v := setupQueue(t)
+	v.Set("offline-list-start", "yesterday")
+
+	_, err := offlinelist.List(v)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), `invalid time "yesterday"`)
+}
+
+func TestList_KeyFilterWithoutApiKey(t *testing.T) {
+	v := setupQueue(t)
+	v.Set("key", "")
+	v.Set("offline-list-key", "00000000-0000-4000-8000-000000000000")
+
+	_, err := offlinelist.List(v)
+	require.Error(t, err)
+}
+
+func setupQueue(t *testing.T) *viper.Viper {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("../testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	dataPy, err := os.ReadFile("../testdata/heartbeat_py.json")
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "heartbeats", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(dataGo),
+		},
+		{
+			ID:        "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+			Heartbeat: string(dataPy),
+		},
+	})
+
+	db.Close()
+
+	v := viper.New()
+	v.Set("offline-list", true)
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("offline-queue-file", f.Name())
+
+	return v
+}
+
+type heartbeatRecord struct {
+	ID        string
+	Heartbeat string
+}
+
+func insertHeartbeatRecords(t *testing.T, db *bolt.DB, bucket string, hh []heartbeatRecord) {
+	for _, h := range hh {
+		insertHeartbeatRecord(t, db, bucket, h)
+	}
+}
+
+func insertHeartbeatRecord(t *testing.T, db *bolt.DB, bucket string, h heartbeatRecord) {
+	t.Helper()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %s", err)
+		}
+
+		err = b.Put([]byte(h.ID), []byte(h.Heartbeat))
+		if err != nil {
+			return fmt.Errorf("failed put hearbeat: %s", err)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+}
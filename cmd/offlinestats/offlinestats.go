@@ -0,0 +1,149 @@
+package offlinestats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+	"github.com/wakatime/wakatime-cli/pkg/output"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+// Run executes the offline-stats command.
+func Run(_ context.Context, v *viper.Viper) (int, error) {
+	out, err := Stats(v)
+	if err != nil {
+		return exitcode.ErrGeneric, fmt.Errorf("failed to compute offline queue stats: %w", err)
+	}
+
+	fmt.Print(out)
+
+	return exitcode.Success, nil
+}
+
+// Stats returns the rendered statistics about the heartbeats in the offline
+// queue.
+func Stats(v *viper.Viper) (string, error) {
+	queueFilepath, err := offline.QueueFilepath()
+	if err != nil {
+		return "", fmt.Errorf("failed to load offline queue filepath: %s", err)
+	}
+
+	p, err := params.LoadOfflineParams(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to load offline parameters: %w", err)
+	}
+
+	if p.QueueFile != "" {
+		queueFilepath = p.QueueFile
+	}
+
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse output: %s", err)
+	}
+
+	paramAPI, err := params.LoadAPIParams(v)
+	if err != nil {
+		log.Warnf("failed to load API parameters: %s", err)
+	}
+
+	stats, err := offline.QueueStats(queueFilepath, apikey.Config{
+		DefaultApiKey: paramAPI.Key,
+		MapPatterns:   paramAPI.KeyPatterns,
+	}, p.QueueOptions()...)
+	if err != nil {
+		return "", err
+	}
+
+	if out == output.JSONOutput {
+		data, err := json.Marshal(stats)
+		if err != nil {
+			return "", fmt.Errorf("failed to json marshal stats: %s", err)
+		}
+
+		return string(data) + "\n", nil
+	}
+
+	return renderText(stats), nil
+}
+
+func renderText(stats offline.Stats) string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "heartbeats:\t%d\n", stats.Count)
+	fmt.Fprintf(w, "bytes:\t%d\n", stats.Bytes)
+
+	if stats.Oldest != nil && stats.Newest != nil {
+		fmt.Fprintf(w, "oldest:\t%s\n", stats.Oldest.Local().Format(time.RFC3339))
+		fmt.Fprintf(w, "newest:\t%s\n", stats.Newest.Local().Format(time.RFC3339))
+	}
+
+	renderGroup(w, "PROJECT", stats.ByProject, byCount(stats.ByProject))
+	renderGroup(w, "DAY", stats.ByDay, byKey(stats.ByDay))
+	renderGroup(w, "API KEY", stats.ByApiKey, byCount(stats.ByApiKey))
+	renderGroup(w, "CATEGORY", stats.ByCategory, byCount(stats.ByCategory))
+	renderGroup(w, "AGE", stats.ByAge, offline.AgeLabels())
+
+	_ = w.Flush()
+
+	return b.String()
+}
+
+func renderGroup(w *tabwriter.Writer, title string, counts map[string]int, keys []string) {
+	if len(counts) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\tCOUNT\n", title)
+
+	for _, key := range keys {
+		count, ok := counts[key]
+		if !ok {
+			continue
+		}
+
+		if key == "" {
+			key = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%d\n", key, count)
+	}
+}
+
+// byCount returns the keys ordered by descending count and by name.
+func byCount(counts map[string]int) []string {
+	keys := byKey(counts)
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return counts[keys[i]] > counts[keys[j]]
+	})
+
+	return keys
+}
+
+// byKey returns the keys in ascending order.
+func byKey(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
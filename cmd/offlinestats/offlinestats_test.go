@@ -0,0 +1,80 @@
+package offlinestats_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/cmd/offlinestats"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	v := setupQueue(t)
+
+	out, err := offlinestats.Stats(v)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+
+	assert.Equal(t, []string{"heartbeats:", "2"}, strings.Fields(lines[0]))
+	assert.Contains(t, out, "PROJECT")
+	assert.Contains(t, out, "wakatime-cli  2")
+	assert.Contains(t, out, "<hidden>0000  2")
+	assert.Contains(t, out, "<1h  2")
+}
+
+func TestStats_JSON(t *testing.T) {
+	v := setupQueue(t)
+	v.Set("output", "json")
+
+	out, err := offlinestats.Stats(v)
+	require.NoError(t, err)
+
+	var stats offline.Stats
+
+	err = json.Unmarshal([]byte(out), &stats)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, map[string]int{"wakatime-cli": 2}, stats.ByProject)
+	assert.Equal(t, map[string]int{"<hidden>0000": 2}, stats.ByApiKey)
+	assert.Equal(t, map[string]int{"<1h": 2}, stats.ByAge)
+}
+
+func setupQueue(t *testing.T) *viper.Viper {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	now := time.Now()
+
+	err := offline.NewBoltStore(fp).Push([]heartbeat.Heartbeat{
+		{
+			Category:   heartbeat.CodingCategory,
+			Entity:     "/tmp/main.go",
+			EntityType: heartbeat.FileType,
+			Project:    heartbeat.PointerTo("wakatime-cli"),
+			Time:       float64(now.Add(-time.Minute).Unix()),
+		},
+		{
+			Category:   heartbeat.CodingCategory,
+			Entity:     "/tmp/main.go",
+			EntityType: heartbeat.FileType,
+			Project:    heartbeat.PointerTo("wakatime-cli"),
+			Time:       float64(now.Unix()),
+		},
+	})
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("offline-queue-file", fp)
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+
+	return v
+}
@@ -1,6 +1,7 @@
 package offlinesync
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/wakatime/wakatime-cli/cmd/params"
 	"github.com/wakatime/wakatime-cli/pkg/api"
 	"github.com/wakatime/wakatime-cli/pkg/apikey"
+	"github.com/wakatime/wakatime-cli/pkg/backoff"
 	"github.com/wakatime/wakatime-cli/pkg/exitcode"
 	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
 	"github.com/wakatime/wakatime-cli/pkg/log"
@@ -17,7 +19,7 @@ import (
 )
 
 // Run executes the sync-offline-activity command.
-func Run(v *viper.Viper) (int, error) {
+func Run(ctx context.Context, v *viper.Viper) (int, error) {
 	queueFilepath, err := offline.QueueFilepath()
 	if err != nil {
 		return exitcode.ErrGeneric, fmt.Errorf(
@@ -26,7 +28,7 @@ func Run(v *viper.Viper) (int, error) {
 		)
 	}
 
-	err = SyncOfflineActivity(v, queueFilepath)
+	err = SyncOfflineActivity(ctx, v, queueFilepath)
 	if err != nil {
 		var errauth api.ErrAuth
 		if errors.As(err, &errauth) {
@@ -44,6 +46,22 @@ func Run(v *viper.Viper) (int, error) {
 			)
 		}
 
+		var errratelimit api.ErrRateLimit
+		if errors.As(err, &errratelimit) {
+			return exitcode.ErrRateLimit, fmt.Errorf(
+				"offline sync failed: rate limited: %s",
+				err,
+			)
+		}
+
+		if code, reason, ok := cmdapi.ErrExitCode(err); ok {
+			return code, fmt.Errorf(
+				"offline sync failed: %s: %s",
+				reason,
+				err,
+			)
+		}
+
 		var errapi api.Err
 		if errors.As(err, &errapi) {
 			return exitcode.ErrAPI, fmt.Errorf(
@@ -72,7 +90,7 @@ func Run(v *viper.Viper) (int, error) {
 
 // SyncOfflineActivity syncs offline activity by sending heartbeats
 // from the offline queue to the WakaTime API.
-func SyncOfflineActivity(v *viper.Viper, queueFilepath string) error {
+func SyncOfflineActivity(ctx context.Context, v *viper.Viper, queueFilepath string) error {
 	paramOffline, err := params.LoadOfflineParams(v)
 	if err != nil {
 		return fmt.Errorf("failed to load offline parameters: %w", err)
@@ -83,28 +101,75 @@ func SyncOfflineActivity(v *viper.Viper, queueFilepath string) error {
 		return fmt.Errorf("failed to load API parameters: %w", err)
 	}
 
-	if paramOffline.SyncMax == 0 {
-		return ErrSyncDisabled("sync offline activity is disabled")
+	paramDestinations, err := params.LoadDestinationParams(v, paramAPI)
+	if err != nil {
+		return fmt.Errorf("failed to load destination parameters: %w", err)
 	}
 
-	apiClient, err := cmdapi.NewClient(paramAPI)
-	if err != nil {
-		return fmt.Errorf("failed to initialize api client: %w", err)
+	if paramOffline.SyncMax == 0 {
+		return ErrSyncDisabled("sync offline activity is disabled")
 	}
 
 	if paramOffline.QueueFile != "" {
 		queueFilepath = paramOffline.QueueFile
 	}
 
-	handle := heartbeat.NewHandle(apiClient,
-		offline.WithSync(queueFilepath, paramOffline.SyncMax),
+	err = syncQueue(ctx, v, paramAPI, paramOffline, "internal", queueFilepath, paramOffline.QueueOptions())
+
+	// every destination syncs its own offline queue, regardless of failures of others
+	for _, d := range paramDestinations {
+		if err := syncQueue(
+			ctx,
+			v,
+			d.API,
+			paramOffline,
+			d.InternalSection(),
+			d.QueueFilepath(queueFilepath),
+			paramOffline.DestinationQueueOptions(d),
+		); err != nil {
+			log.Warnf("failed to sync offline activity of destination %q: %s", d.Name, err)
+		}
+	}
+
+	return err
+}
+
+// syncQueue sends heartbeats from the offline queue at queueFilepath to the
+// api. The rate limit state is stored in the passed in internal config section.
+// Syncing is skipped, while the api is known to be unreachable.
+func syncQueue(
+	ctx context.Context,
+	v *viper.Viper,
+	paramAPI params.API,
+	paramOffline params.Offline,
+	section string,
+	queueFilepath string,
+	opts []offline.Option,
+) error {
+	sender, err := cmdapi.NewSender(paramAPI, cmdapi.WithGzip(v, section, paramAPI))
+	if err != nil {
+		return fmt.Errorf("failed to initialize api client: %w", err)
+	}
+
+	handle := heartbeat.NewHandle(sender,
+		cmdapi.WithConnectivityCheck(sender, paramAPI, paramOffline, queueFilepath),
+		offline.WithSync(
+			queueFilepath,
+			paramOffline.SyncMax,
+			opts...,
+		),
 		apikey.WithReplacing(apikey.Config{
 			DefaultApiKey: paramAPI.Key,
 			MapPatterns:   paramAPI.KeyPatterns,
 		}),
+		backoff.WithRateLimit(backoff.Config{
+			V:       v,
+			Section: section,
+			Until:   paramAPI.BackoffUntil,
+		}),
 	)
 
-	_, err = handle(nil)
+	_, err = handle(ctx, nil)
 	if err != nil {
 		return err
 	}
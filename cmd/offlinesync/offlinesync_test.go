@@ -1,16 +1,22 @@
 package offlinesync_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/wakatime/wakatime-cli/cmd/offlinesync"
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -99,12 +105,73 @@ func TestSyncOfflineActivity(t *testing.T) {
 	v.Set("sync-offline-activity", 100)
 	v.Set("plugin", plugin)
 
-	err = offlinesync.SyncOfflineActivity(v, f.Name())
+	err = offlinesync.SyncOfflineActivity(context.Background(), v, f.Name())
 	require.NoError(t, err)
 
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestSyncOfflineActivity_Unreachable(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+	})
+
+	// setup offline queue
+	tmpDir := t.TempDir()
+
+	f, err := os.CreateTemp(tmpDir, "")
+	require.NoError(t, err)
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "heartbeats", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(dataGo),
+		},
+	})
+
+	db.Close()
+
+	// api host is known to be unreachable
+	u, err := url.Parse(testServerURL)
+	require.NoError(t, err)
+
+	err = os.WriteFile(
+		filepath.Join(tmpDir, ".wakatime-connectivity.json"),
+		[]byte(fmt.Sprintf(`{%q:{"checked_at":%q,"reachable":false}}`, u.Host, time.Now().Format(time.RFC3339))),
+		0600,
+	)
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("api-url", testServerURL)
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("sync-offline-activity", 100)
+
+	err = offlinesync.SyncOfflineActivity(context.Background(), v, f.Name())
+	require.Error(t, err)
+
+	var errconn api.ErrConnection
+
+	assert.True(t, errors.As(err, &errconn))
+	assert.Zero(t, numCalls)
+
+	count, err := offline.CountHeartbeats(f.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count)
+}
+
 func setupTestServer() (string, *http.ServeMux, func()) {
 	router := http.NewServeMux()
 	srv := httptest.NewServer(router)
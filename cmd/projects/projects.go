@@ -0,0 +1,175 @@
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cmdapi "github.com/wakatime/wakatime-cli/cmd/api"
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/output"
+	"github.com/wakatime/wakatime-cli/pkg/project"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+// Params contains projects command parameters.
+type Params struct {
+	API    params.API
+	Output output.Output
+	Page   int
+	Query  string
+}
+
+// Run executes the projects command.
+func Run(ctx context.Context, v *viper.Viper) (int, error) {
+	out, err := Projects(ctx, v)
+	if err != nil {
+		var errauth api.ErrAuth
+		if errors.As(err, &errauth) {
+			return exitcode.ErrAuth, fmt.Errorf(
+				"projects fetch failed: invalid api key... find yours at wakatime.com/api-key. %s",
+				errauth,
+			)
+		}
+
+		var errbadRequest api.ErrBadRequest
+		if errors.As(err, &errbadRequest) {
+			return exitcode.ErrGeneric, fmt.Errorf(
+				"projects fetch failed: bad request: %s",
+				err,
+			)
+		}
+
+		var errratelimit api.ErrRateLimit
+		if errors.As(err, &errratelimit) {
+			return exitcode.ErrRateLimit, fmt.Errorf(
+				"projects fetch failed: rate limited: %s",
+				err,
+			)
+		}
+
+		if code, reason, ok := cmdapi.ErrExitCode(err); ok {
+			return code, fmt.Errorf(
+				"projects fetch failed: %s: %s",
+				reason,
+				err,
+			)
+		}
+
+		var errapi api.Err
+		if errors.As(err, &errapi) {
+			return exitcode.ErrAPI, fmt.Errorf(
+				"projects fetch failed: api error: %s",
+				err,
+			)
+		}
+
+		return exitcode.ErrGeneric, fmt.Errorf(
+			"projects fetch failed: %s",
+			err,
+		)
+	}
+
+	log.Debugln("successfully fetched projects")
+	fmt.Print(out)
+
+	return exitcode.Success, nil
+}
+
+// Projects returns the rendered page of the projects of the current user.
+func Projects(ctx context.Context, v *viper.Viper) (string, error) {
+	params, err := LoadParams(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to load command parameters: %w", err)
+	}
+
+	apiClient, err := cmdapi.NewClient(params.API)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize api client: %w", err)
+	}
+
+	page, err := apiClient.Projects(ctx, params.Query, params.Page)
+	if err != nil {
+		return "", fmt.Errorf("failed fetching projects from api: %w", err)
+	}
+
+	if params.Output == output.JSONOutput {
+		data, err := json.Marshal(page)
+		if err != nil {
+			return "", fmt.Errorf("failed to json marshal projects: %s", err)
+		}
+
+		return string(data) + "\n", nil
+	}
+
+	return renderTable(page), nil
+}
+
+// LoadParams loads projects config params from viper.Viper instance. Returns ErrAuth
+// if failed to retrieve api key.
+func LoadParams(v *viper.Viper) (Params, error) {
+	paramAPI, err := params.LoadAPIParams(v)
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to load API parameters: %w", err)
+	}
+
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to parse output: %s", err)
+	}
+
+	page := 1
+
+	if v.IsSet("projects-page") {
+		page = v.GetInt("projects-page")
+		if page < 1 {
+			return Params{}, errors.New("argument --projects-page must be a positive integer number")
+		}
+	}
+
+	return Params{
+		API:    paramAPI,
+		Output: out,
+		Page:   page,
+		Query:  vipertools.GetString(v, "projects-query"),
+	}, nil
+}
+
+func renderTable(page *project.RemotePage) string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NAME\tLAST HEARTBEAT\tREPOSITORY")
+
+	for _, p := range page.Projects {
+		lastHeartbeatAt := "-"
+		if p.LastHeartbeatAt != nil {
+			lastHeartbeatAt = p.LastHeartbeatAt.Local().Format(time.RFC3339)
+		}
+
+		repository := p.Repository
+		if repository == "" {
+			repository = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, lastHeartbeatAt, repository)
+	}
+
+	_ = w.Flush()
+
+	if page.TotalPages > 1 {
+		fmt.Fprintf(&b, "\npage %d of %d. use --projects-page to show more.\n", page.Page, page.TotalPages)
+	}
+
+	return b.String()
+}
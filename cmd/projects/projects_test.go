@@ -0,0 +1,98 @@
+package projects_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/cmd/projects"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjects_JSON(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/projects", func(w http.ResponseWriter, req *http.Request) {
+		// check request
+		assert.Equal(t, []string{"Basic MDAwMDAwMDAtMDAwMC00MDAwLTgwMDAtMDAwMDAwMDAwMDAw"}, req.Header["Authorization"])
+		assert.Equal(t, "waka", req.URL.Query().Get("q"))
+
+		// write response
+		data, err := os.ReadFile("testdata/api_projects_response.json")
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+	v.Set("projects-query", "waka")
+	v.Set("output", "json")
+
+	output, err := projects.Projects(context.Background(), v)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"projects": [
+			{
+				"id": "00000000-0000-4000-8000-000000000000",
+				"name": "wakatime-cli",
+				"last_heartbeat_at": "2021-09-16T13:04:05Z",
+				"repository": "https://github.com/wakatime/wakatime-cli"
+			},
+			{
+				"id": "00000000-0000-4000-8000-000000000001",
+				"name": "wakatime",
+				"last_heartbeat_at": null
+			}
+		],
+		"page": 1,
+		"total_pages": 2,
+		"total": 3
+	}`, output)
+}
+
+func TestProjects_Text(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/projects", func(w http.ResponseWriter, req *http.Request) {
+		_, err := w.Write([]byte(`{"data":[{"id":"1","name":"wakatime"}],"page":1,"total":1,"total_pages":1}`))
+		require.NoError(t, err)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+
+	output, err := projects.Projects(context.Background(), v)
+	require.NoError(t, err)
+
+	assert.Equal(t, "NAME      LAST HEARTBEAT  REPOSITORY\nwakatime  -               -\n", output)
+}
+
+func TestLoadParams_InvalidPage(t *testing.T) {
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("projects-page", 0)
+
+	_, err := projects.LoadParams(v)
+	require.Error(t, err)
+
+	assert.Equal(t, "argument --projects-page must be a positive integer number", err.Error())
+}
+
+func setupTestServer() (string, *http.ServeMux, func()) {
+	router := http.NewServeMux()
+	srv := httptest.NewServer(router)
+
+	return srv.URL, router, func() { srv.Close() }
+}
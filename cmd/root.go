@@ -99,6 +99,12 @@ func setFlags(cmd *cobra.Command, v *viper.Viper) {
 		"",
 		"(deprecated) Absolute path to file for the heartbeat."+
 			" Can also be a url, domain or app when --entity-type is not file.")
+	flags.Bool(
+		"goals",
+		false,
+		"Prints your goals with their ids, titles, targets and status, then exits."+
+			" Use --output json for json output.",
+	)
 	flags.String("hide-branch-names", "", "Obfuscate branch names. Will not send revision control branch names to api.")
 	flags.String("hide-file-names", "", "Obfuscate filenames. Will not send file names to api.")
 	flags.String("hide-filenames", "", "(deprecated) Obfuscate filenames. Will not send file names to api.")
@@ -185,6 +191,23 @@ func setFlags(cmd *cobra.Command, v *viper.Viper) {
 		"Override the bundled CA certs file. By default, uses"+
 			" system ca certs.",
 	)
+	flags.String(
+		"ssl-client-cert",
+		"",
+		"Client certificate file presented to the api for mutual tls authentication."+
+			" May contain the private key as well.",
+	)
+	flags.String(
+		"ssl-client-key",
+		"",
+		"Private key file of the client certificate. Encrypted keys are supported.",
+	)
+	flags.String(
+		"ssl-client-key-passphrase-file",
+		"",
+		"File containing the passphrase of an encrypted client key. Defaults to"+
+			" the WAKATIME_SSL_CLIENT_KEY_PASSPHRASE environment variable.",
+	)
 	flags.String(
 		"sync-offline-activity",
 		strconv.Itoa(offline.SyncMaxDefault),
@@ -195,21 +218,118 @@ func setFlags(cmd *cobra.Command, v *viper.Viper) {
 			" to a limit of 1000. Can be used without --entity to only sync offline"+
 			" activity without generating new heartbeats.",
 	)
+	flags.Int(
+		"sync-offline-workers",
+		offline.SyncWorkersDefault,
+		"Number of concurrent requests used to sync offline activity. Defaults to 1.",
+	)
 	flags.Bool("offline-count", false, "Prints the number of heartbeats in the offline db, then exits.")
+	flags.Bool(
+		"offline-dead-letter",
+		false,
+		"Prints heartbeats rejected by the api or which could not be requeued, then exits."+
+			" Use --output json for json output.",
+	)
+	flags.Bool(
+		"offline-dead-letter-purge",
+		false,
+		"Deletes all dead letter heartbeats from the offline db, then exits.",
+	)
+	flags.Bool(
+		"offline-dead-letter-retry",
+		false,
+		"Moves all dead letter heartbeats back into the offline queue to be sent again, then exits.",
+	)
+	flags.Bool(
+		"offline-doctor",
+		false,
+		"Checks the offline db for consistency. A broken db is moved aside and all readable"+
+			" heartbeats are salvaged into a fresh db, then exits. Use --output json for json output.",
+	)
+	flags.String(
+		"offline-export",
+		"",
+		"Writes all heartbeats in the offline db as newline delimited json to this file, then exits."+
+			" Use \"-\" to write to stdout.",
+	)
+	flags.String(
+		"offline-import",
+		"",
+		"Reads newline delimited json heartbeats, as written by --offline-export, from this file"+
+			" and adds them to the offline db, then exits. Use \"-\" to read from stdin.",
+	)
+	flags.Bool(
+		"offline-list",
+		false,
+		"Prints the heartbeats in the offline db, then exits. Use --output json"+
+			" for json output. Can be filtered with the --offline-list-* options.",
+	)
+	flags.String("offline-list-entity", "", "When used with --offline-list, only lists heartbeats"+
+		" whose entity contains this value.")
+	flags.String("offline-list-end", "", "When used with --offline-list, only lists heartbeats"+
+		" at or before this time. Can be a unix epoch timestamp, RFC3339 time or YYYY-MM-DD date,"+
+		" which includes the whole day.")
+	flags.String("offline-list-key", "", "When used with --offline-list, only lists heartbeats"+
+		" which will be sent using this api key.")
+	flags.Int("offline-list-limit", 0, "When used with --offline-list, maximum number of heartbeats"+
+		" to print. Defaults to printing all heartbeats.")
+	flags.Int("offline-list-offset", 0, "When used with --offline-list, number of matching"+
+		" heartbeats to skip before printing.")
+	flags.String("offline-list-project", "", "When used with --offline-list, only lists heartbeats"+
+		" of this project.")
+	flags.String("offline-list-start", "", "When used with --offline-list, only lists heartbeats"+
+		" at or after this time. Can be a unix epoch timestamp, RFC3339 time or YYYY-MM-DD date.")
+	flags.Bool(
+		"offline-stats",
+		false,
+		"Prints statistics about the heartbeats in the offline db by project, day, api key, category"+
+			" and age, then exits. Use --output json for json output.",
+	)
+	flags.String(
+		"output",
+		"",
+		"Format output. Can be \"text\" or \"json\". Defaults to \"text\".",
+	)
+	flags.Bool(
+		"projects",
+		false,
+		"Prints your projects with their last heartbeat time and repository, then exits."+
+			" Use --output json for json output.",
+	)
+	flags.Int("projects-page", 1, "When used with --projects, page of projects to print. Defaults to 1.")
+	flags.String("projects-query", "", "When used with --projects, only prints projects whose name"+
+		" matches this search query.")
+	flags.String(
+		"summaries",
+		"",
+		"Prints coding activity of a date range by project, language, editor, branch and machine,"+
+			" then exits. Can be a start date as YYYY-MM-DD, optionally combined with --summaries-end,"+
+			" or one of today, yesterday, last_7_days, last_30_days, this_week, last_week, this_month"+
+			" or last_month. Use --output json for json output.",
+	)
+	flags.String("summaries-end", "", "When used with --summaries, end date of the date range as"+
+		" YYYY-MM-DD. Defaults to the start date.")
+	flags.Int(
+		"run-timeout",
+		0,
+		"Maximum number of seconds a single run may take in total. Heartbeats, which could not be sent"+
+			" in time, are saved to the offline queue. Negative values disable the limit. Defaults to 300 seconds.",
+	)
 	flags.Int(
 		"timeout",
 		api.DefaultTimeoutSecs,
 		"Number of seconds to wait when sending heartbeats to api. Defaults to 120 seconds.",
 	)
 	flags.Float64("time", 0, "Optional floating-point unix epoch timestamp. Uses current time by default.")
-	flags.Bool("today", false, "Prints dashboard time for Today, then exits.")
+	flags.Bool("today", false, "Prints dashboard time for Today, then exits. Use --output json for json"+
+		" output including seconds, categories with percentages and the top project and language.")
 	flags.Bool("today-hide-categories", false, "When optionally included with --today, causes output to"+
 		" show total code time today without categories.")
 	flags.String(
 		"today-goal",
 		"",
-		"Prints time for the given goal id Today, then exits"+
-			" Visit wakatime.com/api/v1/users/current/goals to find your goal id.")
+		"Prints time for the given goal id Today, including the target, percent complete and whether"+
+			" the goal is met, then exits. Use --goals to find your goal id. Use --output json for json output.")
 	flags.Bool(
 		"useragent",
 		false,
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,12 +13,21 @@ import (
 	cmdapi "github.com/wakatime/wakatime-cli/cmd/api"
 	"github.com/wakatime/wakatime-cli/cmd/configread"
 	"github.com/wakatime/wakatime-cli/cmd/configwrite"
+	"github.com/wakatime/wakatime-cli/cmd/goals"
 	cmdheartbeat "github.com/wakatime/wakatime-cli/cmd/heartbeat"
 	"github.com/wakatime/wakatime-cli/cmd/logfile"
 	cmdoffline "github.com/wakatime/wakatime-cli/cmd/offline"
 	"github.com/wakatime/wakatime-cli/cmd/offlinecount"
+	"github.com/wakatime/wakatime-cli/cmd/offlinedeadletter"
+	"github.com/wakatime/wakatime-cli/cmd/offlinedoctor"
+	"github.com/wakatime/wakatime-cli/cmd/offlineexport"
+	"github.com/wakatime/wakatime-cli/cmd/offlineimport"
+	"github.com/wakatime/wakatime-cli/cmd/offlinelist"
+	"github.com/wakatime/wakatime-cli/cmd/offlinestats"
 	"github.com/wakatime/wakatime-cli/cmd/offlinesync"
 	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/cmd/projects"
+	"github.com/wakatime/wakatime-cli/cmd/summaries"
 	"github.com/wakatime/wakatime-cli/cmd/today"
 	"github.com/wakatime/wakatime-cli/cmd/todaygoal"
 	"github.com/wakatime/wakatime-cli/pkg/api"
@@ -51,7 +61,7 @@ func Run(cmd *cobra.Command, v *viper.Viper) {
 			log.Warnf("failed to load offline queue filepath: %s", err)
 		}
 
-		if err := cmdoffline.SaveHeartbeats(v, nil, queueFilepath); err != nil {
+		if err := cmdoffline.SaveHeartbeats(context.Background(), v, nil, queueFilepath); err != nil {
 			log.Errorf("failed to save heartbeats to offline queue: %s", err)
 		}
 
@@ -60,6 +70,10 @@ func Run(cmd *cobra.Command, v *viper.Viper) {
 
 	logFileParams := SetupLogging(v)
 
+	// bound the total run time, so the cli never hangs on slow remote hosts or subprocesses
+	ctx, cancel := newRunContext(v)
+	defer cancel()
+
 	if v.GetBool("useragent") {
 		log.Debugln("command: useragent")
 
@@ -77,56 +91,133 @@ func Run(cmd *cobra.Command, v *viper.Viper) {
 	if v.GetBool("version") {
 		log.Debugln("command: version")
 
-		RunCmd(v, logFileParams.Verbose, runVersion)
+		RunCmd(ctx, v, logFileParams.Verbose, runVersion)
 	}
 
 	if v.IsSet("config-read") {
 		log.Debugln("command: config-read")
 
-		RunCmd(v, logFileParams.Verbose, configread.Run)
+		RunCmd(ctx, v, logFileParams.Verbose, configread.Run)
 	}
 
 	if v.IsSet("config-write") {
 		log.Debugln("command: config-write")
 
-		RunCmd(v, logFileParams.Verbose, configwrite.Run)
+		RunCmd(ctx, v, logFileParams.Verbose, configwrite.Run)
 	}
 
 	if v.GetBool("today") {
 		log.Debugln("command: today")
 
-		RunCmd(v, logFileParams.Verbose, today.Run)
+		RunCmd(ctx, v, logFileParams.Verbose, today.Run)
 	}
 
 	if v.IsSet("today-goal") {
 		log.Debugln("command: today-goal")
 
-		RunCmd(v, logFileParams.Verbose, todaygoal.Run)
+		RunCmd(ctx, v, logFileParams.Verbose, todaygoal.Run)
+	}
+
+	if v.GetBool("goals") {
+		log.Debugln("command: goals")
+
+		RunCmd(ctx, v, logFileParams.Verbose, goals.Run)
+	}
+
+	if v.GetBool("projects") {
+		log.Debugln("command: projects")
+
+		RunCmd(ctx, v, logFileParams.Verbose, projects.Run)
+	}
+
+	if v.IsSet("summaries") {
+		log.Debugln("command: summaries")
+
+		RunCmd(ctx, v, logFileParams.Verbose, summaries.Run)
 	}
 
 	if v.IsSet("entity") {
 		log.Debugln("command: heartbeat")
 
-		RunCmdWithOfflineSync(v, logFileParams.Verbose, cmdheartbeat.Run)
+		RunCmdWithOfflineSync(ctx, v, logFileParams.Verbose, cmdheartbeat.Run)
 	}
 
 	if v.IsSet("sync-offline-activity") {
 		log.Debugln("command: sync-offline-activity")
 
-		RunCmd(v, logFileParams.Verbose, offlinesync.Run)
+		RunCmd(ctx, v, logFileParams.Verbose, offlinesync.Run)
 	}
 
 	if v.GetBool("offline-count") {
 		log.Debugln("command: offline-count")
 
-		RunCmd(v, logFileParams.Verbose, offlinecount.Run)
+		RunCmd(ctx, v, logFileParams.Verbose, offlinecount.Run)
+	}
+
+	if v.GetBool("offline-dead-letter") {
+		log.Debugln("command: offline-dead-letter")
+
+		RunCmd(ctx, v, logFileParams.Verbose, offlinedeadletter.RunList)
+	}
+
+	if v.GetBool("offline-dead-letter-retry") {
+		log.Debugln("command: offline-dead-letter-retry")
+
+		RunCmd(ctx, v, logFileParams.Verbose, offlinedeadletter.RunRetry)
+	}
+
+	if v.GetBool("offline-dead-letter-purge") {
+		log.Debugln("command: offline-dead-letter-purge")
+
+		RunCmd(ctx, v, logFileParams.Verbose, offlinedeadletter.RunPurge)
+	}
+
+	if v.GetBool("offline-doctor") {
+		log.Debugln("command: offline-doctor")
+
+		RunCmd(ctx, v, logFileParams.Verbose, offlinedoctor.Run)
+	}
+
+	if v.IsSet("offline-export") {
+		log.Debugln("command: offline-export")
+
+		RunCmd(ctx, v, logFileParams.Verbose, offlineexport.Run)
+	}
+
+	if v.IsSet("offline-import") {
+		log.Debugln("command: offline-import")
+
+		RunCmd(ctx, v, logFileParams.Verbose, offlineimport.Run)
+	}
+
+	if v.GetBool("offline-list") {
+		log.Debugln("command: offline-list")
+
+		RunCmd(ctx, v, logFileParams.Verbose, offlinelist.Run)
+	}
+
+	if v.GetBool("offline-stats") {
+		log.Debugln("command: offline-stats")
+
+		RunCmd(ctx, v, logFileParams.Verbose, offlinestats.Run)
 	}
 
 	log.Warnf("one of the following parameters has to be provided: %s", strings.Join([]string{
 		"--config-read",
 		"--config-write",
 		"--entity",
+		"--goals",
 		"--offline-count",
+		"--offline-dead-letter",
+		"--offline-dead-letter-purge",
+		"--offline-dead-letter-retry",
+		"--offline-doctor",
+		"--offline-export",
+		"--offline-import",
+		"--offline-list",
+		"--offline-stats",
+		"--projects",
+		"--summaries",
 		"--sync-offline-activity",
 		"--today",
 		"--today-goal",
@@ -190,13 +281,26 @@ func SetupLogging(v *viper.Viper) *logfile.Params {
 	return &logfileParams
 }
 
+// newRunContext returns a context, which is done once the run timeout passed.
+// A negative run timeout disables the deadline.
+func newRunContext(v *viper.Viper) (context.Context, context.CancelFunc) {
+	timeout := params.LoadRunTimeout(v)
+	if timeout < 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	log.Debugf("run timeout: %s", timeout)
+
+	return context.WithTimeout(context.Background(), timeout)
+}
+
 // cmdFn represents a command function.
-type cmdFn func(v *viper.Viper) (int, error)
+type cmdFn func(ctx context.Context, v *viper.Viper) (int, error)
 
 // RunCmd runs a command function and exits with the exit code returned by
 // the command function. Will send diagnostic on any errors or panics.
-func RunCmd(v *viper.Viper, verbose bool, cmd cmdFn) {
-	exitCode := runCmd(v, verbose, cmd)
+func RunCmd(ctx context.Context, v *viper.Viper, verbose bool, cmd cmdFn) {
+	exitCode := runCmd(ctx, v, verbose, cmd)
 
 	os.Exit(exitCode)
 }
@@ -204,17 +308,17 @@ func RunCmd(v *viper.Viper, verbose bool, cmd cmdFn) {
 // RunCmdWithOfflineSync runs a command function and exits with the exit code
 // returned by the command function. If command run was successful, it will execute
 // offline sync command afterwards. Will send diagnostic on any errors or panics.
-func RunCmdWithOfflineSync(v *viper.Viper, verbose bool, cmd cmdFn) {
-	exitCode := runCmd(v, verbose, cmd)
+func RunCmdWithOfflineSync(ctx context.Context, v *viper.Viper, verbose bool, cmd cmdFn) {
+	exitCode := runCmd(ctx, v, verbose, cmd)
 	if exitCode != exitcode.Success {
 		os.Exit(exitCode)
 	}
 
-	os.Exit(runCmd(v, verbose, offlinesync.Run))
+	os.Exit(runCmd(ctx, v, verbose, offlinesync.Run))
 }
 
 // runCmd contains the main logic of RunCmd.
-func runCmd(v *viper.Viper, verbose bool, cmd cmdFn) int {
+func runCmd(ctx context.Context, v *viper.Viper, verbose bool, cmd cmdFn) int {
 	logs := bytes.NewBuffer(nil)
 	resetLogs := captureLogs(logs)
 
@@ -234,13 +338,13 @@ func runCmd(v *viper.Viper, verbose bool, cmd cmdFn) int {
 	}()
 
 	// run command
-	exitCode, err := cmd(v)
+	exitCode, err := cmd(ctx, v)
 	if err != nil {
 		log.Errorf("failed to run command: %s", err.Error())
 
 		resetLogs()
 
-		if exitCode != exitcode.ErrAuth && verbose {
+		if exitCode != exitcode.ErrAuth && exitCode != exitcode.ErrRateLimit && verbose {
 			if err := sendDiagnostics(v, logs.String(), string(debug.Stack())); err != nil {
 				log.Warnf("failed to send diagnostics: %s", err)
 			}
@@ -274,7 +378,9 @@ func sendDiagnostics(v *viper.Viper, logs, stack string) error {
 
 	api.WithDisableSSLVerify()(c)
 
-	err = c.SendDiagnostics(paramAPI.Plugin, diagnostics...)
+	// diagnostics are sent regardless of the run timeout, as they are most
+	// likely sent after it passed. The request is bounded by the client timeout.
+	err = c.SendDiagnostics(context.Background(), paramAPI.Plugin, diagnostics...)
 	if err != nil {
 		return fmt.Errorf("failed to send diagnostics to the API: %s", err)
 	}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,7 +23,7 @@ import (
 func TestRunCmd(t *testing.T) {
 	v := viper.New()
 
-	ret := runCmd(v, false, func(v *viper.Viper) (int, error) {
+	ret := runCmd(context.Background(), v, false, func(_ context.Context, v *viper.Viper) (int, error) {
 		return exitcode.Success, nil
 	})
 
@@ -32,7 +33,7 @@ func TestRunCmd(t *testing.T) {
 func TestRunCmd_Err(t *testing.T) {
 	v := viper.New()
 
-	ret := runCmd(v, false, func(v *viper.Viper) (int, error) {
+	ret := runCmd(context.Background(), v, false, func(_ context.Context, v *viper.Viper) (int, error) {
 		return exitcode.ErrGeneric, errors.New("fail")
 	})
 
@@ -89,7 +90,7 @@ func TestRunCmd_ErrOfflineEnqueue(t *testing.T) {
 	v.Set("key", "00000000-0000-4000-8000-000000000000")
 	v.Set("plugin", "vim")
 
-	ret := runCmd(v, true, func(v *viper.Viper) (int, error) {
+	ret := runCmd(context.Background(), v, true, func(_ context.Context, v *viper.Viper) (int, error) {
 		return exitcode.ErrGeneric, offline.ErrOfflineEnqueue("fail")
 	})
 
@@ -1,6 +1,7 @@
 package cmd_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -45,7 +46,7 @@ func TestRunCmd_SendDiagnostics_Error(t *testing.T) {
 		v.Set("offline-queue-file", offlineQueueFile.Name())
 		v.Set("plugin", "vim")
 
-		cmd.RunCmd(v, true, func(v *viper.Viper) (int, error) {
+		cmd.RunCmd(context.Background(), v, true, func(_ context.Context, v *viper.Viper) (int, error) {
 			return 42, errors.New("fail")
 		})
 
@@ -128,7 +129,7 @@ func TestRunCmd_SendDiagnostics_Panic(t *testing.T) {
 		v.Set("offline-queue-file", offlineQueueFile.Name())
 		v.Set("plugin", "vim")
 
-		cmd.RunCmd(v, true, func(v *viper.Viper) (int, error) {
+		cmd.RunCmd(context.Background(), v, true, func(_ context.Context, v *viper.Viper) (int, error) {
 			panic("fail")
 		})
 
@@ -208,7 +209,7 @@ func TestRunCmdWithOfflineSync(t *testing.T) {
 		v.SetDefault("sync-offline-activity", 24)
 		v.Set("plugin", "vim")
 
-		cmd.RunCmdWithOfflineSync(v, false, func(v *viper.Viper) (int, error) {
+		cmd.RunCmdWithOfflineSync(context.Background(), v, false, func(_ context.Context, v *viper.Viper) (int, error) {
 			return 0, nil
 		})
 
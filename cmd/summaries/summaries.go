@@ -0,0 +1,176 @@
+package summaries
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cmdapi "github.com/wakatime/wakatime-cli/cmd/api"
+	"github.com/wakatime/wakatime-cli/cmd/params"
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/output"
+	"github.com/wakatime/wakatime-cli/pkg/summary"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
+
+	"github.com/spf13/viper"
+)
+
+// Params contains summaries command parameters.
+type Params struct {
+	API    params.API
+	End    time.Time
+	Output output.Output
+	Start  time.Time
+}
+
+// Run executes the summaries command.
+func Run(ctx context.Context, v *viper.Viper) (int, error) {
+	out, err := Summaries(ctx, v)
+	if err != nil {
+		var errauth api.ErrAuth
+		if errors.As(err, &errauth) {
+			return exitcode.ErrAuth, fmt.Errorf(
+				"summaries fetch failed: invalid api key... find yours at wakatime.com/api-key. %s",
+				errauth,
+			)
+		}
+
+		var errbadRequest api.ErrBadRequest
+		if errors.As(err, &errbadRequest) {
+			return exitcode.ErrGeneric, fmt.Errorf(
+				"summaries fetch failed: bad request: %s",
+				err,
+			)
+		}
+
+		var errratelimit api.ErrRateLimit
+		if errors.As(err, &errratelimit) {
+			return exitcode.ErrRateLimit, fmt.Errorf(
+				"summaries fetch failed: rate limited: %s",
+				err,
+			)
+		}
+
+		if code, reason, ok := cmdapi.ErrExitCode(err); ok {
+			return code, fmt.Errorf(
+				"summaries fetch failed: %s: %s",
+				reason,
+				err,
+			)
+		}
+
+		var errapi api.Err
+		if errors.As(err, &errapi) {
+			return exitcode.ErrAPI, fmt.Errorf(
+				"summaries fetch failed: api error: %s",
+				err,
+			)
+		}
+
+		return exitcode.ErrGeneric, fmt.Errorf(
+			"summaries fetch failed: %s",
+			err,
+		)
+	}
+
+	log.Debugln("successfully fetched summaries")
+	fmt.Print(out)
+
+	return exitcode.Success, nil
+}
+
+// Summaries returns the rendered coding activity of the requested date range.
+func Summaries(ctx context.Context, v *viper.Viper) (string, error) {
+	params, err := LoadParams(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to load command parameters: %w", err)
+	}
+
+	apiClient, err := cmdapi.NewClient(params.API)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize api client: %w", err)
+	}
+
+	r, err := apiClient.Summaries(ctx, params.Start, params.End)
+	if err != nil {
+		return "", fmt.Errorf("failed fetching summaries from api: %w", err)
+	}
+
+	if params.Output == output.JSONOutput {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to json marshal summaries: %s", err)
+		}
+
+		return string(data) + "\n", nil
+	}
+
+	return renderText(r), nil
+}
+
+// LoadParams loads summaries config params from viper.Viper instance. Returns ErrAuth
+// if failed to retrieve api key.
+func LoadParams(v *viper.Viper) (Params, error) {
+	paramAPI, err := params.LoadAPIParams(v)
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to load API parameters: %w", err)
+	}
+
+	start, end, err := summary.ParseRange(
+		vipertools.GetString(v, "summaries"),
+		vipertools.GetString(v, "summaries-end"),
+		time.Now(),
+	)
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to parse date range: %s", err)
+	}
+
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to parse output: %s", err)
+	}
+
+	return Params{
+		API:    paramAPI,
+		End:    end,
+		Output: out,
+		Start:  start,
+	}, nil
+}
+
+func renderText(r *summary.Range) string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "range:\t%s - %s\n", r.Start, r.End)
+	fmt.Fprintf(w, "total:\t%s\n", r.Text)
+
+	renderItems(w, "PROJECT", r.Projects)
+	renderItems(w, "LANGUAGE", r.Languages)
+	renderItems(w, "EDITOR", r.Editors)
+	renderItems(w, "BRANCH", r.Branches)
+	renderItems(w, "MACHINE", r.Machines)
+
+	_ = w.Flush()
+
+	return b.String()
+}
+
+func renderItems(w *tabwriter.Writer, title string, items []summary.Item) {
+	if len(items) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\tSECONDS\tTIME\n", title)
+
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%.0f\t%s\n", item.Name, item.Seconds, item.Text)
+	}
+}
@@ -0,0 +1,143 @@
+package summaries_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/cmd/summaries"
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/summary"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaries(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/summaries", func(w http.ResponseWriter, req *http.Request) {
+		// check request
+		assert.Equal(t, "2021-09-01", req.URL.Query().Get("start"))
+		assert.Equal(t, "2021-09-02", req.URL.Query().Get("end"))
+		assert.Equal(t, []string{"Basic MDAwMDAwMDAtMDAwMC00MDAwLTgwMDAtMDAwMDAwMDAwMDAw"}, req.Header["Authorization"])
+
+		// write response
+		data, err := os.ReadFile("testdata/api_summaries_response.json")
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+	v.Set("summaries", "2021-09-01")
+	v.Set("summaries-end", "2021-09-02")
+
+	output, err := summaries.Summaries(context.Background(), v)
+	require.NoError(t, err)
+
+	assert.Equal(t, "range:  2021-09-01 - 2021-09-02\n"+
+		"total:  2 hrs 15 mins\n"+
+		"\n"+
+		"PROJECT       SECONDS  TIME\n"+
+		"wakatime-cli  6300     1 hr 45 mins\n"+
+		"wakatime      1800     30 mins\n"+
+		"\n"+
+		"LANGUAGE  SECONDS  TIME\n"+
+		"Go        6300     1 hr 45 mins\n"+
+		"Markdown  1800     30 mins\n"+
+		"\n"+
+		"EDITOR   SECONDS  TIME\n"+
+		"VS Code  6300     1 hr 45 mins\n"+
+		"Vim      1800     30 mins\n"+
+		"\n"+
+		"MACHINE  SECONDS  TIME\n"+
+		"laptop   5400     1 hr 30 mins\n"+
+		"desktop  2700     45 mins\n",
+		output,
+	)
+}
+
+func TestSummaries_JSON(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/summaries", func(w http.ResponseWriter, req *http.Request) {
+		data, err := os.ReadFile("testdata/api_summaries_response.json")
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+	v.Set("summaries", "2021-09-01")
+	v.Set("summaries-end", "2021-09-02")
+	v.Set("output", "json")
+
+	output, err := summaries.Summaries(context.Background(), v)
+	require.NoError(t, err)
+
+	var r summary.Range
+
+	err = json.Unmarshal([]byte(output), &r)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2021-09-01", r.Start)
+	assert.Equal(t, "2021-09-02", r.End)
+	assert.Equal(t, 8100.5, r.Seconds)
+	assert.Equal(t, "2 hrs 15 mins", r.Text)
+	assert.Len(t, r.Projects, 2)
+	assert.Empty(t, r.Branches)
+}
+
+func TestSummaries_ErrAuth(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/summaries", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+	v.Set("summaries", "today")
+
+	_, err := summaries.Summaries(context.Background(), v)
+	require.Error(t, err)
+
+	var errauth api.ErrAuth
+
+	assert.True(t, errors.As(err, &errauth))
+}
+
+func TestLoadParams_InvalidRange(t *testing.T) {
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("summaries", "today")
+	v.Set("summaries-end", "2021-09-02")
+
+	_, err := summaries.LoadParams(v)
+	require.Error(t, err)
+
+	assert.Equal(t, `failed to parse date range: end date cannot be combined with preset "today"`, err.Error())
+}
+
+func setupTestServer() (string, *http.ServeMux, func()) {
+	router := http.NewServeMux()
+	srv := httptest.NewServer(router)
+
+	return srv.URL, router, func() { srv.Close() }
+}
@@ -1,6 +1,8 @@
 package today
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -9,14 +11,16 @@ import (
 	"github.com/wakatime/wakatime-cli/pkg/api"
 	"github.com/wakatime/wakatime-cli/pkg/exitcode"
 	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/output"
 	"github.com/wakatime/wakatime-cli/pkg/summary"
+	"github.com/wakatime/wakatime-cli/pkg/vipertools"
 
 	"github.com/spf13/viper"
 )
 
 // Run executes the today command.
-func Run(v *viper.Viper) (int, error) {
-	output, err := Today(v)
+func Run(ctx context.Context, v *viper.Viper) (int, error) {
+	out, err := Today(ctx, v)
 	if err != nil {
 		var errauth api.ErrAuth
 		if errors.As(err, &errauth) {
@@ -26,6 +30,22 @@ func Run(v *viper.Viper) (int, error) {
 			)
 		}
 
+		var errratelimit api.ErrRateLimit
+		if errors.As(err, &errratelimit) {
+			return exitcode.ErrRateLimit, fmt.Errorf(
+				"today fetch failed: rate limited: %s",
+				err,
+			)
+		}
+
+		if code, reason, ok := cmdapi.ErrExitCode(err); ok {
+			return code, fmt.Errorf(
+				"today fetch failed: %s: %s",
+				reason,
+				err,
+			)
+		}
+
 		var errapi api.Err
 		if errors.As(err, &errapi) {
 			return exitcode.ErrAPI, fmt.Errorf(
@@ -49,13 +69,13 @@ func Run(v *viper.Viper) (int, error) {
 	}
 
 	log.Debugln("successfully fetched today for status bar")
-	fmt.Println(output)
+	fmt.Println(out)
 
 	return exitcode.Success, nil
 }
 
 // Today returns a rendered summary of todays coding activity.
-func Today(v *viper.Viper) (string, error) {
+func Today(ctx context.Context, v *viper.Viper) (string, error) {
 	paramAPI, err := params.LoadAPIParams(v)
 	if err != nil {
 		return "", fmt.Errorf("failed to load API parameters: %w", err)
@@ -63,20 +83,38 @@ func Today(v *viper.Viper) (string, error) {
 
 	statusBarParam := params.LoadStausBarParams(v)
 
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse output: %s", err)
+	}
+
 	apiClient, err := cmdapi.NewClient(paramAPI)
 	if err != nil {
 		return "", fmt.Errorf("failed to initialize api client: %w", err)
 	}
 
-	s, err := apiClient.Today()
+	s, err := apiClient.Today(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed fetching today from api: %w", err)
 	}
 
-	output, err := summary.RenderToday(s, statusBarParam.HideCategories)
+	if out == output.JSONOutput {
+		if s.ByCategory == nil {
+			s.ByCategory = []summary.Category{}
+		}
+
+		data, err := json.Marshal(s)
+		if err != nil {
+			return "", fmt.Errorf("failed to json marshal today: %s", err)
+		}
+
+		return string(data), nil
+	}
+
+	rendered, err := summary.RenderToday(s, statusBarParam.HideCategories)
 	if err != nil {
 		return "", fmt.Errorf("failed generating today output: %s", err)
 	}
 
-	return output, nil
+	return rendered, nil
 }
@@ -1,6 +1,7 @@
 package today_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -54,13 +55,68 @@ func TestToday(t *testing.T) {
 	v.Set("api-url", testServerURL)
 	v.Set("plugin", plugin)
 
-	output, err := today.Today(v)
+	output, err := today.Today(context.Background(), v)
 	require.NoError(t, err)
 
 	assert.Equal(t, "10 secs", output)
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestToday_JSON(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/statusbar/today", func(w http.ResponseWriter, req *http.Request) {
+		data, err := os.ReadFile("testdata/api_statusbar_today_full_response.json")
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+	v.Set("output", "json")
+
+	output, err := today.Today(context.Background(), v)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"total": "1 hr 59 mins",
+		"seconds": 7152,
+		"categories": [
+			{"category": "Coding", "percent": 75.5, "seconds": 5400, "total": "1 hr 30 mins"},
+			{"category": "Debugging", "percent": 24.5, "seconds": 1752, "total": "29 mins"}
+		],
+		"language": "Go",
+		"project": "wakatime-cli"
+	}`, output)
+}
+
+func TestToday_JSON_NoCategories(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/statusbar/today", func(w http.ResponseWriter, req *http.Request) {
+		data, err := os.ReadFile("testdata/api_statusbar_today_response_template.json")
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	})
+
+	v := viper.New()
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+	v.Set("output", "json")
+
+	output, err := today.Today(context.Background(), v)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"total": "10 secs", "seconds": 0, "categories": []}`, output)
+}
+
 func TestToday_ErrApi(t *testing.T) {
 	testServerURL, router, tearDown := setupTestServer()
 	defer tearDown()
@@ -77,7 +133,7 @@ func TestToday_ErrApi(t *testing.T) {
 	v.Set("key", "00000000-0000-4000-8000-000000000000")
 	v.Set("api-url", testServerURL)
 
-	_, err := today.Today(v)
+	_, err := today.Today(context.Background(), v)
 	require.Error(t, err)
 
 	var errapi api.Err
@@ -109,7 +165,7 @@ func TestToday_ErrAuth(t *testing.T) {
 	v.Set("key", "00000000-0000-4000-8000-000000000000")
 	v.Set("api-url", testServerURL)
 
-	_, err := today.Today(v)
+	_, err := today.Today(context.Background(), v)
 	require.Error(t, err)
 
 	var errauth api.ErrAuth
@@ -141,7 +197,7 @@ func TestToday_ErrBadRequest(t *testing.T) {
 	v.Set("key", "00000000-0000-4000-8000-000000000000")
 	v.Set("api-url", testServerURL)
 
-	_, err := today.Today(v)
+	_, err := today.Today(context.Background(), v)
 	require.Error(t, err)
 
 	var errbadRequest api.ErrBadRequest
@@ -157,9 +213,42 @@ func TestToday_ErrBadRequest(t *testing.T) {
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestToday_ErrRateLimit(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/statusbar/today", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+		w.Header().Set("Retry-After", "90")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	v := viper.New()
+	v.SetDefault("sync-offline-activity", 1000)
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+
+	_, err := today.Today(context.Background(), v)
+	require.Error(t, err)
+
+	var errratelimit api.ErrRateLimit
+
+	assert.True(t, errors.As(err, &errratelimit))
+
+	expectedMsg := fmt.Sprintf(
+		`failed fetching today from api: `+
+			`rate limited at "%s/users/current/statusbar/today". got: 429. retry after 1m30s`,
+		testServerURL,
+	)
+	assert.Equal(t, expectedMsg, err.Error())
+	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
+}
+
 func TestToday_ErrAuth_UnsetAPIKey(t *testing.T) {
 	v := viper.New()
-	_, err := today.Today(v)
+	_, err := today.Today(context.Background(), v)
 	require.Error(t, err)
 
 	var errauth api.ErrAuth
@@ -1,6 +1,8 @@
 package todaygoal
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
@@ -9,7 +11,9 @@ import (
 	"github.com/wakatime/wakatime-cli/cmd/params"
 	"github.com/wakatime/wakatime-cli/pkg/api"
 	"github.com/wakatime/wakatime-cli/pkg/exitcode"
+	"github.com/wakatime/wakatime-cli/pkg/goal"
 	"github.com/wakatime/wakatime-cli/pkg/log"
+	"github.com/wakatime/wakatime-cli/pkg/output"
 	"github.com/wakatime/wakatime-cli/pkg/vipertools"
 
 	"github.com/spf13/viper"
@@ -20,12 +24,13 @@ var uuid4Regex = regexp.MustCompile("^[a-f0-9]{8}-[a-f0-9]{4}-4[a-f0-9]{3}-[89ab
 // Params contains today-goal command parameters.
 type Params struct {
 	GoalID string
+	Output output.Output
 	API    params.API
 }
 
 // Run executes the today-goal command.
-func Run(v *viper.Viper) (int, error) {
-	output, err := Goal(v)
+func Run(ctx context.Context, v *viper.Viper) (int, error) {
+	out, err := Goal(ctx, v)
 	if err != nil {
 		var errauth api.ErrAuth
 		if errors.As(err, &errauth) {
@@ -43,6 +48,22 @@ func Run(v *viper.Viper) (int, error) {
 			)
 		}
 
+		var errratelimit api.ErrRateLimit
+		if errors.As(err, &errratelimit) {
+			return exitcode.ErrRateLimit, fmt.Errorf(
+				"today goal fetch failed: rate limited: %s",
+				err,
+			)
+		}
+
+		if code, reason, ok := cmdapi.ErrExitCode(err); ok {
+			return code, fmt.Errorf(
+				"today goal fetch failed: %s: %s",
+				reason,
+				err,
+			)
+		}
+
 		var errapi api.Err
 		if errors.As(err, &errapi) {
 			return exitcode.ErrAPI, fmt.Errorf(
@@ -58,13 +79,13 @@ func Run(v *viper.Viper) (int, error) {
 	}
 
 	log.Debugln("successfully fetched today goal")
-	fmt.Println(output)
+	fmt.Println(out)
 
 	return exitcode.Success, nil
 }
 
 // Goal returns total time of given goal id for todays coding activity.
-func Goal(v *viper.Viper) (string, error) {
+func Goal(ctx context.Context, v *viper.Viper) (string, error) {
 	params, err := LoadParams(v)
 	if err != nil {
 		return "", fmt.Errorf("failed to load command parameters: %w", err)
@@ -75,12 +96,21 @@ func Goal(v *viper.Viper) (string, error) {
 		return "", fmt.Errorf("failed to initialize api client: %w", err)
 	}
 
-	goal, err := apiClient.Goal(params.GoalID)
+	g, err := apiClient.Goal(ctx, params.GoalID)
 	if err != nil {
 		return "", fmt.Errorf("failed fetching todays goal from api: %w", err)
 	}
 
-	return goal.Total, nil
+	if params.Output == output.JSONOutput {
+		data, err := json.Marshal(g)
+		if err != nil {
+			return "", fmt.Errorf("failed to json marshal goal: %s", err)
+		}
+
+		return string(data), nil
+	}
+
+	return goal.Render(g), nil
 }
 
 // LoadParams loads todaygoal config params from viper.Viper instance. Returns ErrAuth
@@ -100,8 +130,14 @@ func LoadParams(v *viper.Viper) (Params, error) {
 		return Params{}, fmt.Errorf("goal id invalid")
 	}
 
+	out, err := output.Parse(vipertools.GetString(v, "output"))
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to parse output: %s", err)
+	}
+
 	return Params{
 		GoalID: goalID,
+		Output: out,
 		API:    paramAPI,
 	}, nil
 }
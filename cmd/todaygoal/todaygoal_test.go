@@ -1,6 +1,7 @@
 package todaygoal_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -56,13 +57,58 @@ func TestGoal(t *testing.T) {
 	v.Set("plugin", plugin)
 	v.Set("today-goal", "00000000-0000-4000-8000-000000000000")
 
-	output, err := todaygoal.Goal(v)
+	output, err := todaygoal.Goal(context.Background(), v)
 	require.NoError(t, err)
 
 	assert.Equal(t, "3 hrs 23 mins", output)
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestGoal_Progress(t *testing.T) {
+	tests := map[string]struct {
+		Output   string
+		Expected string
+	}{
+		"text": {
+			Expected: "3 hrs 23 mins of 4 hrs (84.6%)",
+		},
+		"json": {
+			Output: "json",
+			Expected: `{"id":"00000000-0000-4000-8000-000000000000","title":"Code 4 hrs per day","status":"pending",` +
+				`"seconds":12180,"total":"3 hrs 23 mins","target_seconds":14400,"target":"4 hrs","percent":84.6,` +
+				`"is_inverse":false,"is_met":false}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			testServerURL, router, tearDown := setupTestServer()
+			defer tearDown()
+
+			router.HandleFunc(
+				"/users/current/goals/00000000-0000-4000-8000-000000000000",
+				func(w http.ResponseWriter, req *http.Request) {
+					data, err := os.ReadFile("testdata/api_goals_id_full_response.json")
+					require.NoError(t, err)
+
+					_, err = w.Write(data)
+					require.NoError(t, err)
+				})
+
+			v := viper.New()
+			v.Set("key", "00000000-0000-4000-8000-000000000000")
+			v.Set("api-url", testServerURL)
+			v.Set("today-goal", "00000000-0000-4000-8000-000000000000")
+			v.Set("output", test.Output)
+
+			output, err := todaygoal.Goal(context.Background(), v)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, output)
+		})
+	}
+}
+
 func TestGoal_ErrApi(t *testing.T) {
 	testServerURL, router, tearDown := setupTestServer()
 	defer tearDown()
@@ -81,7 +127,7 @@ func TestGoal_ErrApi(t *testing.T) {
 	v.Set("api-url", testServerURL)
 	v.Set("today-goal", "00000000-0000-4000-8000-000000000000")
 
-	_, err := todaygoal.Goal(v)
+	_, err := todaygoal.Goal(context.Background(), v)
 	require.Error(t, err)
 
 	var errapi api.Err
@@ -116,7 +162,7 @@ func TestGoal_ErrAuth(t *testing.T) {
 	v.Set("api-url", testServerURL)
 	v.Set("today-goal", "00000000-0000-4000-8000-000000000000")
 
-	_, err := todaygoal.Goal(v)
+	_, err := todaygoal.Goal(context.Background(), v)
 	require.Error(t, err)
 
 	var errauth api.ErrAuth
@@ -132,6 +178,41 @@ func TestGoal_ErrAuth(t *testing.T) {
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestGoal_ErrRateLimit(t *testing.T) {
+	testServerURL, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc(
+		"/users/current/goals/00000000-0000-4000-8000-000000000000", func(w http.ResponseWriter, req *http.Request) {
+			numCalls++
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+	v := viper.New()
+	v.SetDefault("sync-offline-activity", 1000)
+	v.Set("key", "00000000-0000-4000-8000-000000000000")
+	v.Set("api-url", testServerURL)
+	v.Set("today-goal", "00000000-0000-4000-8000-000000000000")
+
+	_, err := todaygoal.Goal(context.Background(), v)
+	require.Error(t, err)
+
+	var errratelimit api.ErrRateLimit
+
+	assert.True(t, errors.As(err, &errratelimit))
+
+	expectedMsg := fmt.Sprintf(
+		`failed fetching todays goal from api: `+
+			`rate limited at "%s/users/current/goals/00000000-0000-4000-8000-000000000000". got: 503. retry after 30s`,
+		testServerURL,
+	)
+	assert.Equal(t, expectedMsg, err.Error())
+	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
+}
+
 func TestGoal_ErrBadRequest(t *testing.T) {
 	testServerURL, router, tearDown := setupTestServer()
 	defer tearDown()
@@ -150,7 +231,7 @@ func TestGoal_ErrBadRequest(t *testing.T) {
 	v.Set("api-url", testServerURL)
 	v.Set("today-goal", "00000000-0000-4000-8000-000000000000")
 
-	_, err := todaygoal.Goal(v)
+	_, err := todaygoal.Goal(context.Background(), v)
 	require.Error(t, err)
 
 	var errbadRequest api.ErrBadRequest
@@ -168,7 +249,7 @@ func TestGoal_ErrBadRequest(t *testing.T) {
 
 func TestGoal_ErrAuth_UnsetAPIKey(t *testing.T) {
 	v := viper.New()
-	_, err := todaygoal.Goal(v)
+	_, err := todaygoal.Goal(context.Background(), v)
 	require.Error(t, err)
 
 	var errauth api.ErrAuth
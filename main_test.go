@@ -264,7 +264,7 @@ func TestSendHeartbeats_Err(t *testing.T) {
 
 	out := runWakatimeCliExpectErr(
 		t,
-		exitcode.ErrAPI,
+		exitcode.ErrServer,
 		"--api-url", apiURL,
 		"--key", "00000000-0000-4000-8000-000000000000",
 		"--config", tmpFile.Name(),
@@ -470,7 +470,7 @@ func TestOfflineCount(t *testing.T) {
 
 	out := runWakatimeCliExpectErr(
 		t,
-		exitcode.ErrAPI,
+		exitcode.ErrServer,
 		"--api-url", apiURL,
 		"--key", "00000000-0000-4000-8000-000000000000",
 		"--config", tmpFile.Name(),
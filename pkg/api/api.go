@@ -28,6 +28,10 @@ type Client struct {
 	//		return resp, err
 	//	}
 	doFunc func(c *Client, req *http.Request) (*http.Response, error)
+	// gzip holds the state of compressing heartbeat uploads. Nil, if disabled.
+	gzip *gzipState
+	// proxy holds the explicitly configured proxy and no proxy settings.
+	proxy proxyConfig
 }
 
 // NewClient creates a new Client. Any number of Options can be provided.
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"  // nolint:gosec
+	"crypto/sha1" // nolint:gosec
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// nolint:gochecknoglobals
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+type (
+	// encryptedPrivateKeyInfo is the PKCS #8 EncryptedPrivateKeyInfo structure.
+	encryptedPrivateKeyInfo struct {
+		Algorithm     pkix.AlgorithmIdentifier
+		EncryptedData []byte
+	}
+
+	// pbes2Params are the parameters of the PBES2 encryption scheme of RFC 8018.
+	pbes2Params struct {
+		KeyDerivationFunc pkix.AlgorithmIdentifier
+		EncryptionScheme  pkix.AlgorithmIdentifier
+	}
+
+	// pbkdf2Params are the parameters of the PBKDF2 key derivation function of RFC 8018.
+	pbkdf2Params struct {
+		Salt           []byte
+		IterationCount int
+		KeyLength      int                      `asn1:"optional"`
+		PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+	}
+)
+
+// LoadClientCert loads the client certificate for mutual tls authentication
+// from certFile and keyFile. If keyFile is empty, the private key is read from
+// certFile. Encrypted private keys, either in legacy PEM or in PKCS #8 format,
+// are decrypted with the passed in passphrase.
+func LoadClientCert(certFile, keyFile string, passphrase []byte) (tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read client cert file: %s", err)
+	}
+
+	keyPEM := certPEM
+
+	if keyFile != "" && keyFile != certFile {
+		keyPEM, err = os.ReadFile(keyFile)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to read client key file: %s", err)
+		}
+	}
+
+	keyPEM, err = decryptKeyPEM(keyPEM, passphrase)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load client cert: %s", err)
+	}
+
+	return cert, nil
+}
+
+// decryptKeyPEM returns the first private key found in data as unencrypted PEM.
+func decryptKeyPEM(data, passphrase []byte) ([]byte, error) {
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("failed to find private key in client key file")
+		}
+
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+
+		// nolint:staticcheck
+		encrypted := block.Type == "ENCRYPTED PRIVATE KEY" || x509.IsEncryptedPEMBlock(block)
+		if !encrypted {
+			return pem.EncodeToMemory(block), nil
+		}
+
+		if len(passphrase) == 0 {
+			return nil, errors.New("client key is encrypted, but no passphrase was configured")
+		}
+
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			der, err := decryptPKCS8(block.Bytes, passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt client key: %s", err)
+			}
+
+			return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+		}
+
+		// nolint:staticcheck
+		der, err := x509.DecryptPEMBlock(block, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt client key: %s", err)
+		}
+
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	}
+}
+
+// decryptPKCS8 decrypts a PKCS #8 private key encrypted via PBES2, which is
+// the default of current openssl versions.
+func decryptPKCS8(data, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %s", err)
+	}
+
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption algorithm %s. only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse PBES2 parameters: %s", err)
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf(
+			"unsupported key derivation function %s. only PBKDF2 is supported",
+			params.KeyDerivationFunc.Algorithm,
+		)
+	}
+
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, fmt.Errorf("failed to parse PBKDF2 parameters: %s", err)
+	}
+
+	var prf func() hash.Hash
+
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 pseudorandom function %s", kdfParams.PRF.Algorithm)
+	}
+
+	var (
+		keyLen    int
+		newCipher func(key []byte) (cipher.Block, error)
+	)
+
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		keyLen, newCipher = 16, aes.NewCipher
+	case scheme.Equal(oidAES192CBC):
+		keyLen, newCipher = 24, aes.NewCipher
+	case scheme.Equal(oidAES256CBC):
+		keyLen, newCipher = 32, aes.NewCipher
+	case scheme.Equal(oidDESEDE3CBC):
+		keyLen, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, fmt.Errorf("unsupported encryption scheme %s", scheme)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("failed to parse initialization vector: %s", err)
+	}
+
+	block, err := newCipher(pbkdf2.Key(passphrase, kdfParams.Salt, kdfParams.IterationCount, keyLen, prf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cipher: %s", err)
+	}
+
+	if len(iv) != block.BlockSize() || len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid encrypted private key")
+	}
+
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// remove PKCS #7 padding, which is invalid most likely due to a wrong passphrase
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > block.BlockSize() ||
+		!bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, x509.IncorrectPasswordError
+	}
+
+	der := decrypted[:len(decrypted)-padding]
+
+	if _, err := x509.ParsePKCS8PrivateKey(der); err != nil {
+		return nil, x509.IncorrectPasswordError
+	}
+
+	return der, nil
+}
@@ -0,0 +1,251 @@
+package api_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadClientCert(t *testing.T) {
+	expected, err := tls.LoadX509KeyPair("testdata/client.crt", "testdata/client.key")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		KeyFile    string
+		Passphrase string
+	}{
+		"unencrypted key": {
+			KeyFile: "testdata/client.key",
+		},
+		"legacy pem encrypted key": {
+			KeyFile:    "testdata/client_encrypted.key",
+			Passphrase: "secret",
+		},
+		"pkcs8 encrypted key": {
+			KeyFile:    "testdata/client_pkcs8_encrypted.key",
+			Passphrase: "secret",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cert, err := api.LoadClientCert("testdata/client.crt", test.KeyFile, []byte(test.Passphrase))
+			require.NoError(t, err)
+
+			assert.Equal(t, expected.Certificate, cert.Certificate)
+			assert.Equal(t, expected.PrivateKey, cert.PrivateKey)
+		})
+	}
+}
+
+func TestLoadClientCert_CombinedFile(t *testing.T) {
+	certPEM, err := os.ReadFile("testdata/client.crt")
+	require.NoError(t, err)
+
+	keyPEM, err := os.ReadFile("testdata/client_pkcs8_encrypted.key")
+	require.NoError(t, err)
+
+	combined := filepath.Join(t.TempDir(), "client.pem")
+
+	err = os.WriteFile(combined, append(certPEM, keyPEM...), 0600)
+	require.NoError(t, err)
+
+	cert, err := api.LoadClientCert(combined, "", []byte("secret"))
+	require.NoError(t, err)
+
+	assert.Len(t, cert.Certificate, 1)
+}
+
+func TestLoadClientCert_Err(t *testing.T) {
+	tests := map[string]struct {
+		KeyFile    string
+		Passphrase string
+		Expected   string
+	}{
+		"missing passphrase": {
+			KeyFile:  "testdata/client_pkcs8_encrypted.key",
+			Expected: "client key is encrypted, but no passphrase was configured",
+		},
+		"legacy pem wrong passphrase": {
+			KeyFile:    "testdata/client_encrypted.key",
+			Passphrase: "wrong",
+			Expected:   "failed to decrypt client key: x509: decryption password incorrect",
+		},
+		"pkcs8 wrong passphrase": {
+			KeyFile:    "testdata/client_pkcs8_encrypted.key",
+			Passphrase: "wrong",
+			Expected:   "failed to decrypt client key: x509: decryption password incorrect",
+		},
+		"no private key": {
+			KeyFile:  "testdata/client.crt",
+			Expected: "failed to find private key in client key file",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := api.LoadClientCert("testdata/client.crt", test.KeyFile, []byte(test.Passphrase))
+			require.Error(t, err)
+
+			assert.Equal(t, test.Expected, err.Error())
+		})
+	}
+}
+
+func TestOption_WithClientCertFile(t *testing.T) {
+	withNTLM, err := api.WithNTLM(`domain\\john:secret`)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		Opts  func(t *testing.T, proxyURL string) []api.Option
+		Proxy bool
+	}{
+		"client cert only": {
+			Opts: func(t *testing.T, _ string) []api.Option {
+				return nil
+			},
+		},
+		"ntlm before client cert": {
+			Opts: func(t *testing.T, _ string) []api.Option {
+				return []api.Option{withNTLM}
+			},
+		},
+		"proxy before client cert": {
+			Opts: func(t *testing.T, proxyURL string) []api.Option {
+				withProxy, err := api.WithProxy(proxyURL)
+				require.NoError(t, err)
+
+				return []api.Option{withProxy}
+			},
+			Proxy: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := setupTestMTLSServer(t)
+			defer srv.Close()
+
+			var proxyURL string
+
+			proxied := make(chan string, 1)
+
+			if test.Proxy {
+				proxy := setupTestConnectProxy(t, proxied)
+				defer proxy.Close()
+
+				proxyURL = proxy.URL
+			}
+
+			withClientCert, err := api.WithClientCertFile(
+				"testdata/client.crt",
+				"testdata/client_encrypted.key",
+				[]byte("secret"),
+			)
+			require.NoError(t, err)
+
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(srv.Certificate())
+
+			withSSLCertPool, err := api.WithSSLCertPool(rootCAs)
+			require.NoError(t, err)
+
+			opts := append(test.Opts(t, proxyURL), withClientCert, withSSLCertPool)
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			require.NoError(t, err)
+
+			c := api.NewClient(srv.URL, opts...)
+
+			resp, err := c.Do(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "wakatime-cli test client", string(body))
+
+			if test.Proxy {
+				assert.Equal(t, srv.Listener.Addr().String(), <-proxied)
+			}
+		})
+	}
+}
+
+// setupTestMTLSServer starts a tls server, which requires the test client cert
+// and responds with the common name of the presented client cert.
+func setupTestMTLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	certPEM, err := os.ReadFile("testdata/client.crt")
+	require.NoError(t, err)
+
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM(certPEM))
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(req.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	srv.StartTLS()
+
+	return srv
+}
+
+// setupTestConnectProxy starts a http proxy, which tunnels CONNECT requests
+// and sends the requested host to proxied.
+func setupTestConnectProxy(t *testing.T, proxied chan<- string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		proxied <- req.Host
+
+		upstream, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+
+		go func() {
+			defer upstream.Close()
+			defer conn.Close()
+
+			_, _ = io.Copy(upstream, conn)
+		}()
+
+		_, _ = io.Copy(conn, upstream)
+	}))
+}
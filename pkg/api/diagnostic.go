@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,7 +23,7 @@ type diagnosticsBody struct {
 }
 
 // SendDiagnostics sends diagnostics to the WakaTime api.
-func (c *Client) SendDiagnostics(plugin string, diagnostics ...diagnostic.Diagnostic) error {
+func (c *Client) SendDiagnostics(ctx context.Context, plugin string, diagnostics ...diagnostic.Diagnostic) error {
 	url := c.baseURL + "/plugins/errors"
 
 	log.Debugf("sending diagnostic data to api at %s", url)
@@ -50,7 +51,7 @@ func (c *Client) SendDiagnostics(plugin string, diagnostics ...diagnostic.Diagno
 		return fmt.Errorf("failed to json marshal request body: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %s", err)
 	}
@@ -59,7 +60,7 @@ func (c *Client) SendDiagnostics(plugin string, diagnostics ...diagnostic.Diagno
 
 	resp, err := c.Do(req)
 	if err != nil {
-		return Err(fmt.Sprintf("failed making request to %q: %s", url, err))
+		return newErrRequest(fmt.Sprintf("failed making request to %q: %s", url, err), err)
 	}
 	defer resp.Body.Close()
 
@@ -69,7 +70,7 @@ func (c *Client) SendDiagnostics(plugin string, diagnostics ...diagnostic.Diagno
 	}
 
 	if resp.StatusCode != http.StatusCreated {
-		return Err(fmt.Sprintf(
+		return newErrStatus(resp.StatusCode, fmt.Sprintf(
 			"invalid response status from %q. got: %d, want: %d. body: %q",
 			url,
 			resp.StatusCode,
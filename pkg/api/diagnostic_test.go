@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"os"
@@ -52,7 +53,7 @@ func TestClient_SendDiagnostics(t *testing.T) {
 	}
 
 	c := api.NewClient(url)
-	err := c.SendDiagnostics("vim", diagnostics...)
+	err := c.SendDiagnostics(context.Background(), "vim", diagnostics...)
 	require.NoError(t, err)
 
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
@@ -1,5 +1,19 @@
 package api
 
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
 // Err represents a general api error.
 type Err string
 
@@ -16,6 +30,75 @@ func (e ErrAuth) Error() string {
 	return string(e)
 }
 
+// ErrConnection represents a failure to connect to the API, e.g. due to a
+// refused connection or a failed DNS lookup. Most likely the user is offline.
+type ErrConnection string
+
+// Error method to implement error interface.
+func (e ErrConnection) Error() string {
+	return string(e)
+}
+
+// Unwrap returns the error as general api error, so it can be handled as Err.
+func (e ErrConnection) Unwrap() error {
+	return Err(e)
+}
+
+// ErrTimeout represents a request to the API, which timed out.
+type ErrTimeout string
+
+// Error method to implement error interface.
+func (e ErrTimeout) Error() string {
+	return string(e)
+}
+
+// Unwrap returns the error as general api error, so it can be handled as Err.
+func (e ErrTimeout) Unwrap() error {
+	return Err(e)
+}
+
+// ErrTLS represents a failed verification of the API's certificate or the
+// API rejecting the client certificate.
+type ErrTLS string
+
+// Error method to implement error interface.
+func (e ErrTLS) Error() string {
+	return string(e)
+}
+
+// Unwrap returns the error as general api error, so it can be handled as Err.
+func (e ErrTLS) Unwrap() error {
+	return Err(e)
+}
+
+// ErrProxyAuth represents a 407 Proxy Authentication Required response of
+// the configured proxy.
+type ErrProxyAuth string
+
+// Error method to implement error interface.
+func (e ErrProxyAuth) Error() string {
+	return string(e)
+}
+
+// Unwrap returns the error as general api error, so it can be handled as Err.
+func (e ErrProxyAuth) Unwrap() error {
+	return Err(e)
+}
+
+// ErrServer represents a 5xx response from the API, other than 503 Service
+// Unavailable, which is handled as ErrRateLimit.
+type ErrServer string
+
+// Error method to implement error interface.
+func (e ErrServer) Error() string {
+	return string(e)
+}
+
+// Unwrap returns the error as general api error, so it can be handled as Err.
+func (e ErrServer) Unwrap() error {
+	return Err(e)
+}
+
 // ErrBadRequest represents a 400 response from the API.
 type ErrBadRequest string
 
@@ -23,3 +106,117 @@ type ErrBadRequest string
 func (e ErrBadRequest) Error() string {
 	return string(e)
 }
+
+// ErrRateLimit represents a 429 Too Many Requests or 503 Service Unavailable
+// response from the API.
+type ErrRateLimit struct {
+	// Msg is the error message.
+	Msg string
+	// RetryAfter is the duration to wait before sending the next request, as
+	// parsed from the Retry-After header. Zero if the header is missing or invalid.
+	RetryAfter time.Duration
+}
+
+// Error method to implement error interface.
+func (e ErrRateLimit) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s. retry after %s", e.Msg, e.RetryAfter)
+	}
+
+	return e.Msg
+}
+
+// newErrRateLimit creates an ErrRateLimit from a rate limited response.
+func newErrRateLimit(resp *http.Response, url string) ErrRateLimit {
+	return ErrRateLimit{
+		Msg:        fmt.Sprintf("rate limited at %q. got: %d", url, resp.StatusCode),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an http date. Returns zero for missing, invalid or
+// past values.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+
+		return time.Duration(secs) * time.Second
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil || !at.After(now) {
+		return 0
+	}
+
+	return at.Sub(now).Round(time.Second)
+}
+
+// newErrRequest classifies the error of a request, which did not receive any
+// response from the API, and returns it with the passed in message.
+func newErrRequest(msg string, err error) error {
+	var (
+		dnsErr          *net.DNSError
+		netErr          net.Error
+		opErr           *net.OpError
+		unknownAuthErr  x509.UnknownAuthorityError
+		certInvalidErr  x509.CertificateInvalidError
+		hostnameErr     x509.HostnameError
+		systemRootsErr  x509.SystemRootsError
+		constraintErr   x509.ConstraintViolationError
+		insecureAlgoErr x509.InsecureAlgorithmError
+	)
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout(msg)
+	case errors.As(err, &unknownAuthErr),
+		errors.As(err, &certInvalidErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &systemRootsErr),
+		errors.As(err, &constraintErr),
+		errors.As(err, &insecureAlgoErr),
+		strings.Contains(err.Error(), "remote error: tls: "):
+		return ErrTLS(msg)
+	case isProxyAuthErr(err):
+		return ErrProxyAuth(msg)
+	case errors.As(err, &dnsErr),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.As(err, &opErr) && opErr.Op == "dial":
+		return ErrConnection(msg)
+	}
+
+	return Err(msg)
+}
+
+// isProxyAuthErr returns true, if the proxy rejected the CONNECT request with
+// 407 Proxy Authentication Required. The transport does not expose the CONNECT
+// response, but returns its reason phrase as error of the request.
+func isProxyAuthErr(err error) bool {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || urlErr.Err == nil {
+		return false
+	}
+
+	return urlErr.Err.Error() == http.StatusText(http.StatusProxyAuthRequired)
+}
+
+// newErrStatus returns the error for an unexpected response status from the
+// API with the passed in message.
+func newErrStatus(status int, msg string) error {
+	switch {
+	case status == http.StatusProxyAuthRequired:
+		return ErrProxyAuth(msg)
+	case status >= http.StatusInternalServerError:
+		return ErrServer(msg)
+	}
+
+	return Err(msg)
+}
@@ -0,0 +1,172 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SendHeartbeats_ErrConnection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	c := api.NewClient(url)
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+
+	var errconn api.ErrConnection
+
+	assert.True(t, errors.As(err, &errconn))
+}
+
+func TestClient_SendHeartbeats_ErrConnection_DNS(t *testing.T) {
+	c := api.NewClient("http://wakatime.invalid/api/v1")
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+
+	var errconn api.ErrConnection
+
+	assert.True(t, errors.As(err, &errconn))
+}
+
+func TestClient_SendHeartbeats_ErrTimeout(t *testing.T) {
+	url, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		<-done
+	})
+
+	c := api.NewClient(url, api.WithTimeout(50*time.Millisecond))
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+
+	var errtimeout api.ErrTimeout
+
+	assert.True(t, errors.As(err, &errtimeout))
+}
+
+func TestClient_SendHeartbeats_ErrTimeout_Context(t *testing.T) {
+	url, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		<-done
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := api.NewClient(url)
+	_, err := c.SendHeartbeats(ctx, testHeartbeats())
+
+	var errtimeout api.ErrTimeout
+
+	assert.True(t, errors.As(err, &errtimeout))
+}
+
+func TestClient_SendHeartbeats_ErrTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer srv.Close()
+
+	c := api.NewClient(srv.URL)
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+
+	var errtls api.ErrTLS
+
+	assert.True(t, errors.As(err, &errtls))
+}
+
+func TestClient_SendHeartbeats_ErrProxyAuth(t *testing.T) {
+	url, router, close := setupTestServer()
+	defer close()
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	})
+
+	c := api.NewClient(url)
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+
+	var errproxyauth api.ErrProxyAuth
+
+	assert.True(t, errors.As(err, &errproxyauth))
+}
+
+func TestClient_SendHeartbeats_ErrProxyAuth_Connect(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodConnect, req.Method)
+
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer proxy.Close()
+
+	withProxy, err := api.WithProxy(proxy.URL)
+	require.NoError(t, err)
+
+	c := api.NewClient("https://api.wakatime.com/api/v1", withProxy)
+	_, err = c.SendHeartbeats(context.Background(), testHeartbeats())
+
+	var errproxyauth api.ErrProxyAuth
+
+	assert.True(t, errors.As(err, &errproxyauth))
+}
+
+func TestClient_SendHeartbeats_ErrServer(t *testing.T) {
+	tests := []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusGatewayTimeout,
+	}
+
+	for _, status := range tests {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			url, router, close := setupTestServer()
+			defer close()
+
+			router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(status)
+			})
+
+			c := api.NewClient(url)
+			_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+
+			var errserver api.ErrServer
+
+			assert.True(t, errors.As(err, &errserver))
+		})
+	}
+}
+
+func TestErr_As(t *testing.T) {
+	tests := map[string]error{
+		"connection": api.ErrConnection("error"),
+		"timeout":    api.ErrTimeout("error"),
+		"tls":        api.ErrTLS("error"),
+		"proxy auth": api.ErrProxyAuth("error"),
+		"server":     api.ErrServer("error"),
+	}
+
+	for name, err := range tests {
+		t.Run(name, func(t *testing.T) {
+			var errapi api.Err
+
+			require.True(t, errors.As(fmt.Errorf("wrapped: %w", err), &errapi))
+
+			assert.Equal(t, api.Err("error"), errapi)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+// FileScheme is the scheme of api urls, which append heartbeats to a local
+// file instead of sending them to an api.
+const FileScheme = "file"
+
+// FileSender appends heartbeats as newline delimited json to a local file. It
+// implements heartbeat.Sender and reports every heartbeat as created, so it can
+// replace the api client in the heartbeat processing pipeline.
+type FileSender struct {
+	filepath string
+}
+
+// NewFileSender creates a new FileSender, appending to the file at filepath.
+func NewFileSender(filepath string) *FileSender {
+	return &FileSender{
+		filepath: filepath,
+	}
+}
+
+// IsFileURL reports, whether the api url points to a local file.
+func IsFileURL(apiURL string) bool {
+	return strings.HasPrefix(apiURL, FileScheme+"://")
+}
+
+// FilepathFromURL returns the local filepath of a file api url, e.g.
+// file:///var/log/heartbeats.ndjson.
+func FilepathFromURL(apiURL string) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse file url %q: %s", apiURL, err)
+	}
+
+	if u.Scheme != FileScheme {
+		return "", fmt.Errorf("invalid file url %q. scheme must be %q", apiURL, FileScheme)
+	}
+
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("invalid file url %q. host must be empty or localhost", apiURL)
+	}
+
+	if u.Path == "" {
+		return "", fmt.Errorf("invalid file url %q. missing path", apiURL)
+	}
+
+	path := u.Path
+
+	// file:///C:/path on windows
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+
+	return filepath.FromSlash(path), nil
+}
+
+// SendHeartbeats appends the heartbeats to the file within a single write and
+// returns a created result for every heartbeat.
+func (s *FileSender) SendHeartbeats(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	log.Debugf("appending %d heartbeat(s) to %s", len(hh), s.filepath)
+
+	var buf bytes.Buffer
+
+	results := make([]heartbeat.Result, 0, len(hh))
+
+	for _, h := range hh {
+		data, err := json.Marshal(h)
+		if err != nil {
+			return nil, fmt.Errorf("failed to json encode heartbeat: %s", err)
+		}
+
+		buf.Write(data)
+		buf.WriteByte('\n')
+
+		results = append(results, heartbeat.Result{
+			Heartbeat: h,
+			Status:    http.StatusCreated,
+		})
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.filepath), 0700); err != nil {
+		return nil, Err(fmt.Sprintf("failed to create directory of %q: %s", s.filepath, err))
+	}
+
+	f, err := os.OpenFile(s.filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, Err(fmt.Sprintf("failed to open %q: %s", s.filepath, err))
+	}
+
+	defer f.Close()
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return nil, Err(fmt.Sprintf("failed to append heartbeats to %q: %s", s.filepath, err))
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, Err(fmt.Sprintf("failed to close %q: %s", s.filepath, err))
+	}
+
+	return results, nil
+}
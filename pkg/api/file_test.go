@@ -0,0 +1,120 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender_SendHeartbeats(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "nested", "heartbeats.ndjson")
+
+	sender := api.NewFileSender(fp)
+
+	results, err := sender.SendHeartbeats(context.Background(), testHeartbeats())
+	require.NoError(t, err)
+
+	assert.Equal(t, []heartbeat.Result{
+		{
+			Heartbeat: testHeartbeats()[0],
+			Status:    http.StatusCreated,
+		},
+		{
+			Heartbeat: testHeartbeats()[1],
+			Status:    http.StatusCreated,
+		},
+	}, results)
+
+	_, err = sender.SendHeartbeats(context.Background(), testHeartbeats()[:1])
+	require.NoError(t, err)
+
+	f, err := os.Open(fp)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	var lines []heartbeat.Heartbeat
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var h heartbeat.Heartbeat
+
+		err := json.Unmarshal(scanner.Bytes(), &h)
+		require.NoError(t, err)
+
+		lines = append(lines, h)
+	}
+
+	require.NoError(t, scanner.Err())
+
+	assert.Equal(t, append(testHeartbeats(), testHeartbeats()[0]), lines)
+}
+
+func TestIsFileURL(t *testing.T) {
+	assert.True(t, api.IsFileURL("file:///tmp/heartbeats.ndjson"))
+	assert.False(t, api.IsFileURL("https://api.wakatime.com/api/v1"))
+}
+
+func TestFilepathFromURL(t *testing.T) {
+	tests := map[string]struct {
+		URL      string
+		Expected string
+	}{
+		"empty host": {
+			URL:      "file:///tmp/heartbeats.ndjson",
+			Expected: filepath.FromSlash("/tmp/heartbeats.ndjson"),
+		},
+		"localhost": {
+			URL:      "file://localhost/tmp/heartbeats.ndjson",
+			Expected: filepath.FromSlash("/tmp/heartbeats.ndjson"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fp, err := api.FilepathFromURL(test.URL)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, fp)
+		})
+	}
+}
+
+func TestFilepathFromURL_Err(t *testing.T) {
+	tests := map[string]struct {
+		URL      string
+		Expected string
+	}{
+		"remote host": {
+			URL:      "file://remote-host/tmp/heartbeats.ndjson",
+			Expected: `invalid file url "file://remote-host/tmp/heartbeats.ndjson". host must be empty or localhost`,
+		},
+		"missing path": {
+			URL:      "file://",
+			Expected: `invalid file url "file://". missing path`,
+		},
+		"http scheme": {
+			URL:      "http://localhost/heartbeats",
+			Expected: `invalid file url "http://localhost/heartbeats". scheme must be "file"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := api.FilepathFromURL(test.URL)
+			require.Error(t, err)
+
+			assert.Equal(t, test.Expected, err.Error())
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 
 	"github.com/wakatime/wakatime-cli/pkg/goal"
@@ -13,18 +15,19 @@ import (
 //
 // ErrRequest is returned upon request failure with no received response from api.
 // ErrAuth is returned upon receiving a 401 Unauthorized api response.
+// ErrRateLimit is returned upon receiving a 429 or 503 api response.
 // Err is returned on any other api response related error.
-func (c *Client) Goal(id string) (*goal.Goal, error) {
+func (c *Client) Goal(ctx context.Context, id string) (*goal.Goal, error) {
 	url := c.baseURL + "/users/current/goals/" + id
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %s", err)
 	}
 
 	resp, err := c.Do(req)
 	if err != nil {
-		return nil, Err(fmt.Sprintf("failed to make request to %q: %s", url, err))
+		return nil, newErrRequest(fmt.Sprintf("failed to make request to %q: %s", url, err), err)
 	}
 	defer resp.Body.Close()
 
@@ -40,8 +43,10 @@ func (c *Client) Goal(id string) (*goal.Goal, error) {
 		return nil, ErrAuth(fmt.Sprintf("authentication failed at %q. body: %q", url, string(body)))
 	case http.StatusBadRequest:
 		return nil, ErrBadRequest(fmt.Sprintf("bad request at %q", url))
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return nil, newErrRateLimit(resp, url)
 	default:
-		return nil, Err(fmt.Sprintf(
+		return nil, newErrStatus(resp.StatusCode, fmt.Sprintf(
 			"invalid response status from %q. got: %d, want: %d. body: %q",
 			url,
 			resp.StatusCode,
@@ -58,21 +63,146 @@ func (c *Client) Goal(id string) (*goal.Goal, error) {
 	return goal, nil
 }
 
+// Goals fetches all goals of the current user.
+//
+// ErrRequest is returned upon request failure with no received response from api.
+// ErrAuth is returned upon receiving a 401 Unauthorized api response.
+// ErrRateLimit is returned upon receiving a 429 or 503 api response.
+// Err is returned on any other api response related error.
+func (c *Client) Goals(ctx context.Context) ([]goal.Goal, error) {
+	url := c.baseURL + "/users/current/goals"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %s", err)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, newErrRequest(fmt.Sprintf("failed to make request to %q: %s", url, err), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, Err(fmt.Sprintf("failed to read response body from %q: %s", url, err))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		break
+	case http.StatusUnauthorized:
+		return nil, ErrAuth(fmt.Sprintf("authentication failed at %q. body: %q", url, string(body)))
+	case http.StatusBadRequest:
+		return nil, ErrBadRequest(fmt.Sprintf("bad request at %q", url))
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return nil, newErrRateLimit(resp, url)
+	default:
+		return nil, newErrStatus(resp.StatusCode, fmt.Sprintf(
+			"invalid response status from %q. got: %d, want: %d. body: %q",
+			url,
+			resp.StatusCode,
+			http.StatusOK,
+			string(body),
+		))
+	}
+
+	goals, err := ParseGoalsResponse(body)
+	if err != nil {
+		return nil, Err(fmt.Sprintf("failed to parse results from %q: %s", url, err))
+	}
+
+	return goals, nil
+}
+
+// goalData is the json representation of a goal in api responses.
+type goalData struct {
+	ChartData []struct {
+		ActualSeconds     float64 `json:"actual_seconds"`
+		ActualSecondsText string  `json:"actual_seconds_text"`
+		GoalSeconds       float64 `json:"goal_seconds"`
+		GoalSecondsText   string  `json:"goal_seconds_text"`
+	} `json:"chart_data"`
+	ID        string  `json:"id"`
+	IsInverse bool    `json:"is_inverse"`
+	Seconds   float64 `json:"seconds"`
+	Status    string  `json:"status"`
+	Title     string  `json:"title"`
+}
+
 // ParseGoalResponse parses the wakatime api response into goal.Goal.
 func ParseGoalResponse(data []byte) (*goal.Goal, error) {
 	var body struct {
-		Data struct {
-			ChartData []struct {
-				ActualSecondsText string `json:"actual_seconds_text"`
-			} `json:"chart_data"`
-		} `json:"data"`
+		Data goalData `json:"data"`
 	}
 
 	if err := json.Unmarshal(data, &body); err != nil {
 		return nil, fmt.Errorf("failed to parse json response body: %s. body: %q", err, data)
 	}
 
-	return &goal.Goal{
-		Total: body.Data.ChartData[len(body.Data.ChartData)-1].ActualSecondsText,
-	}, nil
+	if len(body.Data.ChartData) == 0 {
+		return nil, fmt.Errorf("no chart data found for goal. body: %q", data)
+	}
+
+	g := parseGoal(body.Data)
+
+	return &g, nil
+}
+
+// ParseGoalsResponse parses the wakatime api response of the goals list into goal.Goal.
+// Goals without chart data are returned without progress.
+func ParseGoalsResponse(data []byte) ([]goal.Goal, error) {
+	var body struct {
+		Data []goalData `json:"data"`
+	}
+
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse json response body: %s. body: %q", err, data)
+	}
+
+	goals := make([]goal.Goal, 0, len(body.Data))
+
+	for _, d := range body.Data {
+		goals = append(goals, parseGoal(d))
+	}
+
+	return goals, nil
+}
+
+// parseGoal converts the json representation of a goal into goal.Goal, taking
+// the progress from the most recent chart data.
+func parseGoal(d goalData) goal.Goal {
+	g := goal.Goal{
+		ID:            d.ID,
+		Title:         d.Title,
+		Status:        d.Status,
+		TargetSeconds: d.Seconds,
+		IsInverse:     d.IsInverse,
+	}
+
+	if len(d.ChartData) == 0 {
+		return g
+	}
+
+	latest := d.ChartData[len(d.ChartData)-1]
+
+	g.Seconds = latest.ActualSeconds
+	g.Total = latest.ActualSecondsText
+	g.Target = latest.GoalSecondsText
+
+	if latest.GoalSeconds > 0 {
+		g.TargetSeconds = latest.GoalSeconds
+	}
+
+	if g.TargetSeconds > 0 {
+		g.Percent = math.Round(g.Seconds/g.TargetSeconds*1000) / 10
+
+		if g.IsInverse {
+			g.IsMet = g.Seconds <= g.TargetSeconds
+		} else {
+			g.IsMet = g.Seconds >= g.TargetSeconds
+		}
+	}
+
+	return g
 }
@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/goal"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -40,7 +42,7 @@ func TestClient_Goal(t *testing.T) {
 		})
 
 	c := api.NewClient(u)
-	goal, err := c.Goal("00000000-0000-4000-8000-000000000000")
+	goal, err := c.Goal(context.Background(), "00000000-0000-4000-8000-000000000000")
 
 	require.NoError(t, err)
 
@@ -66,7 +68,7 @@ func TestClient_GoalWithTimeout(t *testing.T) {
 
 	opts := []api.Option{api.WithTimeout(20 * time.Millisecond)}
 	c := api.NewClient(u, opts...)
-	_, err := c.Goal("00000000-0000-4000-8000-000000000000")
+	_, err := c.Goal(context.Background(), "00000000-0000-4000-8000-000000000000")
 	require.Error(t, err)
 
 	errMsg := fmt.Sprintf("error %q does not contain string 'Timeout'", err)
@@ -94,7 +96,7 @@ func TestClient_Goal_Err(t *testing.T) {
 		})
 
 	c := api.NewClient(u)
-	_, err := c.Goal("00000000-0000-4000-8000-000000000000")
+	_, err := c.Goal(context.Background(), "00000000-0000-4000-8000-000000000000")
 
 	var apierr api.Err
 
@@ -115,7 +117,7 @@ func TestClient_Goal_ErrAuth(t *testing.T) {
 		})
 
 	c := api.NewClient(u)
-	_, err := c.Goal("00000000-0000-4000-8000-000000000000")
+	_, err := c.Goal(context.Background(), "00000000-0000-4000-8000-000000000000")
 
 	var errauth api.ErrAuth
 
@@ -136,7 +138,7 @@ func TestClient_Goal_ErrBadRequest(t *testing.T) {
 		})
 
 	c := api.NewClient(u)
-	_, err := c.Goal("00000000-0000-4000-8000-000000000000")
+	_, err := c.Goal(context.Background(), "00000000-0000-4000-8000-000000000000")
 
 	var errbadRequest api.ErrBadRequest
 
@@ -144,11 +146,121 @@ func TestClient_Goal_ErrBadRequest(t *testing.T) {
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestClient_Goal_ErrRateLimit(t *testing.T) {
+	u, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc(
+		"/users/current/goals/00000000-0000-4000-8000-000000000000", func(w http.ResponseWriter, req *http.Request) {
+			numCalls++
+			w.Header().Set("Retry-After", "invalid")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+	c := api.NewClient(u)
+	_, err := c.Goal(context.Background(), "00000000-0000-4000-8000-000000000000")
+
+	var errratelimit api.ErrRateLimit
+
+	require.True(t, errors.As(err, &errratelimit))
+
+	assert.Zero(t, errratelimit.RetryAfter)
+	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
+}
+
 func TestClient_Goal_ErrInvalidUrl(t *testing.T) {
 	c := api.NewClient("invalid-url")
-	_, err := c.Goal("00000000-0000-4000-8000-000000000000")
+	_, err := c.Goal(context.Background(), "00000000-0000-4000-8000-000000000000")
 
 	var apierr api.Err
 
 	assert.True(t, errors.As(err, &apierr))
 }
+
+func TestClient_Goals(t *testing.T) {
+	u, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/goals", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+
+		// check request
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, []string{"application/json"}, req.Header["Accept"])
+
+		// write response
+		f, err := os.Open("testdata/api_goals_response.json")
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusOK)
+		_, err = io.Copy(w, f)
+		require.NoError(t, err)
+	})
+
+	c := api.NewClient(u)
+	goals, err := c.Goals(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []goal.Goal{
+		{
+			ID:            "00000000-0000-4000-8000-000000000000",
+			Title:         "Code 4 hrs per day",
+			Status:        "pending",
+			Seconds:       12180,
+			Total:         "3 hrs 23 mins",
+			TargetSeconds: 14400,
+			Target:        "4 hrs",
+			Percent:       84.6,
+		},
+		{
+			ID:            "00000000-0000-4000-8000-000000000001",
+			Title:         "Debug less than 2 hrs per week",
+			Status:        "success",
+			Seconds:       3600,
+			Total:         "1 hr",
+			TargetSeconds: 7200,
+			Target:        "2 hrs",
+			Percent:       50,
+			IsInverse:     true,
+			IsMet:         true,
+		},
+		{
+			ID:            "00000000-0000-4000-8000-000000000002",
+			Title:         "Write docs 1 hr per day",
+			Status:        "ignored",
+			TargetSeconds: 3600,
+		},
+	}, goals)
+
+	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
+}
+
+func TestParseGoalResponse(t *testing.T) {
+	data, err := os.ReadFile("testdata/api_goals_id_full_response.json")
+	require.NoError(t, err)
+
+	g, err := api.ParseGoalResponse(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, &goal.Goal{
+		ID:            "00000000-0000-4000-8000-000000000000",
+		Title:         "Code 4 hrs per day",
+		Status:        "pending",
+		Seconds:       12180,
+		Total:         "3 hrs 23 mins",
+		TargetSeconds: 14400,
+		Target:        "4 hrs",
+		Percent:       84.6,
+	}, g)
+}
+
+func TestParseGoalResponse_EmptyChart(t *testing.T) {
+	_, err := api.ParseGoalResponse([]byte(`{"data":{"chart_data":[]}}`))
+	require.Error(t, err)
+
+	assert.Equal(t, `no chart data found for goal. body: "{\"data\":{\"chart_data\":[]}}"`, err.Error())
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+// gzipState holds the state of compressing heartbeat uploads. Shared between
+// concurrent requests of the same client.
+type gzipState struct {
+	mu            sync.Mutex
+	unsupported   bool
+	onUnsupported func()
+}
+
+// WithGzip compresses heartbeat uploads with gzip. If the api responds with
+// 415 Unsupported Media Type, the upload is repeated as plain json and
+// compression is disabled for all further requests of the client. The passed
+// in callback is called once in that case, to allow recording the choice.
+func WithGzip(onUnsupported func()) Option {
+	return func(c *Client) {
+		c.gzip = &gzipState{
+			onUnsupported: onUnsupported,
+		}
+	}
+}
+
+// enabled reports, whether request bodies should be compressed.
+func (s *gzipState) enabled() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.unsupported
+}
+
+// disable disables compression and calls the callback on the first call.
+func (s *gzipState) disable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.unsupported {
+		return
+	}
+
+	s.unsupported = true
+
+	if s.onUnsupported != nil {
+		s.onUnsupported()
+	}
+}
+
+// postJSON posts the json encoded data to url. Data gets compressed, if gzip
+// compression is enabled, falling back to plain json, if not supported by
+// the api.
+func (c *Client) postJSON(ctx context.Context, url string, data []byte) (*http.Response, error) {
+	if !c.gzip.enabled() {
+		return c.post(ctx, url, data, false)
+	}
+
+	resp, err := c.post(ctx, url, data, true)
+	if err != nil || resp.StatusCode != http.StatusUnsupportedMediaType {
+		return resp, err
+	}
+
+	resp.Body.Close()
+
+	log.Debugf("api at %q does not support gzip compressed requests, falling back to plain json", url)
+
+	c.gzip.disable()
+
+	return c.post(ctx, url, data, false)
+}
+
+func (c *Client) post(ctx context.Context, url string, data []byte, compress bool) (*http.Response, error) {
+	body := data
+
+	if compress {
+		var buf bytes.Buffer
+
+		w := gzip.NewWriter(&buf)
+
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to gzip compress body: %s", err)
+		}
+
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to gzip compress body: %s", err)
+		}
+
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %s", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, newErrRequest(fmt.Sprintf("failed making request to %q: %s", url, err), err)
+	}
+
+	return resp, nil
+}
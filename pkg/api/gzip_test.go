@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SendHeartbeats_Gzip(t *testing.T) {
+	url, router, close := setupTestServer()
+	defer close()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+
+		// check headers
+		assert.Equal(t, []string{"gzip"}, req.Header["Content-Encoding"])
+		assert.Equal(t, []string{"application/json"}, req.Header["Content-Type"])
+
+		// check body
+		expectedBody, err := os.ReadFile("testdata/api_heartbeats_request.json")
+		require.NoError(t, err)
+
+		r, err := gzip.NewReader(req.Body)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(r)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, string(expectedBody), string(body))
+
+		// write response
+		f, err := os.Open("testdata/api_heartbeats_response.json")
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusCreated)
+		_, err = io.Copy(w, f)
+		require.NoError(t, err)
+	})
+
+	c := api.NewClient(url, api.WithGzip(func() {
+		t.Fatal("compression must not be reported as unsupported")
+	}))
+
+	results, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+	require.NoError(t, err)
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, 1, numCalls)
+}
+
+func TestClient_SendHeartbeats_GzipUnsupported(t *testing.T) {
+	url, router, close := setupTestServer()
+	defer close()
+
+	var (
+		compressedCalls int
+		plainCalls      int
+	)
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			compressedCalls++
+
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+
+			return
+		}
+
+		plainCalls++
+
+		// check body
+		expectedBody, err := os.ReadFile("testdata/api_heartbeats_request.json")
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, string(expectedBody), string(body))
+
+		// write response
+		f, err := os.Open("testdata/api_heartbeats_response.json")
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusCreated)
+		_, err = io.Copy(w, f)
+		require.NoError(t, err)
+	})
+
+	var unsupported int
+
+	c := api.NewClient(url, api.WithGzip(func() {
+		unsupported++
+	}))
+
+	results, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+	require.NoError(t, err)
+
+	assert.Len(t, results, 2)
+
+	// further requests skip compression
+	_, err = c.SendHeartbeats(context.Background(), testHeartbeats())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, compressedCalls)
+	assert.Equal(t, 2, plainCalls)
+	assert.Equal(t, 1, unsupported)
+}
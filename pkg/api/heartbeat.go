@@ -1,7 +1,7 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,8 +20,9 @@ import (
 //
 // ErrRequest is returned upon request failure with no received response from api.
 // ErrAuth is returned upon receiving a 401 Unauthorized api response.
+// ErrRateLimit is returned upon receiving a 429 or 503 api response.
 // Err is returned on any other api response related error.
-func (c *Client) SendHeartbeats(heartbeats []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+func (c *Client) SendHeartbeats(ctx context.Context, heartbeats []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 	url := c.baseURL + "/users/current/heartbeats.bulk"
 
 	log.Debugf("sending %d heartbeat(s) to api at %s", len(heartbeats), url)
@@ -36,7 +37,7 @@ func (c *Client) SendHeartbeats(heartbeats []heartbeat.Heartbeat) ([]heartbeat.R
 
 	// don't spawn threads when there's only one api key set.
 	if len(grouped) == 1 {
-		c.sendHeartbeats(url, heartbeats, chres, cherr)
+		c.sendHeartbeats(ctx, url, heartbeats, chres, cherr)
 
 		return <-chres, <-cherr
 	}
@@ -62,7 +63,7 @@ func (c *Client) SendHeartbeats(heartbeats []heartbeat.Heartbeat) ([]heartbeat.R
 
 			auth(c)
 
-			c.sendHeartbeats(url, hh, chres, cherr)
+			c.sendHeartbeats(ctx, url, hh, chres, cherr)
 		}()
 	}
 
@@ -83,7 +84,7 @@ func (c *Client) SendHeartbeats(heartbeats []heartbeat.Heartbeat) ([]heartbeat.R
 	return results, nil
 }
 
-func (c *Client) sendHeartbeats(url string, heartbeats []heartbeat.Heartbeat,
+func (c *Client) sendHeartbeats(ctx context.Context, url string, heartbeats []heartbeat.Heartbeat,
 	chresults chan []heartbeat.Result, cherr chan error) {
 	data, err := json.Marshal(heartbeats)
 	if err != nil {
@@ -95,19 +96,9 @@ func (c *Client) sendHeartbeats(url string, heartbeats []heartbeat.Heartbeat,
 
 	log.Debugf("heartbeats: %s", string(data))
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+	resp, err := c.postJSON(ctx, url, data)
 	if err != nil {
-		cherr <- fmt.Errorf("failed to create request: %s", err)
-		chresults <- nil
-
-		return
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.Do(req)
-	if err != nil {
-		cherr <- Err(fmt.Sprintf("failed making request to %q: %s", url, err))
+		cherr <- err
 		chresults <- nil
 
 		return
@@ -134,9 +125,14 @@ func (c *Client) sendHeartbeats(url string, heartbeats []heartbeat.Heartbeat,
 		cherr <- ErrBadRequest(fmt.Sprintf("bad request at %q", url))
 		chresults <- nil
 
+		return
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		cherr <- newErrRateLimit(resp, url)
+		chresults <- nil
+
 		return
 	default:
-		cherr <- Err(fmt.Sprintf(
+		cherr <- newErrStatus(resp.StatusCode, fmt.Sprintf(
 			"invalid response status from %q. got: %d, want: %d/%d. body: %q",
 			url,
 			resp.StatusCode,
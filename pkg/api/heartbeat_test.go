@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -55,7 +56,7 @@ func TestClient_SendHeartbeats(t *testing.T) {
 			})
 
 			c := api.NewClient(url)
-			results, err := c.SendHeartbeats(testHeartbeats())
+			results, err := c.SendHeartbeats(context.Background(), testHeartbeats())
 			require.NoError(t, err)
 
 			// check via assert.Equal on complete slice here, to assert exact order of results,
@@ -115,7 +116,7 @@ func TestClient_SendHeartbeats_Err(t *testing.T) {
 	})
 
 	c := api.NewClient(url)
-	_, err := c.SendHeartbeats(testHeartbeats())
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
 
 	var errapi api.Err
 
@@ -124,6 +125,27 @@ func TestClient_SendHeartbeats_Err(t *testing.T) {
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestClient_SendHeartbeats_ContextCanceled(t *testing.T) {
+	url, router, close := setupTestServer()
+	defer close()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := api.NewClient(url)
+	_, err := c.SendHeartbeats(ctx, testHeartbeats())
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "context canceled")
+	assert.Zero(t, numCalls)
+}
+
 func TestClient_SendHeartbeats_ErrAuth(t *testing.T) {
 	url, router, close := setupTestServer()
 	defer close()
@@ -136,7 +158,7 @@ func TestClient_SendHeartbeats_ErrAuth(t *testing.T) {
 	})
 
 	c := api.NewClient(url)
-	_, err := c.SendHeartbeats(testHeartbeats())
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
 
 	var errauth api.ErrAuth
 
@@ -157,7 +179,7 @@ func TestClient_SendHeartbeats_ErrBadRequest(t *testing.T) {
 	})
 
 	c := api.NewClient(url)
-	_, err := c.SendHeartbeats(testHeartbeats())
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
 
 	var errbadRequest api.ErrBadRequest
 
@@ -166,9 +188,32 @@ func TestClient_SendHeartbeats_ErrBadRequest(t *testing.T) {
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestClient_SendHeartbeats_ErrRateLimit(t *testing.T) {
+	url, router, close := setupTestServer()
+	defer close()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/heartbeats.bulk", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	c := api.NewClient(url)
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
+
+	var errratelimit api.ErrRateLimit
+
+	require.True(t, errors.As(err, &errratelimit))
+
+	assert.Equal(t, 2*time.Minute, errratelimit.RetryAfter)
+	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
+}
+
 func TestClient_SendHeartbeats_InvalidUrl(t *testing.T) {
 	c := api.NewClient("invalid-url")
-	_, err := c.SendHeartbeats(testHeartbeats())
+	_, err := c.SendHeartbeats(context.Background(), testHeartbeats())
 
 	var apierr api.Err
 
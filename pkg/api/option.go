@@ -1,6 +1,7 @@
 package api
 
 import (
+	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"net/http"
@@ -54,10 +55,35 @@ func WithDisableSSLVerify() Option {
 		tlsConfig.InsecureSkipVerify = true
 
 		transport.TLSClientConfig = tlsConfig
-		c.client.Transport = transport
+		setTransport(c, transport)
 	}
 }
 
+// WithClientCert configures the client to present the passed in certificate
+// for mutual tls authentication.
+func WithClientCert(cert tls.Certificate) Option {
+	return func(c *Client) {
+		var transport = LazyCreateNewTransport(c)
+		tlsConfig := transport.TLSClientConfig
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		transport.TLSClientConfig = tlsConfig
+
+		setTransport(c, transport)
+	}
+}
+
+// WithClientCertFile configures the client to present the certificate from
+// certFile and keyFile for mutual tls authentication. An encrypted key is
+// decrypted with the passed in passphrase.
+func WithClientCertFile(certFile, keyFile string, passphrase []byte) (Option, error) {
+	cert, err := LoadClientCert(certFile, keyFile, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithClientCert(cert), nil
+}
+
 // WithNTLM allows authentication via ntlm protocol.
 func WithNTLM(creds string) (Option, error) {
 	if !strings.Contains(creds, `\\`) {
@@ -114,6 +140,7 @@ func WithNTLMRequestRetry(creds string) (Option, error) {
 }
 
 // WithProxy configures the client to proxy outgoing requests to the specified url.
+// It takes priority over the proxy environment variables.
 func WithProxy(proxyURL string) (Option, error) {
 	u, err := url.Parse(proxyURL)
 	if err != nil {
@@ -121,9 +148,11 @@ func WithProxy(proxyURL string) (Option, error) {
 	}
 
 	return func(c *Client) {
+		c.proxy.url = u
+
 		var transport = LazyCreateNewTransport(c)
-		transport.Proxy = http.ProxyURL(u)
-		c.client.Transport = transport
+		transport.Proxy = c.resolveProxy
+		setTransport(c, transport)
 	}, nil
 }
 
@@ -148,7 +177,7 @@ func WithSSLCertPool(caCertPool *x509.CertPool) (Option, error) {
 		tlsConfig.RootCAs = caCertPool
 		transport.TLSClientConfig = tlsConfig
 
-		c.client.Transport = transport
+		setTransport(c, transport)
 	}, nil
 }
 
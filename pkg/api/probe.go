@@ -0,0 +1,25 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Probe checks, whether the api is reachable, by sending a HEAD request to
+// the base url. Any response counts as reachable, regardless of its status.
+// Use the passed in context to limit the time to wait for a response.
+func (c *Client) Probe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %s", err)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return newErrRequest(fmt.Sprintf("failed making request to %q: %s", c.baseURL, err), err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
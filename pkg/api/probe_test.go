@@ -0,0 +1,49 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Probe(t *testing.T) {
+	url, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+
+		assert.Equal(t, http.MethodHead, req.Method)
+
+		// any response counts as reachable
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	c := api.NewClient(url)
+	err := c.Probe(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, numCalls)
+}
+
+func TestClient_Probe_ErrConnection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	c := api.NewClient(url)
+	err := c.Probe(context.Background())
+
+	var errconn api.ErrConnection
+
+	assert.True(t, errors.As(err, &errconn))
+}
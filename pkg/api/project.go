@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/project"
+)
+
+// Projects fetches a page of the projects of the current user. Optionally
+// filtered by a search query, matching the project names. Pages start at 1.
+//
+// ErrRequest is returned upon request failure with no received response from api.
+// ErrAuth is returned upon receiving a 401 Unauthorized api response.
+// ErrRateLimit is returned upon receiving a 429 or 503 api response.
+// Err is returned on any other api response related error.
+func (c *Client) Projects(ctx context.Context, query string, page int) (*project.RemotePage, error) {
+	url := c.baseURL + "/users/current/projects"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %s", err)
+	}
+
+	q := req.URL.Query()
+
+	if query != "" {
+		q.Add("q", query)
+	}
+
+	if page > 1 {
+		q.Add("page", strconv.Itoa(page))
+	}
+
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, newErrRequest(fmt.Sprintf("failed to make request to %q: %s", url, err), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, Err(fmt.Sprintf("failed to read response body from %q: %s", url, err))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		break
+	case http.StatusUnauthorized:
+		return nil, ErrAuth(fmt.Sprintf("authentication failed at %q. body: %q", url, string(body)))
+	case http.StatusBadRequest:
+		return nil, ErrBadRequest(fmt.Sprintf("bad request at %q. body: %q", url, string(body)))
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return nil, newErrRateLimit(resp, url)
+	default:
+		return nil, newErrStatus(resp.StatusCode, fmt.Sprintf(
+			"invalid response status from %q. got: %d, want: %d. body: %q",
+			url,
+			resp.StatusCode,
+			http.StatusOK,
+			string(body),
+		))
+	}
+
+	parsed, err := ParseProjectsResponse(body)
+	if err != nil {
+		return nil, Err(fmt.Sprintf("failed to parse results from %q: %s", url, err))
+	}
+
+	return parsed, nil
+}
+
+// ParseProjectsResponse parses the wakatime api response into project.RemotePage.
+func ParseProjectsResponse(data []byte) (*project.RemotePage, error) {
+	var body struct {
+		Data []struct {
+			ID              string     `json:"id"`
+			LastHeartbeatAt *time.Time `json:"last_heartbeat_at"`
+			Name            string     `json:"name"`
+			Repository      *struct {
+				HTMLURL string `json:"html_url"`
+				URL     string `json:"url"`
+			} `json:"repository"`
+		} `json:"data"`
+		Page       int `json:"page"`
+		Total      int `json:"total"`
+		TotalPages int `json:"total_pages"`
+	}
+
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse json response body: %s. body: %q", err, data)
+	}
+
+	parsed := project.RemotePage{
+		Projects:   make([]project.Remote, 0, len(body.Data)),
+		Page:       body.Page,
+		Total:      body.Total,
+		TotalPages: body.TotalPages,
+	}
+
+	for _, p := range body.Data {
+		remote := project.Remote{
+			ID:              p.ID,
+			Name:            p.Name,
+			LastHeartbeatAt: p.LastHeartbeatAt,
+		}
+
+		if p.Repository != nil {
+			remote.Repository = p.Repository.HTMLURL
+			if remote.Repository == "" {
+				remote.Repository = p.Repository.URL
+			}
+		}
+
+		parsed.Projects = append(parsed.Projects, remote)
+	}
+
+	return &parsed, nil
+}
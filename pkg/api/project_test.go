@@ -0,0 +1,102 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/project"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Projects(t *testing.T) {
+	u, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/projects", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+
+		// check request
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, []string{"application/json"}, req.Header["Accept"])
+		assert.Equal(t, "waka", req.URL.Query().Get("q"))
+		assert.Equal(t, "2", req.URL.Query().Get("page"))
+
+		// write response
+		f, err := os.Open("testdata/api_projects_response.json")
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusOK)
+		_, err = io.Copy(w, f)
+		require.NoError(t, err)
+	})
+
+	c := api.NewClient(u)
+	page, err := c.Projects(context.Background(), "waka", 2)
+	require.NoError(t, err)
+
+	lastHeartbeatAt := time.Date(2021, 9, 16, 13, 4, 5, 0, time.UTC)
+
+	assert.Equal(t, &project.RemotePage{
+		Projects: []project.Remote{
+			{
+				ID:              "00000000-0000-4000-8000-000000000000",
+				Name:            "wakatime-cli",
+				LastHeartbeatAt: &lastHeartbeatAt,
+				Repository:      "https://github.com/wakatime/wakatime-cli",
+			},
+			{
+				ID:   "00000000-0000-4000-8000-000000000001",
+				Name: "wakatime",
+			},
+		},
+		Page:       1,
+		Total:      3,
+		TotalPages: 2,
+	}, page)
+
+	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
+}
+
+func TestClient_Projects_NoQuery(t *testing.T) {
+	u, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/projects", func(w http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.URL.RawQuery)
+
+		_, err := w.Write([]byte(`{"data":[],"page":1,"total":0,"total_pages":1}`))
+		require.NoError(t, err)
+	})
+
+	c := api.NewClient(u)
+	page, err := c.Projects(context.Background(), "", 1)
+	require.NoError(t, err)
+
+	assert.Empty(t, page.Projects)
+}
+
+func TestClient_Projects_ErrAuth(t *testing.T) {
+	u, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/projects", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	c := api.NewClient(u)
+	_, err := c.Projects(context.Background(), "", 1)
+
+	var errauth api.ErrAuth
+
+	assert.True(t, errors.As(err, &errauth))
+}
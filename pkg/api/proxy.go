@@ -0,0 +1,241 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+// proxyConfig contains the proxy settings of the client. Requests are sent
+// via the explicitly configured proxy, or else via the proxy of the standard
+// environment variables, unless the host matches the no proxy list.
+type proxyConfig struct {
+	// url is the explicitly configured proxy url.
+	url *url.URL
+	// noProxy contains hosts, which are connected to directly.
+	noProxy []noProxyMatcher
+}
+
+// noProxyMatcher matches a host against a single no proxy entry.
+type noProxyMatcher func(host, port string) bool
+
+// ProxyFromEnvironment returns the proxy url for the request following the
+// standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables. Unlike
+// http.ProxyFromEnvironment, the environment is read on every call.
+func ProxyFromEnvironment(req *http.Request) (*url.URL, error) {
+	return proxyConfig{}.resolve(req)
+}
+
+// WithNoProxy configures the client to connect directly to hosts matching one
+// of the passed in entries, instead of using a proxy. Entries are either host
+// names, matching the host and all its subdomains, domain suffixes like
+// .example.org, ip addresses, CIDR ranges like 10.0.0.0/8, or * to match all
+// hosts. Host names and ip addresses may be restricted to a port.
+func WithNoProxy(entries []string) (Option, error) {
+	var matchers []noProxyMatcher
+
+	for _, entry := range entries {
+		matcher, err := parseNoProxy(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if matcher != nil {
+			matchers = append(matchers, matcher)
+		}
+	}
+
+	return func(c *Client) {
+		c.proxy.noProxy = matchers
+
+		var transport = LazyCreateNewTransport(c)
+		transport.Proxy = c.resolveProxy
+		setTransport(c, transport)
+	}, nil
+}
+
+// resolveProxy returns the proxy url for the request.
+func (c *Client) resolveProxy(req *http.Request) (*url.URL, error) {
+	return c.proxy.resolve(req)
+}
+
+// resolve returns the proxy url for the request, or nil to connect directly.
+func (p proxyConfig) resolve(req *http.Request) (*url.URL, error) {
+	host, port := req.URL.Hostname(), req.URL.Port()
+	if port == "" {
+		port = defaultPort(req.URL.Scheme)
+	}
+
+	if matchNoProxy(host, port, p.noProxy) {
+		log.Debugf("using no proxy for request to %s, as it matches no_proxy", req.URL.Host)
+
+		return nil, nil
+	}
+
+	if p.url != nil {
+		log.Debugf("using proxy %s for request to %s", p.url.Redacted(), req.URL.Host)
+
+		return p.url, nil
+	}
+
+	key, value := proxyEnv(req.URL.Scheme)
+	if value == "" {
+		log.Debugf("using no proxy for request to %s", req.URL.Host)
+
+		return nil, nil
+	}
+
+	// follow net/http and never proxy requests to localhost via environment
+	if isLocalhost(host) || matchNoProxy(host, port, envNoProxy()) {
+		log.Debugf("using no proxy for request to %s, as it matches NO_PROXY", req.URL.Host)
+
+		return nil, nil
+	}
+
+	u, err := parseProxyEnv(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url in %s: %s", key, err)
+	}
+
+	log.Debugf("using proxy %s from %s for request to %s", u.Redacted(), key, req.URL.Host)
+
+	return u, nil
+}
+
+// proxyEnv returns the name and value of the proxy environment variable
+// matching the scheme.
+func proxyEnv(scheme string) (string, string) {
+	keys := []string{"HTTPS_PROXY", "https_proxy"}
+	if scheme == "http" {
+		keys = []string{"HTTP_PROXY", "http_proxy"}
+	}
+
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return key, value
+		}
+	}
+
+	return "", ""
+}
+
+// envNoProxy returns the matchers of the NO_PROXY environment variable.
+// Invalid entries are ignored.
+func envNoProxy() []noProxyMatcher {
+	value := os.Getenv("NO_PROXY")
+	if value == "" {
+		value = os.Getenv("no_proxy")
+	}
+
+	var matchers []noProxyMatcher
+
+	for _, entry := range strings.Split(value, ",") {
+		matcher, err := parseNoProxy(entry)
+		if err != nil {
+			log.Debugf("ignoring NO_PROXY entry: %s", err)
+			continue
+		}
+
+		if matcher != nil {
+			matchers = append(matchers, matcher)
+		}
+	}
+
+	return matchers
+}
+
+// parseProxyEnv parses a proxy url from an environment variable, which may
+// omit the scheme.
+func parseProxyEnv(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		if u, err := url.Parse("http://" + value); err == nil {
+			return u, nil
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// parseNoProxy parses a single no proxy entry. Returns nil for empty entries.
+func parseNoProxy(entry string) (noProxyMatcher, error) {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+
+	switch {
+	case entry == "":
+		return nil, nil
+	case entry == "*":
+		return func(_, _ string) bool { return true }, nil
+	case strings.Contains(entry, "/"):
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid no proxy CIDR %q: %s", entry, err)
+		}
+
+		return func(host, _ string) bool {
+			ip := net.ParseIP(host)
+			return ip != nil && network.Contains(ip)
+		}, nil
+	}
+
+	var entryPort string
+
+	if h, p, err := net.SplitHostPort(entry); err == nil {
+		entry, entryPort = h, p
+	}
+
+	if ip := net.ParseIP(strings.Trim(entry, "[]")); ip != nil {
+		return func(host, port string) bool {
+			return ip.Equal(net.ParseIP(host)) && (entryPort == "" || entryPort == port)
+		}, nil
+	}
+
+	// example.org, .example.org and *.example.org all match example.org and its subdomains
+	domain := strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+
+	return func(host, port string) bool {
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+		return (host == domain || strings.HasSuffix(host, "."+domain)) && (entryPort == "" || entryPort == port)
+	}, nil
+}
+
+func matchNoProxy(host, port string, matchers []noProxyMatcher) bool {
+	for _, match := range matchers {
+		if match(host, port) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isLocalhost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+
+	return ""
+}
@@ -0,0 +1,262 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyFromEnvironment(t *testing.T) {
+	tests := map[string]struct {
+		Env      map[string]string
+		URL      string
+		Expected string
+	}{
+		"no proxy set": {
+			URL: "https://api.wakatime.com/api/v1",
+		},
+		"https proxy": {
+			Env:      map[string]string{"HTTPS_PROXY": "http://proxy.example.org:3128"},
+			URL:      "https://api.wakatime.com/api/v1",
+			Expected: "http://proxy.example.org:3128",
+		},
+		"https proxy lowercase": {
+			Env:      map[string]string{"https_proxy": "http://proxy.example.org:3128"},
+			URL:      "https://api.wakatime.com/api/v1",
+			Expected: "http://proxy.example.org:3128",
+		},
+		"https proxy without scheme": {
+			Env:      map[string]string{"HTTPS_PROXY": "proxy.example.org:3128"},
+			URL:      "https://api.wakatime.com/api/v1",
+			Expected: "http://proxy.example.org:3128",
+		},
+		"http proxy ignored for https": {
+			Env: map[string]string{"HTTP_PROXY": "http://proxy.example.org:3128"},
+			URL: "https://api.wakatime.com/api/v1",
+		},
+		"http proxy": {
+			Env:      map[string]string{"HTTP_PROXY": "http://proxy.example.org:3128"},
+			URL:      "http://wakapi.example.org/api",
+			Expected: "http://proxy.example.org:3128",
+		},
+		"localhost never proxied": {
+			Env: map[string]string{"HTTP_PROXY": "http://proxy.example.org:3128"},
+			URL: "http://localhost:3000/api",
+		},
+		"loopback never proxied": {
+			Env: map[string]string{"HTTP_PROXY": "http://proxy.example.org:3128"},
+			URL: "http://127.0.0.1:3000/api",
+		},
+		"no proxy domain": {
+			Env: map[string]string{
+				"HTTPS_PROXY": "http://proxy.example.org:3128",
+				"NO_PROXY":    "wakatime.com",
+			},
+			URL: "https://api.wakatime.com/api/v1",
+		},
+		"no proxy domain suffix": {
+			Env: map[string]string{
+				"HTTPS_PROXY": "http://proxy.example.org:3128",
+				"no_proxy":    "example.org,.wakatime.com",
+			},
+			URL: "https://api.wakatime.com/api/v1",
+		},
+		"no proxy wildcard domain": {
+			Env: map[string]string{
+				"HTTPS_PROXY": "http://proxy.example.org:3128",
+				"NO_PROXY":    "*.WakaTime.com",
+			},
+			URL: "https://api.wakatime.com/api/v1",
+		},
+		"no proxy cidr": {
+			Env: map[string]string{
+				"HTTPS_PROXY": "http://proxy.example.org:3128",
+				"NO_PROXY":    "10.0.0.0/8",
+			},
+			URL: "https://10.1.2.3/api/v1",
+		},
+		"no proxy port": {
+			Env: map[string]string{
+				"HTTPS_PROXY": "http://proxy.example.org:3128",
+				"NO_PROXY":    "wakapi.example.org:8443",
+			},
+			URL: "https://wakapi.example.org:8443/api",
+		},
+		"no proxy port mismatch": {
+			Env: map[string]string{
+				"HTTPS_PROXY": "http://proxy.example.org:3128",
+				"NO_PROXY":    "wakapi.example.org:8443",
+			},
+			URL:      "https://wakapi.example.org/api",
+			Expected: "http://proxy.example.org:3128",
+		},
+		"no proxy other domain": {
+			Env: map[string]string{
+				"HTTPS_PROXY": "http://proxy.example.org:3128",
+				"NO_PROXY":    "notwakatime.com,10.0.0.0/8",
+			},
+			URL:      "https://api.wakatime.com/api/v1",
+			Expected: "http://proxy.example.org:3128",
+		},
+		"no proxy all": {
+			Env: map[string]string{
+				"HTTPS_PROXY": "http://proxy.example.org:3128",
+				"NO_PROXY":    "*",
+			},
+			URL: "https://api.wakatime.com/api/v1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"} {
+				t.Setenv(key, test.Env[key])
+			}
+
+			req, err := http.NewRequest(http.MethodGet, test.URL, nil)
+			require.NoError(t, err)
+
+			u, err := api.ProxyFromEnvironment(req)
+			require.NoError(t, err)
+
+			if test.Expected == "" {
+				assert.Nil(t, u)
+				return
+			}
+
+			require.NotNil(t, u)
+			assert.Equal(t, test.Expected, u.String())
+		})
+	}
+}
+
+func TestClient_ProxyFromEnvironment(t *testing.T) {
+	proxyURL, proxied := setupTestProxy(t)
+
+	t.Setenv("HTTP_PROXY", proxyURL)
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.org", nil)
+	require.NoError(t, err)
+
+	c := api.NewClient("")
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, "example.org", <-proxied)
+}
+
+func TestOption_WithProxy_TakesPriorityOverEnvironment(t *testing.T) {
+	envProxyURL, envProxied := setupTestProxy(t)
+	proxyURL, proxied := setupTestProxy(t)
+
+	t.Setenv("HTTP_PROXY", envProxyURL)
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+
+	withProxy, err := api.WithProxy(proxyURL)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.org", nil)
+	require.NoError(t, err)
+
+	c := api.NewClient("", withProxy)
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, "example.org", <-proxied)
+	assert.Empty(t, envProxied)
+}
+
+func TestOption_WithNoProxy(t *testing.T) {
+	tests := map[string]struct {
+		NoProxy []string
+		Proxied bool
+	}{
+		"ip": {
+			NoProxy: []string{"127.0.0.1"},
+		},
+		"cidr": {
+			NoProxy: []string{"10.0.0.0/8", "127.0.0.0/8"},
+		},
+		"all": {
+			NoProxy: []string{"*"},
+		},
+		"other ip": {
+			NoProxy: []string{"127.0.0.2"},
+			Proxied: true,
+		},
+		"other port": {
+			NoProxy: []string{"127.0.0.1:1"},
+			Proxied: true,
+		},
+		"domain": {
+			NoProxy: []string{"wakatime.com"},
+			Proxied: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			proxyURL, proxied := setupTestProxy(t)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+			defer srv.Close()
+
+			withProxy, err := api.WithProxy(proxyURL)
+			require.NoError(t, err)
+
+			withNoProxy, err := api.WithNoProxy(test.NoProxy)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			require.NoError(t, err)
+
+			c := api.NewClient("", withNoProxy, withProxy)
+			resp, err := c.Do(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			if test.Proxied {
+				assert.Equal(t, srv.Listener.Addr().String(), <-proxied)
+				return
+			}
+
+			assert.Empty(t, proxied)
+		})
+	}
+}
+
+func TestOption_WithNoProxy_InvalidCIDR(t *testing.T) {
+	_, err := api.WithNoProxy([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	assert.Equal(t, `invalid no proxy CIDR "10.0.0.0/33": invalid CIDR address: 10.0.0.0/33`, err.Error())
+}
+
+// setupTestProxy starts a http proxy, which responds to every request itself
+// and sends the requested host to the returned channel.
+func setupTestProxy(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	proxied := make(chan string, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied <- req.Host
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv.URL, proxied
+}
@@ -1,10 +1,13 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/wakatime/wakatime-cli/pkg/summary"
 )
@@ -13,11 +16,12 @@ import (
 //
 // ErrRequest is returned upon request failure with no received response from api.
 // ErrAuth is returned upon receiving a 401 Unauthorized api response.
+// ErrRateLimit is returned upon receiving a 429 or 503 api response.
 // Err is returned on any other api response related error.
-func (c *Client) Today() (*summary.Summary, error) {
+func (c *Client) Today(ctx context.Context) (*summary.Summary, error) {
 	url := c.baseURL + "/users/current/statusbar/today"
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, Err(fmt.Sprintf("failed to create request: %s", err))
 	}
@@ -27,7 +31,7 @@ func (c *Client) Today() (*summary.Summary, error) {
 
 	resp, err := c.Do(req)
 	if err != nil {
-		return nil, Err(fmt.Sprintf("failed to make request to %q: %s", url, err))
+		return nil, newErrRequest(fmt.Sprintf("failed to make request to %q: %s", url, err), err)
 	}
 	defer resp.Body.Close()
 
@@ -43,8 +47,10 @@ func (c *Client) Today() (*summary.Summary, error) {
 		return nil, ErrAuth(fmt.Sprintf("authentication failed at %q. body: %q", url, string(body)))
 	case http.StatusBadRequest:
 		return nil, ErrBadRequest(fmt.Sprintf("bad request at %q", url))
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return nil, newErrRateLimit(resp, url)
 	default:
-		return nil, Err(fmt.Sprintf(
+		return nil, newErrStatus(resp.StatusCode, fmt.Sprintf(
 			"invalid response status from %q. got: %d, want: %d. body: %q",
 			url,
 			resp.StatusCode,
@@ -63,15 +69,24 @@ func (c *Client) Today() (*summary.Summary, error) {
 
 // ParseSummaryResponse parses the wakatime api response into summary.Summary.
 func ParseSummaryResponse(data []byte) (*summary.Summary, error) {
+	type item struct {
+		Name string `json:"name"`
+	}
+
 	var body struct {
 		Data struct {
 			Categories []struct {
-				Name string `json:"name"`
-				Text string `json:"text"`
+				Name         string  `json:"name"`
+				Percent      float64 `json:"percent"`
+				Text         string  `json:"text"`
+				TotalSeconds float64 `json:"total_seconds"`
 			} `json:"categories"`
 			GrandTotal struct {
-				Text string `json:"text"`
+				Text         string  `json:"text"`
+				TotalSeconds float64 `json:"total_seconds"`
 			} `json:"grand_total"`
+			Languages []item `json:"languages"`
+			Projects  []item `json:"projects"`
 		} `json:"data"`
 	}
 
@@ -80,17 +95,174 @@ func ParseSummaryResponse(data []byte) (*summary.Summary, error) {
 	}
 
 	parsed := summary.Summary{
-		Total: body.Data.GrandTotal.Text,
+		Total:   body.Data.GrandTotal.Text,
+		Seconds: body.Data.GrandTotal.TotalSeconds,
 	}
 
 	if len(body.Data.Categories) > 0 {
 		for _, category := range body.Data.Categories {
 			parsed.ByCategory = append(parsed.ByCategory, summary.Category{
 				Category: category.Name,
+				Percent:  category.Percent,
+				Seconds:  category.TotalSeconds,
 				Total:    category.Text,
 			})
 		}
 	}
 
+	// the api lists languages and projects by descending time
+	if len(body.Data.Languages) > 0 {
+		parsed.Language = body.Data.Languages[0].Name
+	}
+
+	if len(body.Data.Projects) > 0 {
+		parsed.Project = body.Data.Projects[0].Name
+	}
+
 	return &parsed, nil
 }
+
+// Summaries fetches code stats for the date range from start to end, including
+// both days.
+//
+// ErrRequest is returned upon request failure with no received response from api.
+// ErrAuth is returned upon receiving a 401 Unauthorized api response.
+// ErrRateLimit is returned upon receiving a 429 or 503 api response.
+// Err is returned on any other api response related error.
+func (c *Client) Summaries(ctx context.Context, start, end time.Time) (*summary.Range, error) {
+	url := c.baseURL + "/users/current/summaries"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, Err(fmt.Sprintf("failed to create request: %s", err))
+	}
+
+	q := req.URL.Query()
+	q.Add("start", start.Format(summary.DateFormat))
+	q.Add("end", end.Format(summary.DateFormat))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, newErrRequest(fmt.Sprintf("failed to make request to %q: %s", url, err), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, Err(fmt.Sprintf("failed to read response body from %q: %s", url, err))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		break
+	case http.StatusUnauthorized:
+		return nil, ErrAuth(fmt.Sprintf("authentication failed at %q. body: %q", url, string(body)))
+	case http.StatusBadRequest:
+		return nil, ErrBadRequest(fmt.Sprintf("bad request at %q. body: %q", url, string(body)))
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return nil, newErrRateLimit(resp, url)
+	default:
+		return nil, newErrStatus(resp.StatusCode, fmt.Sprintf(
+			"invalid response status from %q. got: %d, want: %d. body: %q",
+			url,
+			resp.StatusCode,
+			http.StatusOK,
+			string(body),
+		))
+	}
+
+	parsed, err := ParseSummariesResponse(body)
+	if err != nil {
+		return nil, Err(fmt.Sprintf("failed to parse results from %q: %s", url, err))
+	}
+
+	parsed.Start = start.Format(summary.DateFormat)
+	parsed.End = end.Format(summary.DateFormat)
+
+	return parsed, nil
+}
+
+// ParseSummariesResponse parses the wakatime api summaries response into
+// summary.Range, adding up the time of all days.
+func ParseSummariesResponse(data []byte) (*summary.Range, error) {
+	type item struct {
+		Name         string  `json:"name"`
+		TotalSeconds float64 `json:"total_seconds"`
+	}
+
+	var body struct {
+		Data []struct {
+			Branches   []item `json:"branches"`
+			Editors    []item `json:"editors"`
+			GrandTotal struct {
+				TotalSeconds float64 `json:"total_seconds"`
+			} `json:"grand_total"`
+			Languages []item `json:"languages"`
+			Machines  []item `json:"machines"`
+			Projects  []item `json:"projects"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse json response body: %s. body: %q", err, data)
+	}
+
+	var (
+		seconds   float64
+		branches  = make(map[string]float64)
+		editors   = make(map[string]float64)
+		languages = make(map[string]float64)
+		machines  = make(map[string]float64)
+		projects  = make(map[string]float64)
+	)
+
+	add := func(totals map[string]float64, items []item) {
+		for _, i := range items {
+			totals[i.Name] += i.TotalSeconds
+		}
+	}
+
+	for _, day := range body.Data {
+		seconds += day.GrandTotal.TotalSeconds
+
+		add(branches, day.Branches)
+		add(editors, day.Editors)
+		add(languages, day.Languages)
+		add(machines, day.Machines)
+		add(projects, day.Projects)
+	}
+
+	return &summary.Range{
+		Seconds:   seconds,
+		Text:      summary.FormatSeconds(seconds),
+		Projects:  summaryItems(projects),
+		Languages: summaryItems(languages),
+		Editors:   summaryItems(editors),
+		Branches:  summaryItems(branches),
+		Machines:  summaryItems(machines),
+	}, nil
+}
+
+// summaryItems converts the totals into items ordered by descending time and by name.
+func summaryItems(totals map[string]float64) []summary.Item {
+	items := make([]summary.Item, 0, len(totals))
+
+	for name, seconds := range totals {
+		items = append(items, summary.Item{
+			Name:    name,
+			Seconds: seconds,
+			Text:    summary.FormatSeconds(seconds),
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Seconds != items[j].Seconds {
+			return items[i].Seconds > items[j].Seconds
+		}
+
+		return items[i].Name < items[j].Name
+	})
+
+	return items
+}
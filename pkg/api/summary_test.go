@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -40,7 +41,7 @@ func TestClient_Summary(t *testing.T) {
 	})
 
 	c := api.NewClient(u)
-	s, err := c.Today()
+	s, err := c.Today(context.Background())
 
 	require.NoError(t, err)
 
@@ -69,7 +70,7 @@ func TestClient_SummaryByCategory(t *testing.T) {
 	})
 
 	c := api.NewClient(u)
-	s, err := c.Today()
+	s, err := c.Today(context.Background())
 	require.NoError(t, err)
 
 	assert.Equal(t, s, &summary.Summary{
@@ -105,7 +106,7 @@ func TestClient_SummaryWithTimeout(t *testing.T) {
 
 	opts := []api.Option{api.WithTimeout(20 * time.Millisecond)}
 	c := api.NewClient(u, opts...)
-	_, err := c.Today()
+	_, err := c.Today(context.Background())
 	require.Error(t, err)
 
 	errMsg := fmt.Sprintf("error %q does not contain string 'Timeout'", err)
@@ -132,7 +133,7 @@ func TestClient_Summary_Err(t *testing.T) {
 	})
 
 	c := api.NewClient(u)
-	_, err := c.Today()
+	_, err := c.Today(context.Background())
 
 	var apierr api.Err
 
@@ -152,7 +153,7 @@ func TestClient_Summary_ErrAuth(t *testing.T) {
 	})
 
 	c := api.NewClient(u)
-	_, err := c.Today()
+	_, err := c.Today(context.Background())
 
 	var errauth api.ErrAuth
 
@@ -172,7 +173,7 @@ func TestClient_Summary_ErrBadRequest(t *testing.T) {
 	})
 
 	c := api.NewClient(u)
-	_, err := c.Today()
+	_, err := c.Today(context.Background())
 
 	var errbadRequest api.ErrBadRequest
 
@@ -180,15 +181,113 @@ func TestClient_Summary_ErrBadRequest(t *testing.T) {
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
+func TestClient_Summary_ErrRateLimit(t *testing.T) {
+	u, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/statusbar/today", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+		w.Header().Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	c := api.NewClient(u)
+	_, err := c.Today(context.Background())
+
+	var errratelimit api.ErrRateLimit
+
+	require.True(t, errors.As(err, &errratelimit))
+
+	assert.InDelta(t, time.Hour.Seconds(), errratelimit.RetryAfter.Seconds(), 2)
+	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
+}
+
 func TestClient_Summary_InvalidUrl(t *testing.T) {
 	c := api.NewClient("invalid-url")
-	_, err := c.Today()
+	_, err := c.Today(context.Background())
 
 	var apierr api.Err
 
 	assert.True(t, errors.As(err, &apierr))
 }
 
+func TestClient_Summaries(t *testing.T) {
+	u, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	var numCalls int
+
+	router.HandleFunc("/users/current/summaries", func(w http.ResponseWriter, req *http.Request) {
+		numCalls++
+
+		// check request
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, []string{"application/json"}, req.Header["Accept"])
+		assert.Equal(t, "2021-09-01", req.URL.Query().Get("start"))
+		assert.Equal(t, "2021-09-02", req.URL.Query().Get("end"))
+
+		// write response
+		f, err := os.Open("testdata/api_summaries_response.json")
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusOK)
+		_, err = io.Copy(w, f)
+		require.NoError(t, err)
+	})
+
+	c := api.NewClient(u)
+	r, err := c.Summaries(
+		context.Background(),
+		time.Date(2021, 9, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2021, 9, 2, 0, 0, 0, 0, time.Local),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, &summary.Range{
+		Start:   "2021-09-01",
+		End:     "2021-09-02",
+		Seconds: 8100.5,
+		Text:    "2 hrs 15 mins",
+		Projects: []summary.Item{
+			{Name: "wakatime-cli", Seconds: 6300.5, Text: "1 hr 45 mins"},
+			{Name: "wakatime", Seconds: 1800, Text: "30 mins"},
+		},
+		Languages: []summary.Item{
+			{Name: "Go", Seconds: 6300, Text: "1 hr 45 mins"},
+			{Name: "Markdown", Seconds: 1800.5, Text: "30 mins"},
+		},
+		Editors: []summary.Item{
+			{Name: "VS Code", Seconds: 6300.5, Text: "1 hr 45 mins"},
+			{Name: "Vim", Seconds: 1800, Text: "30 mins"},
+		},
+		Branches: []summary.Item{},
+		Machines: []summary.Item{
+			{Name: "laptop", Seconds: 5400.5, Text: "1 hr 30 mins"},
+			{Name: "desktop", Seconds: 2700, Text: "45 mins"},
+		},
+	}, r)
+
+	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
+}
+
+func TestClient_Summaries_ErrAuth(t *testing.T) {
+	u, router, tearDown := setupTestServer()
+	defer tearDown()
+
+	router.HandleFunc("/users/current/summaries", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	c := api.NewClient(u)
+	_, err := c.Summaries(context.Background(), time.Now(), time.Now())
+
+	var errauth api.ErrAuth
+
+	assert.True(t, errors.As(err, &errauth))
+}
+
 func TestParseSummaryResponse_DayTotal(t *testing.T) {
 	data, err := os.ReadFile("testdata/api_statusbar_today_response.json")
 	require.NoError(t, err)
@@ -222,3 +321,32 @@ func TestParseSummaryResponse_TotalsByCategory(t *testing.T) {
 		},
 	})
 }
+
+func TestParseSummaryResponse_Full(t *testing.T) {
+	data, err := os.ReadFile("testdata/api_statusbar_today_full_response.json")
+	require.NoError(t, err)
+
+	s, err := api.ParseSummaryResponse(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, s, &summary.Summary{
+		Total:   "1 hr 59 mins",
+		Seconds: 7152,
+		ByCategory: []summary.Category{
+			{
+				Category: "Coding",
+				Percent:  75.5,
+				Seconds:  5400,
+				Total:    "1 hr 30 mins",
+			},
+			{
+				Category: "Debugging",
+				Percent:  24.5,
+				Seconds:  1752,
+				Total:    "29 mins",
+			},
+		},
+		Language: "Go",
+		Project:  "wakatime-cli",
+	})
+}
@@ -6,12 +6,15 @@ import (
 	"time"
 
 	"github.com/wakatime/wakatime-cli/pkg/log"
+
+	"github.com/Azure/go-ntlmssp"
 )
 
-// NewTransport initializes a new http.Transport.
+// NewTransport initializes a new http.Transport. Requests are proxied following
+// the standard proxy environment variables.
 func NewTransport() *http.Transport {
 	return &http.Transport{
-		Proxy:               nil,
+		Proxy:               ProxyFromEnvironment,
 		TLSHandshakeTimeout: DefaultTimeoutSecs * time.Second,
 		MaxIdleConns:        1,
 		MaxIdleConnsPerHost: 1,
@@ -21,14 +24,38 @@ func NewTransport() *http.Transport {
 }
 
 // LazyCreateNewTransport uses the client's Transport if exists, or creates a new one.
+// A Transport wrapped for ntlm authentication is unwrapped.
 func LazyCreateNewTransport(c *Client) *http.Transport {
-	if c != nil && c.client != nil && c.client.Transport != nil {
-		return c.client.Transport.(*http.Transport).Clone()
+	if c == nil || c.client == nil || c.client.Transport == nil {
+		return NewTransport()
+	}
+
+	switch t := c.client.Transport.(type) {
+	case *http.Transport:
+		return t.Clone()
+	case ntlmssp.Negotiator:
+		if transport, ok := t.RoundTripper.(*http.Transport); ok {
+			return transport.Clone()
+		}
 	}
 
 	return NewTransport()
 }
 
+// setTransport sets the client's Transport, keeping ntlm authentication in
+// place, if enabled before.
+func setTransport(c *Client, transport *http.Transport) {
+	if _, ok := c.client.Transport.(ntlmssp.Negotiator); ok {
+		c.client.Transport = ntlmssp.Negotiator{
+			RoundTripper: transport,
+		}
+
+		return
+	}
+
+	c.client.Transport = transport
+}
+
 const letsencryptCerts string = `
 -----BEGIN CERTIFICATE-----
 MIIEYDCCAkigAwIBAgIQB55JKIY3b9QISMI/xjHkYzANBgkqhkiG9w0BAQsFADBP
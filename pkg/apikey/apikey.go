@@ -1,6 +1,9 @@
 package apikey
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
 	"github.com/wakatime/wakatime-cli/pkg/log"
 	"github.com/wakatime/wakatime-cli/pkg/regex"
@@ -27,23 +30,38 @@ type MapPattern struct {
 // for a heartbeat following the provided configurations.
 func WithReplacing(config Config) heartbeat.HandleOption {
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			log.Debugln("execute api key replacing")
 
 			for n, h := range hh {
-				result, ok := MatchPattern(h.Entity, config.MapPatterns)
-				if ok {
-					hh[n].ApiKey = result
-				} else {
-					hh[n].ApiKey = config.DefaultApiKey
-				}
+				hh[n].ApiKey = Resolve(h.Entity, config)
 			}
 
-			return next(hh)
+			return next(ctx, hh)
 		}
 	}
 }
 
+// Resolve returns the api key used for the passed in entity. Falls back to
+// the default api key, if no pattern matches.
+func Resolve(entity string, config Config) string {
+	if result, ok := MatchPattern(entity, config.MapPatterns); ok {
+		return result
+	}
+
+	return config.DefaultApiKey
+}
+
+// Mask hides all but the last 4 characters of an api key, so it can
+// be printed or logged.
+func Mask(apiKey string) string {
+	if len(apiKey) <= 4 {
+		return apiKey
+	}
+
+	return fmt.Sprintf("<hidden>%s", apiKey[len(apiKey)-4:])
+}
+
 // MatchPattern matches regex against entity's path to find alternate api key.
 func MatchPattern(fp string, patterns []MapPattern) (string, bool) {
 	for _, pattern := range patterns {
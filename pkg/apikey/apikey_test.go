@@ -1,6 +1,7 @@
 package apikey_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -36,7 +37,7 @@ func TestWithReplacing(t *testing.T) {
 	}
 
 	opt := apikey.WithReplacing(config)
-	h := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	h := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				ApiKey: "00000000-0000-4000-8000-000000000000",
@@ -55,7 +56,7 @@ func TestWithReplacing(t *testing.T) {
 		}, nil
 	})
 
-	result, err := h([]heartbeat.Heartbeat{first, second})
+	result, err := h(context.Background(), []heartbeat.Heartbeat{first, second})
 	require.NoError(t, err)
 
 	assert.Equal(t, []heartbeat.Result{
@@ -118,6 +119,27 @@ func TestApiKey_MatchPattern_ZeroPatterns(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestResolve(t *testing.T) {
+	config := apikey.Config{
+		DefaultApiKey: "00000000-0000-4000-8000-000000000000",
+		MapPatterns: []apikey.MapPattern{
+			{
+				ApiKey: "00000000-0000-4000-8000-000000000001",
+				Regex:  regexp.MustCompile(`.workdir.`),
+			},
+		},
+	}
+
+	assert.Equal(t, "00000000-0000-4000-8000-000000000000", apikey.Resolve("/tmp/main.go", config))
+	assert.Equal(t, "00000000-0000-4000-8000-000000000001", apikey.Resolve("/workdir/main.go", config))
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "<hidden>0001", apikey.Mask("00000000-0000-4000-8000-000000000001"))
+	assert.Equal(t, "abc", apikey.Mask("abc"))
+	assert.Equal(t, "", apikey.Mask(""))
+}
+
 func formatRegex(fp string) string {
 	if runtime.GOOS != "windows" {
 		return fp
@@ -1,6 +1,8 @@
 package backoff
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
@@ -27,37 +29,64 @@ type Config struct {
 	At time.Time
 	// Retries is the number of attempts to connect.
 	Retries int
+	// Section is the config section the backoff state is stored in.
+	// Defaults to internal.
+	Section string
+	// Until is the time until which the api asked to not send any requests,
+	// as signaled via Retry-After header.
+	Until time.Time
 	// V is an instance of Viper.
 	V *viper.Viper
 }
 
 // WithBackoff initializes and returns a heartbeat handle option, which
 // can be used in a heartbeat processing pipeline to prevent trying to send
-// a heartbeat when the api is unresponsive.
+// a heartbeat when the api is unresponsive. If the api signaled a deadline via
+// Retry-After header, only the deadline is recorded instead.
 func WithBackoff(config Config) heartbeat.HandleOption {
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			log.Debugln("execute heartbeat backoff algorithm")
 
+			if err := checkRateLimit(config.Until); err != nil {
+				return nil, err
+			}
+
 			if shouldBackoff(config.Retries, config.At) {
 				return nil, api.Err("won't send heartbeat due to backoff")
 			}
 
-			results, err := next(hh)
+			results, err := next(ctx, hh)
 			if err != nil {
+				// rate limited with Retry-After, wait as long as the api asked for
+				// instead of incrementing the exponential backoff
+				if until := rateLimitedUntil(err, time.Now()); !until.IsZero() {
+					if updateErr := updateRateLimitSettings(config.V, config.section(), until); updateErr != nil {
+						log.Warnf("failed to update rate limit settings: %s", updateErr)
+					}
+
+					return nil, err
+				}
+
 				log.Debugf("incrementing backoff due to error")
 
 				// error response, increment backoff
-				if updateErr := updateBackoffSettings(config.V, config.Retries+1, time.Now()); updateErr != nil {
+				if updateErr := updateBackoffSettings(
+					config.V,
+					config.section(),
+					config.Retries+1,
+					time.Now(),
+					time.Time{},
+				); updateErr != nil {
 					log.Warnf("failed to update backoff settings: %s", updateErr)
 				}
 
 				return nil, err
 			}
 
-			if !config.At.IsZero() {
+			if !config.At.IsZero() || !config.Until.IsZero() {
 				// success response, reset backoff
-				if resetErr := updateBackoffSettings(config.V, 0, time.Time{}); resetErr != nil {
+				if resetErr := updateBackoffSettings(config.V, config.section(), 0, time.Time{}, time.Time{}); resetErr != nil {
 					log.Warnf("failed to reset backoff settings: %s", resetErr)
 				}
 			}
@@ -67,6 +96,67 @@ func WithBackoff(config Config) heartbeat.HandleOption {
 	}
 }
 
+// WithRateLimit initializes and returns a heartbeat handle option, which
+// only respects and records the deadline signaled by the api via Retry-After
+// header, leaving the exponential backoff untouched.
+func WithRateLimit(config Config) heartbeat.HandleOption {
+	return func(next heartbeat.Handle) heartbeat.Handle {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+			if err := checkRateLimit(config.Until); err != nil {
+				return nil, err
+			}
+
+			results, err := next(ctx, hh)
+			if err != nil {
+				if until := rateLimitedUntil(err, time.Now()); !until.IsZero() {
+					if updateErr := updateRateLimitSettings(config.V, config.section(), until); updateErr != nil {
+						log.Warnf("failed to update rate limit settings: %s", updateErr)
+					}
+				}
+
+				return nil, err
+			}
+
+			return results, nil
+		}
+	}
+}
+
+func (c Config) section() string {
+	if c.Section == "" {
+		return "internal"
+	}
+
+	return c.Section
+}
+
+// checkRateLimit returns api.ErrRateLimit, if the deadline signaled by the
+// api has not passed yet.
+func checkRateLimit(until time.Time) error {
+	wait := time.Until(until)
+	if wait <= 0 {
+		return nil
+	}
+
+	log.Debugf("api rate limit in effect, will retry at %s", until.Format(time.Stamp))
+
+	return api.ErrRateLimit{
+		Msg:        "won't send heartbeat due to rate limit",
+		RetryAfter: wait.Round(time.Second),
+	}
+}
+
+// rateLimitedUntil returns the deadline signaled by the api, if err is an
+// api.ErrRateLimit carrying a Retry-After value. Returns zero time otherwise.
+func rateLimitedUntil(err error, now time.Time) time.Time {
+	var errratelimit api.ErrRateLimit
+	if !errors.As(err, &errratelimit) || errratelimit.RetryAfter <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(errratelimit.RetryAfter)
+}
+
 func shouldBackoff(retries int, at time.Time) bool {
 	if retries < 1 || at.IsZero() {
 		return false
@@ -85,7 +175,7 @@ func shouldBackoff(retries int, at time.Time) bool {
 	return now.Before(at.Add(duration)) && now.Before(at.Add(resetAfter*time.Second))
 }
 
-func updateBackoffSettings(v *viper.Viper, retries int, at time.Time) error {
+func updateBackoffSettings(v *viper.Viper, section string, retries int, at, until time.Time) error {
 	w, err := ini.NewIniWriter(v, ini.FilePath)
 	if err != nil {
 		return fmt.Errorf("failed to parse config file: %s", err)
@@ -94,15 +184,31 @@ func updateBackoffSettings(v *viper.Viper, retries int, at time.Time) error {
 	keyValue := map[string]string{
 		"backoff_retries": strconv.Itoa(retries),
 		"backoff_at":      "",
+		"backoff_until":   "",
 	}
 
 	if !at.IsZero() {
 		keyValue["backoff_at"] = at.Format(ini.DateFormat)
-	} else {
-		keyValue["backoff_at"] = ""
 	}
 
-	if err := w.Write("internal", keyValue); err != nil {
+	if !until.IsZero() {
+		keyValue["backoff_until"] = until.Format(ini.DateFormat)
+	}
+
+	if err := w.Write(section, keyValue); err != nil {
+		return fmt.Errorf("failed to write to internal config file: %s", err)
+	}
+
+	return nil
+}
+
+func updateRateLimitSettings(v *viper.Viper, section string, until time.Time) error {
+	w, err := ini.NewIniWriter(v, ini.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file: %s", err)
+	}
+
+	if err := w.Write(section, map[string]string{"backoff_until": until.Format(ini.DateFormat)}); err != nil {
 		return fmt.Errorf("failed to write to internal config file: %s", err)
 	}
 
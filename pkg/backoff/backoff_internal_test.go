@@ -47,7 +47,7 @@ func TestUpdateBackoffSettings(t *testing.T) {
 
 	at := time.Now().Add(time.Second * -1)
 
-	err = updateBackoffSettings(v, 2, at)
+	err = updateBackoffSettings(v, "internal", 2, at, time.Time{})
 	require.NoError(t, err)
 
 	writer, err := ini.NewIniWriter(v, func(vp *viper.Viper) (string, error) {
@@ -73,7 +73,7 @@ func TestUpdateBackoffSettings_NotInBackoff(t *testing.T) {
 	v.Set("config", tmpFile.Name())
 	v.Set("internal-config", tmpFile.Name())
 
-	err = updateBackoffSettings(v, 0, time.Time{})
+	err = updateBackoffSettings(v, "internal", 0, time.Time{}, time.Time{})
 	require.NoError(t, err)
 
 	writer, err := ini.NewIniWriter(v, func(vp *viper.Viper) (string, error) {
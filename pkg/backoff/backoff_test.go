@@ -1,17 +1,21 @@
 package backoff_test
 
 import (
+	"context"
 	"errors"
 	"os"
 	"testing"
 	"time"
 
-	"github.com/spf13/viper"
+	"github.com/wakatime/wakatime-cli/pkg/api"
 	"github.com/wakatime/wakatime-cli/pkg/backoff"
 	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	inipkg "github.com/wakatime/wakatime-cli/pkg/ini"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
 )
 
 func TestWithRetry(t *testing.T) {
@@ -28,7 +32,7 @@ func TestWithRetry(t *testing.T) {
 		V: v,
 	})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		return []heartbeat.Result{
 			{
 				Status: 201,
@@ -36,7 +40,7 @@ func TestWithRetry(t *testing.T) {
 		}, nil
 	})
 
-	_, err = handle([]heartbeat.Heartbeat{})
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{})
 	require.NoError(t, err)
 }
 
@@ -48,7 +52,7 @@ func TestWithRetry_BeforeNextBackoff(t *testing.T) {
 		Retries: 1,
 	})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		return []heartbeat.Result{
 			{
 				Status: 201,
@@ -56,7 +60,7 @@ func TestWithRetry_BeforeNextBackoff(t *testing.T) {
 		}, nil
 	})
 
-	_, err := handle([]heartbeat.Heartbeat{})
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{})
 	require.Error(t, err)
 
 	assert.Equal(t, "won't send heartbeat due to backoff", err.Error())
@@ -76,12 +80,129 @@ func TestWithRetry_ApiError(t *testing.T) {
 		V: v,
 	})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		return []heartbeat.Result{}, errors.New("error")
 	})
 
-	_, err = handle([]heartbeat.Heartbeat{})
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{})
 	require.Error(t, err)
 
 	assert.Equal(t, "error", err.Error())
 }
+
+func TestWithRetry_ApiError_Section(t *testing.T) {
+	v := viper.New()
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v.Set("config", tmpFile.Name())
+
+	opt := backoff.WithBackoff(backoff.Config{
+		V:       v,
+		Section: "internal_destination_wakapi",
+	})
+
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return []heartbeat.Result{}, errors.New("error")
+	})
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{})
+	require.Error(t, err)
+
+	cfg, err := ini.Load(tmpFile.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", cfg.Section("internal_destination_wakapi").Key("backoff_retries").String())
+	assert.False(t, cfg.HasSection("internal"))
+}
+
+func TestWithRetry_BeforeRateLimitDeadline(t *testing.T) {
+	opt := backoff.WithBackoff(backoff.Config{
+		Until: time.Now().Add(time.Minute),
+	})
+
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		t.Fatal("must not send heartbeats before rate limit deadline")
+
+		return nil, nil
+	})
+
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{})
+	require.Error(t, err)
+
+	var errratelimit api.ErrRateLimit
+
+	require.True(t, errors.As(err, &errratelimit))
+
+	assert.InDelta(t, time.Minute.Seconds(), errratelimit.RetryAfter.Seconds(), 1)
+}
+
+func TestWithRetry_ApiRateLimit(t *testing.T) {
+	v := viper.New()
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v.Set("config", tmpFile.Name())
+	v.Set("internal-config", tmpFile.Name())
+
+	opt := backoff.WithBackoff(backoff.Config{
+		V: v,
+	})
+
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return nil, api.ErrRateLimit{Msg: "rate limited", RetryAfter: 10 * time.Minute}
+	})
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{})
+	require.Error(t, err)
+
+	cfg, err := ini.Load(tmpFile.Name())
+	require.NoError(t, err)
+
+	backoffUntil, err := time.Parse(inipkg.DateFormat, cfg.Section("internal").Key("backoff_until").String())
+	require.NoError(t, err)
+
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), backoffUntil, 5*time.Second)
+
+	// exponential backoff is left untouched
+	assert.Empty(t, cfg.Section("internal").Key("backoff_retries").String())
+	assert.Empty(t, cfg.Section("internal").Key("backoff_at").String())
+}
+
+func TestWithRateLimit(t *testing.T) {
+	v := viper.New()
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v.Set("config", tmpFile.Name())
+	v.Set("internal-config", tmpFile.Name())
+
+	opt := backoff.WithRateLimit(backoff.Config{
+		V: v,
+	})
+
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return nil, api.ErrRateLimit{Msg: "rate limited", RetryAfter: 30 * time.Second}
+	})
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{})
+	require.Error(t, err)
+
+	cfg, err := ini.Load(tmpFile.Name())
+	require.NoError(t, err)
+
+	backoffUntil, err := time.Parse(inipkg.DateFormat, cfg.Section("internal").Key("backoff_until").String())
+	require.NoError(t, err)
+
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), backoffUntil, 5*time.Second)
+	assert.False(t, cfg.Section("internal").HasKey("backoff_retries"))
+}
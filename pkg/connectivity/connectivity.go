@@ -0,0 +1,231 @@
+package connectivity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+const (
+	// DefaultTTL is the default duration the result of a probe is cached for.
+	DefaultTTL = 60 * time.Second
+	// ProbeTimeout is the maximum duration to wait for the api to respond to a probe.
+	ProbeTimeout = 3 * time.Second
+	// stateFilename is the name of the file probe results are recorded in.
+	stateFilename = ".wakatime-connectivity.json"
+)
+
+// Prober checks, whether the api is reachable.
+type Prober interface {
+	Probe(ctx context.Context) error
+}
+
+// Config contains connectivity check configurations.
+type Config struct {
+	// Filepath is the path of the state file probe results are recorded in.
+	Filepath string
+	// Prober probes the api.
+	Prober Prober
+	// TTL is the duration the result of a probe is cached for.
+	TTL time.Duration
+	// URL is the api url. Probe results are recorded per host.
+	URL string
+}
+
+// result is the recorded result of a probe of a single host.
+type result struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Reachable bool      `json:"reachable"`
+}
+
+// StateFilepath returns the path of the state file, which is located next to
+// the passed in offline queue file.
+func StateFilepath(queueFilepath string) string {
+	return filepath.Join(filepath.Dir(queueFilepath), stateFilename)
+}
+
+// WithCheck initializes and returns a heartbeat handle option, which can be
+// used in a heartbeat processing pipeline to prevent waiting for requests to
+// an unreachable api. The reachability of the api host is recorded from the
+// outcome of each request. If it is unknown or the host was unreachable, the
+// host is probed with a short timeout first. While the host is known to be
+// unreachable for the configured ttl, an api.ErrConnection is returned without
+// sending heartbeats.
+func WithCheck(config Config) heartbeat.HandleOption {
+	return func(next heartbeat.Handle) heartbeat.Handle {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+			log.Debugln("execute connectivity check")
+
+			u, err := url.Parse(config.URL)
+			if err != nil || u.Host == "" {
+				log.Warnf("skipping connectivity check due to invalid api url %q", config.URL)
+
+				return next(ctx, hh)
+			}
+
+			if err := check(ctx, config, u.Host); err != nil {
+				return nil, err
+			}
+
+			results, err := next(ctx, hh)
+
+			switch {
+			case err == nil:
+				record(config.Filepath, u.Host, true)
+			case ctx.Err() == nil && isUnreachable(err):
+				record(config.Filepath, u.Host, false)
+			}
+
+			return results, err
+		}
+	}
+}
+
+// check returns an error, if the host is known to be unreachable or cannot be
+// reached by the probe. The host is only probed, if its reachability is unknown or it was
+// unreachable and the cached result has expired. A host known to be reachable
+// is not probed, as the outcome of the actual request refreshes its state.
+func check(ctx context.Context, config Config, host string) error {
+	state, err := readState(config.Filepath)
+	if err != nil {
+		log.Warnf("failed to read connectivity state: %s", err)
+	}
+
+	if r, ok := state[host]; ok {
+		if r.Reachable {
+			return nil
+		}
+
+		if age := time.Since(r.CheckedAt); age >= 0 && age < config.TTL {
+			log.Debugf(
+				"api host %q unreachable as of %s, will probe again at %s",
+				host,
+				r.CheckedAt.Format(time.Stamp),
+				r.CheckedAt.Add(config.TTL).Format(time.Stamp),
+			)
+
+			return api.ErrConnection(fmt.Sprintf("won't send heartbeat(s), api host %q is unreachable", host))
+		}
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+
+	err = config.Prober.Probe(probeCtx)
+	if err == nil {
+		record(config.Filepath, host, true)
+
+		return nil
+	}
+
+	if !isUnreachable(err) {
+		// the actual request returns the classified error, if it fails as well
+		log.Debugf("api host %q reachable, but failed probe: %s", host, err)
+
+		return nil
+	}
+
+	// the run deadline passing does not tell anything about the api host
+	if ctx.Err() == nil {
+		record(config.Filepath, host, false)
+	}
+
+	log.Debugf("api host %q failed to respond to probe: %s", host, err)
+
+	return err
+}
+
+// isUnreachable reports, whether err indicates, that the api host could not
+// be reached at all.
+func isUnreachable(err error) bool {
+	var (
+		errconn    api.ErrConnection
+		errtimeout api.ErrTimeout
+	)
+
+	return errors.As(err, &errconn) || errors.As(err, &errtimeout)
+}
+
+// record records the reachability of host in the state file.
+func record(fp, host string, reachable bool) {
+	state, err := readState(fp)
+	if err != nil {
+		log.Warnf("failed to read connectivity state: %s", err)
+	}
+
+	if state == nil {
+		state = map[string]result{}
+	}
+
+	state[host] = result{
+		CheckedAt: time.Now(),
+		Reachable: reachable,
+	}
+
+	if err := writeState(fp, state); err != nil {
+		log.Warnf("failed to write connectivity state: %s", err)
+	}
+}
+
+func readState(fp string) (map[string]result, error) {
+	data, err := os.ReadFile(fp)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read file %q: %s", fp, err)
+	}
+
+	var state map[string]result
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse file %q: %s", fp, err)
+	}
+
+	return state, nil
+}
+
+// writeState writes the state to a temporary file first, to never leave a
+// partially written state file behind for concurrent runs.
+func writeState(fp string, state map[string]result) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %s", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(fp), stateFilename+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %s", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("failed to write temporary file: %s", err)
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("failed to close temporary file: %s", err)
+	}
+
+	if err := os.Rename(f.Name(), fp); err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("failed to replace file %q: %s", fp, err)
+	}
+
+	return nil
+}
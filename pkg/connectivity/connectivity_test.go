@@ -0,0 +1,288 @@
+package connectivity_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/connectivity"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCheck(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "connectivity.json")
+
+	prober := &mockProber{}
+
+	handle := connectivity.WithCheck(connectivity.Config{
+		Filepath: fp,
+		Prober:   prober,
+		TTL:      time.Minute,
+		URL:      "https://api.wakatime.com/api/v1",
+	})(createdHandle)
+
+	// first run probes the api host, second run uses the cached result
+	for i := 0; i < 2; i++ {
+		results, err := handle(context.Background(), []heartbeat.Heartbeat{{}})
+		require.NoError(t, err)
+
+		assert.Len(t, results, 1)
+	}
+
+	assert.Equal(t, 1, prober.NumCalls)
+}
+
+func TestWithCheck_Unreachable(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "connectivity.json")
+
+	prober := &mockProber{Err: api.ErrConnection("connection refused")}
+
+	var numCalls int
+
+	handle := connectivity.WithCheck(connectivity.Config{
+		Filepath: fp,
+		Prober:   prober,
+		TTL:      time.Minute,
+		URL:      "https://api.wakatime.com/api/v1",
+	})(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		numCalls++
+		return nil, nil
+	})
+
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{{}})
+	require.Error(t, err)
+
+	assert.Equal(t, "connection refused", err.Error())
+
+	// api host is known to be unreachable, so no further probe is sent
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{{}})
+	require.Error(t, err)
+
+	var errconn api.ErrConnection
+
+	assert.True(t, errors.As(err, &errconn))
+	assert.Equal(t, `won't send heartbeat(s), api host "api.wakatime.com" is unreachable`, err.Error())
+	assert.Equal(t, 1, prober.NumCalls)
+	assert.Zero(t, numCalls)
+}
+
+func TestWithCheck_Expired(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "connectivity.json")
+
+	checkedAt := time.Now().Add(-2 * time.Minute).Format(time.RFC3339)
+
+	err := os.WriteFile(
+		fp,
+		[]byte(`{"api.wakatime.com":{"checked_at":"`+checkedAt+`","reachable":false}}`),
+		0600,
+	)
+	require.NoError(t, err)
+
+	prober := &mockProber{}
+
+	handle := connectivity.WithCheck(connectivity.Config{
+		Filepath: fp,
+		Prober:   prober,
+		TTL:      time.Minute,
+		URL:      "https://api.wakatime.com/api/v1",
+	})(createdHandle)
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{{}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, prober.NumCalls)
+
+	data, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), `"reachable":true`)
+}
+
+func TestWithCheck_ReachableExpired(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "connectivity.json")
+
+	checkedAt := time.Now().Add(-2 * time.Minute).Format(time.RFC3339)
+
+	err := os.WriteFile(
+		fp,
+		[]byte(`{"api.wakatime.com":{"checked_at":"`+checkedAt+`","reachable":true}}`),
+		0600,
+	)
+	require.NoError(t, err)
+
+	prober := &mockProber{}
+
+	handle := connectivity.WithCheck(connectivity.Config{
+		Filepath: fp,
+		Prober:   prober,
+		TTL:      time.Minute,
+		URL:      "https://api.wakatime.com/api/v1",
+	})(createdHandle)
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{{}})
+	require.NoError(t, err)
+
+	// a reachable host is not probed, but refreshed by the sent request
+	assert.Zero(t, prober.NumCalls)
+
+	data, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(data), checkedAt)
+	assert.Contains(t, string(data), `"reachable":true`)
+}
+
+func TestWithCheck_ProbeErr(t *testing.T) {
+	tests := map[string]error{
+		"api":        api.Err("unexpected response"),
+		"tls":        api.ErrTLS("certificate signed by unknown authority"),
+		"proxy auth": api.ErrProxyAuth("Proxy Authentication Required"),
+	}
+
+	for name, probeErr := range tests {
+		t.Run(name, func(t *testing.T) {
+			fp := filepath.Join(t.TempDir(), "connectivity.json")
+
+			prober := &mockProber{Err: probeErr}
+
+			var numCalls int
+
+			handle := connectivity.WithCheck(connectivity.Config{
+				Filepath: fp,
+				Prober:   prober,
+				TTL:      time.Minute,
+				URL:      "https://api.wakatime.com/api/v1",
+			})(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+				numCalls++
+				return nil, probeErr
+			})
+
+			// the host is not recorded as unreachable and heartbeats are still sent
+			for i := 0; i < 2; i++ {
+				_, err := handle(context.Background(), []heartbeat.Heartbeat{{}})
+				require.Error(t, err)
+
+				assert.Equal(t, probeErr, err)
+			}
+
+			assert.Equal(t, 2, numCalls)
+			assert.Equal(t, 2, prober.NumCalls)
+			assert.NoFileExists(t, fp)
+		})
+	}
+}
+
+func TestWithCheck_OtherHost(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "connectivity.json")
+
+	checkedAt := time.Now().Format(time.RFC3339)
+
+	err := os.WriteFile(
+		fp,
+		[]byte(`{"wakapi.example.org":{"checked_at":"`+checkedAt+`","reachable":false}}`),
+		0600,
+	)
+	require.NoError(t, err)
+
+	prober := &mockProber{}
+
+	handle := connectivity.WithCheck(connectivity.Config{
+		Filepath: fp,
+		Prober:   prober,
+		TTL:      time.Minute,
+		URL:      "https://api.wakatime.com/api/v1",
+	})(createdHandle)
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{{}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, prober.NumCalls)
+
+	data, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), `"wakapi.example.org":{"checked_at":"`)
+}
+
+func TestWithCheck_RecordsFailedRequest(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "connectivity.json")
+
+	prober := &mockProber{}
+
+	handle := connectivity.WithCheck(connectivity.Config{
+		Filepath: fp,
+		Prober:   prober,
+		TTL:      time.Minute,
+		URL:      "https://api.wakatime.com/api/v1",
+	})(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return nil, api.ErrTimeout("timeout")
+	})
+
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{{}})
+	require.Error(t, err)
+
+	assert.Equal(t, "timeout", err.Error())
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{{}})
+	require.Error(t, err)
+
+	var errconn api.ErrConnection
+
+	assert.True(t, errors.As(err, &errconn))
+	assert.Equal(t, 1, prober.NumCalls)
+}
+
+func TestWithCheck_ContextDone(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "connectivity.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	prober := &mockProber{Err: api.ErrTimeout("context canceled")}
+
+	handle := connectivity.WithCheck(connectivity.Config{
+		Filepath: fp,
+		Prober:   prober,
+		TTL:      time.Minute,
+		URL:      "https://api.wakatime.com/api/v1",
+	})(createdHandle)
+
+	_, err := handle(ctx, []heartbeat.Heartbeat{{}})
+	require.Error(t, err)
+
+	// the result of an aborted probe is not recorded
+	assert.NoFileExists(t, fp)
+}
+
+func TestStateFilepath(t *testing.T) {
+	fp := connectivity.StateFilepath(filepath.Join("path", "to", ".wakatime.bdb"))
+
+	assert.Equal(t, filepath.Join("path", "to", ".wakatime-connectivity.json"), fp)
+}
+
+type mockProber struct {
+	Err      error
+	NumCalls int
+}
+
+func (m *mockProber) Probe(_ context.Context) error {
+	m.NumCalls++
+	return m.Err
+}
+
+func createdHandle(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	results := make([]heartbeat.Result, len(hh))
+
+	for i, h := range hh {
+		results[i] = heartbeat.Result{Status: 201, Heartbeat: h}
+	}
+
+	return results, nil
+}
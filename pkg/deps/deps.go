@@ -1,6 +1,7 @@
 package deps
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
@@ -34,7 +35,7 @@ type DependencyParser interface {
 // local file if available.
 func WithDetection(c Config) heartbeat.HandleOption {
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			log.Debugln("execute dependency detection")
 
 			for n, h := range hh {
@@ -74,7 +75,7 @@ func WithDetection(c Config) heartbeat.HandleOption {
 				hh[n].Dependencies = dependencies
 			}
 
-			return next(hh)
+			return next(ctx, hh)
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package deps_test
 
 import (
+	"context"
 	"regexp"
 	"testing"
 
@@ -15,7 +16,7 @@ import (
 func TestWithDetection(t *testing.T) {
 	opt := deps.WithDetection(deps.Config{})
 
-	h := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	h := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				Dependencies: []string{
@@ -35,7 +36,7 @@ func TestWithDetection(t *testing.T) {
 		}, nil
 	})
 
-	result, err := h([]heartbeat.Heartbeat{{
+	result, err := h(context.Background(), []heartbeat.Heartbeat{{
 		Entity:     "testdata/golang_minimal.go",
 		EntityType: heartbeat.FileType,
 		Language:   heartbeat.PointerTo("Go"),
@@ -54,7 +55,7 @@ func TestWithDetection_SkipSanitized(t *testing.T) {
 		FilePatterns: []regex.Regex{regexp.MustCompile(".*")},
 	})
 
-	h := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	h := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Len(t, hh[0].Dependencies, 0)
 
 		return []heartbeat.Result{
@@ -64,7 +65,7 @@ func TestWithDetection_SkipSanitized(t *testing.T) {
 		}, nil
 	})
 
-	result, err := h([]heartbeat.Heartbeat{{
+	result, err := h(context.Background(), []heartbeat.Heartbeat{{
 		Entity:     "testdata/golang.go",
 		EntityType: heartbeat.FileType,
 		Language:   heartbeat.PointerTo("Go"),
@@ -81,7 +82,7 @@ func TestWithDetection_SkipSanitized(t *testing.T) {
 func TestWithDetection_LocalFile(t *testing.T) {
 	opt := deps.WithDetection(deps.Config{})
 
-	h := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	h := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				Dependencies: []string{
@@ -102,7 +103,7 @@ func TestWithDetection_LocalFile(t *testing.T) {
 		}, nil
 	})
 
-	result, err := h([]heartbeat.Heartbeat{{
+	result, err := h(context.Background(), []heartbeat.Heartbeat{{
 		Entity:     "testdata/golang.go",
 		EntityType: heartbeat.FileType,
 		Language:   heartbeat.PointerTo("Go"),
@@ -120,7 +121,7 @@ func TestWithDetection_LocalFile(t *testing.T) {
 func TestWithDetection_NonFileType(t *testing.T) {
 	opt := deps.WithDetection(deps.Config{})
 
-	h := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	h := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				Entity:     "testdata/codefiles/golang.go",
@@ -135,7 +136,7 @@ func TestWithDetection_NonFileType(t *testing.T) {
 		}, nil
 	})
 
-	result, err := h([]heartbeat.Heartbeat{{
+	result, err := h(context.Background(), []heartbeat.Heartbeat{{
 		Entity:     "testdata/codefiles/golang.go",
 		EntityType: heartbeat.AppType,
 	}})
@@ -15,4 +15,18 @@ const (
 	ErrConfigFileRead = 110
 	// ErrConfigFileWrite is used for errors of config write command.
 	ErrConfigFileWrite = 111
+	// ErrRateLimit is used when the WakaTime API asked to retry later.
+	ErrRateLimit = 112
+	// ErrConnection is used when the WakaTime API could not be connected, e.g. due to
+	// a refused connection or a failed DNS lookup.
+	ErrConnection = 113
+	// ErrTimeout is used when a request to the WakaTime API timed out.
+	ErrTimeout = 114
+	// ErrTLS is used when the TLS handshake with the WakaTime API failed, e.g. due to
+	// a certificate, which could not be verified.
+	ErrTLS = 115
+	// ErrProxyAuth is used when the configured proxy rejected the proxy credentials.
+	ErrProxyAuth = 116
+	// ErrServer is used when the WakaTime API responded with a server error.
+	ErrServer = 117
 )
@@ -2,6 +2,7 @@ package filestats
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -20,7 +21,7 @@ const maxFileSizeSupported = 2097152
 // moment only the total number of lines in a file is detected.
 func WithDetection() heartbeat.HandleOption {
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			log.Debugln("execute filestats detection")
 
 			for n, h := range hh {
@@ -70,7 +71,7 @@ func WithDetection() heartbeat.HandleOption {
 				hh[n].Lines = heartbeat.PointerTo(lines)
 			}
 
-			return next(hh)
+			return next(ctx, hh)
 		}
 	}
 }
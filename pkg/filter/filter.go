@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -22,7 +23,7 @@ type Config struct {
 // the provided configurations.
 func WithFiltering(config Config) heartbeat.HandleOption {
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			log.Debugln("execute heartbeat filtering")
 
 			var filtered []heartbeat.Heartbeat
@@ -43,7 +44,7 @@ func WithFiltering(config Config) heartbeat.HandleOption {
 				return []heartbeat.Result{}, nil
 			}
 
-			return next(filtered)
+			return next(ctx, filtered)
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package filter_test
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
@@ -27,7 +28,7 @@ func TestWithFiltering(t *testing.T) {
 	second.Time++
 
 	opt := filter.WithFiltering(filter.Config{})
-	h := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	h := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				Branch:         heartbeat.PointerTo("heartbeat"),
@@ -53,7 +54,7 @@ func TestWithFiltering(t *testing.T) {
 		}, nil
 	})
 
-	result, err := h([]heartbeat.Heartbeat{first, second})
+	result, err := h(context.Background(), []heartbeat.Heartbeat{first, second})
 	require.NoError(t, err)
 
 	assert.Equal(t, []heartbeat.Result{
@@ -65,11 +66,11 @@ func TestWithFiltering(t *testing.T) {
 
 func TestWithFiltering_AbortAllFiltered(t *testing.T) {
 	opt := filter.WithFiltering(filter.Config{})
-	h := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	h := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		return []heartbeat.Result{}, errors.New("this will should never be called")
 	})
 
-	result, err := h([]heartbeat.Heartbeat{testHeartbeat()})
+	result, err := h(context.Background(), []heartbeat.Heartbeat{testHeartbeat()})
 	require.NoError(t, err)
 
 	assert.Equal(t, result, []heartbeat.Result{})
@@ -1,6 +1,44 @@
 package goal
 
-// Goal represents the tracked working time for a single goal.
+import (
+	"fmt"
+	"strings"
+)
+
+// Goal represents the tracked working time for a single goal. Progress fields
+// refer to the most recent range of the goal, e.g. the current day or week.
 type Goal struct {
-	Total string
+	ID            string  `json:"id"`
+	Title         string  `json:"title"`
+	Status        string  `json:"status"`
+	Seconds       float64 `json:"seconds"`
+	Total         string  `json:"total"`
+	TargetSeconds float64 `json:"target_seconds"`
+	Target        string  `json:"target"`
+	Percent       float64 `json:"percent"`
+	IsInverse     bool    `json:"is_inverse"`
+	IsMet         bool    `json:"is_met"`
+}
+
+// Render generates a text representation of the progress of a goal. Falls
+// back to only the tracked time, if the target is unknown.
+func Render(g *Goal) string {
+	if g.Target == "" {
+		return g.Total
+	}
+
+	rendered := fmt.Sprintf("%s of %s (%s%%)", g.Total, g.Target, formatPercent(g.Percent))
+
+	if g.IsMet {
+		rendered += ", goal met"
+	}
+
+	return rendered
+}
+
+// formatPercent formats a percentage with at most one decimal place.
+func formatPercent(percent float64) string {
+	s := fmt.Sprintf("%.1f", percent)
+
+	return strings.TrimSuffix(s, ".0")
 }
@@ -0,0 +1,35 @@
+package goal_test
+
+import (
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/goal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	tests := map[string]struct {
+		Goal     goal.Goal
+		Expected string
+	}{
+		"unknown target": {
+			Goal:     goal.Goal{Total: "3 hrs 23 mins"},
+			Expected: "3 hrs 23 mins",
+		},
+		"in progress": {
+			Goal:     goal.Goal{Total: "3 hrs 23 mins", Target: "4 hrs", Percent: 84.6},
+			Expected: "3 hrs 23 mins of 4 hrs (84.6%)",
+		},
+		"met": {
+			Goal:     goal.Goal{Total: "4 hrs", Target: "4 hrs", Percent: 100, IsMet: true},
+			Expected: "4 hrs of 4 hrs (100%), goal met",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, goal.Render(&test.Goal))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package heartbeat
 
 import (
+	"context"
 	"path/filepath"
 	"strings"
 
@@ -11,7 +12,7 @@ import (
 // can be used in a heartbeat processing pipeline to change an entity path.
 func WithEntityModifer() HandleOption {
 	return func(next Handle) Handle {
-		return func(hh []Heartbeat) ([]Result, error) {
+		return func(ctx context.Context, hh []Heartbeat) ([]Result, error) {
 			log.Debugln("execute heartbeat entity modifier")
 
 			for n, h := range hh {
@@ -21,7 +22,7 @@ func WithEntityModifer() HandleOption {
 				}
 			}
 
-			return next(hh)
+			return next(ctx, hh)
 		}
 	}
 }
@@ -0,0 +1,68 @@
+package heartbeat
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+// Destination is a named handle, heartbeats are fanned out to.
+type Destination struct {
+	Name   string
+	Handle Handle
+}
+
+// FanOut is a Sender, which passes the heartbeats on to a primary handle and
+// additional destinations concurrently. Only results and error of the primary
+// handle are returned. Failures of additional destinations are logged, as
+// every destination is expected to take care of them on its own, e.g. via its
+// own offline queue.
+type FanOut struct {
+	destinations []Destination
+	primary      Handle
+}
+
+// NewFanOut creates a new FanOut sender.
+func NewFanOut(primary Handle, destinations ...Destination) FanOut {
+	return FanOut{
+		destinations: destinations,
+		primary:      primary,
+	}
+}
+
+// SendHeartbeats passes a copy of the heartbeats to every handle and returns
+// once all of them finished.
+func (f FanOut) SendHeartbeats(ctx context.Context, hh []Heartbeat) ([]Result, error) {
+	var wg sync.WaitGroup
+
+	for _, d := range f.destinations {
+		wg.Add(1)
+
+		go func(d Destination, hh []Heartbeat) {
+			defer wg.Done()
+
+			log.Debugf("send %d heartbeat(s) to destination %q", len(hh), d.Name)
+
+			if _, err := d.Handle(ctx, hh); err != nil {
+				log.Warnf("failed to send heartbeat(s) to destination %q: %s", d.Name, err)
+			}
+		}(d, copyHeartbeats(hh))
+	}
+
+	results, err := f.primary(ctx, copyHeartbeats(hh))
+
+	wg.Wait()
+
+	return results, err
+}
+
+// copyHeartbeats returns a shallow copy of the heartbeats, as handles modify
+// the heartbeats passed in.
+func copyHeartbeats(hh []Heartbeat) []Heartbeat {
+	if hh == nil {
+		return nil
+	}
+
+	return append([]Heartbeat{}, hh...)
+}
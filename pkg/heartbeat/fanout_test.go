@@ -0,0 +1,96 @@
+package heartbeat_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOut_SendHeartbeats(t *testing.T) {
+	hh := []heartbeat.Heartbeat{
+		{Entity: "/tmp/main.go"},
+		{Entity: "/tmp/main_test.go"},
+	}
+
+	var (
+		mu       sync.Mutex
+		received = make(map[string][]heartbeat.Heartbeat)
+	)
+
+	newHandle := func(name, apiKey string, err error) heartbeat.Handle {
+		return func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+			for n := range hh {
+				hh[n].ApiKey = apiKey
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			received[name] = hh
+
+			if err != nil {
+				return nil, err
+			}
+
+			return []heartbeat.Result{{Status: 201, Heartbeat: hh[0]}}, nil
+		}
+	}
+
+	fanOut := heartbeat.NewFanOut(
+		newHandle("primary", "primary-key", nil),
+		heartbeat.Destination{Name: "wakapi", Handle: newHandle("wakapi", "wakapi-key", errors.New("unreachable"))},
+		heartbeat.Destination{Name: "other", Handle: newHandle("other", "other-key", nil)},
+	)
+
+	results, err := fanOut.SendHeartbeats(context.Background(), hh)
+	require.NoError(t, err)
+
+	assert.Equal(t, []heartbeat.Result{
+		{
+			Status:    201,
+			Heartbeat: heartbeat.Heartbeat{Entity: "/tmp/main.go", ApiKey: "primary-key"},
+		},
+	}, results)
+
+	require.Len(t, received, 3)
+
+	for name, hh := range received {
+		require.Len(t, hh, 2)
+
+		assert.Equal(t, name+"-key", hh[0].ApiKey)
+		assert.Equal(t, name+"-key", hh[1].ApiKey)
+	}
+
+	// passed in heartbeats are left untouched
+	assert.Empty(t, hh[0].ApiKey)
+}
+
+func TestFanOut_SendHeartbeats_PrimaryErr(t *testing.T) {
+	var called bool
+
+	fanOut := heartbeat.NewFanOut(
+		func(_ context.Context, _ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+			return nil, errors.New("failed")
+		},
+		heartbeat.Destination{
+			Name: "wakapi",
+			Handle: func(_ context.Context, _ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+				called = true
+
+				return nil, nil
+			},
+		},
+	)
+
+	_, err := fanOut.SendHeartbeats(context.Background(), []heartbeat.Heartbeat{{Entity: "/tmp/main.go"}})
+	require.Error(t, err)
+
+	assert.Equal(t, "failed", err.Error())
+	assert.True(t, called)
+}
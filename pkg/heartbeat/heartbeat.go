@@ -1,6 +1,7 @@
 package heartbeat
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -115,19 +116,21 @@ type Result struct {
 
 // Sender sends heartbeats to the wakatime api.
 type Sender interface {
-	SendHeartbeats(hh []Heartbeat) ([]Result, error)
+	SendHeartbeats(ctx context.Context, hh []Heartbeat) ([]Result, error)
 }
 
 // Sender is a noop api client, used to always skip sending to the API.
 type Noop struct{}
 
 // SendHeartbeats always returns nil.
-func (Noop) SendHeartbeats(_ []Heartbeat) ([]Result, error) {
+func (Noop) SendHeartbeats(_ context.Context, _ []Heartbeat) ([]Result, error) {
 	return nil, nil
 }
 
-// Handle does processing of heartbeats.
-type Handle func(hh []Heartbeat) ([]Result, error)
+// Handle does processing of heartbeats. Handles are expected to stop any
+// long running work, once the context is done, and to pass on the heartbeats
+// anyway, so they can be saved to the offline queue.
+type Handle func(ctx context.Context, hh []Heartbeat) ([]Result, error)
 
 // HandleOption is a function, which allows chaining multiple Handles.
 type HandleOption func(next Handle) Handle
@@ -135,13 +138,13 @@ type HandleOption func(next Handle) Handle
 // NewHandle creates a new Handle, which acts like a processing pipeline,
 // with a sender eventually sending the heartbeats.
 func NewHandle(sender Sender, opts ...HandleOption) Handle {
-	return func(heartbeats []Heartbeat) ([]Result, error) {
+	return func(ctx context.Context, heartbeats []Heartbeat) ([]Result, error) {
 		var handle Handle = sender.SendHeartbeats
 		for i := len(opts) - 1; i >= 0; i-- {
 			handle = opts[i](handle)
 		}
 
-		return handle(heartbeats)
+		return handle(ctx, heartbeats)
 	}
 }
 
@@ -1,6 +1,7 @@
 package heartbeat
 
 import (
+	"context"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -29,14 +30,14 @@ type SanitizeConfig struct {
 // can be used in a heartbeat processing pipeline to hide sensitive data.
 func WithSanitization(config SanitizeConfig) HandleOption {
 	return func(next Handle) Handle {
-		return func(hh []Heartbeat) ([]Result, error) {
+		return func(ctx context.Context, hh []Heartbeat) ([]Result, error) {
 			log.Debugln("execute heartbeat sanitization")
 
 			for n, h := range hh {
 				hh[n] = Sanitize(h, config)
 			}
 
-			return next(hh)
+			return next(ctx, hh)
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package heartbeat_test
 
 import (
+	"context"
 	"regexp"
 	"testing"
 
@@ -16,7 +17,7 @@ func TestWithSanitization_ObfuscateFile(t *testing.T) {
 		FilePatterns: []regex.Regex{regexp.MustCompile(".*")},
 	})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				Category:   heartbeat.CodingCategory,
@@ -37,7 +38,7 @@ func TestWithSanitization_ObfuscateFile(t *testing.T) {
 		}, nil
 	})
 
-	result, err := handle([]heartbeat.Heartbeat{testHeartbeat()})
+	result, err := handle(context.Background(), []heartbeat.Heartbeat{testHeartbeat()})
 	require.NoError(t, err)
 
 	assert.Equal(t, []heartbeat.Result{
@@ -65,19 +65,11 @@ func (w *WriterConfig) Write(section string, keyValue map[string]string) error {
 		return errors.New("got undefined wakatime config file instance")
 	}
 
-	for key, value := range keyValue {
-		// prevent writing null characters
-		key = strings.ReplaceAll(key, "\x00", "")
-		value = strings.ReplaceAll(value, "\x00", "")
-
-		w.File.Section(section).Key(key).SetValue(value)
-	}
-
 	releaser, err := mutex.Acquire(mutex.Spec{
 		Name:    "wakatime-cli-config-mutex",
 		Delay:   time.Millisecond,
 		Timeout: defaultTimeout,
-		Clock:   &mutexClock{delay: time.Millisecond},
+		Clock:   &mutexClock{},
 	})
 	if err != nil {
 		log.Debugf("failed to acquire mutex: %s", err)
@@ -89,6 +81,20 @@ func (w *WriterConfig) Write(section string, keyValue map[string]string) error {
 		}
 	}()
 
+	// reload the file while holding the mutex, to not overwrite values saved
+	// by concurrent writers since it was loaded
+	if err := w.reload(); err != nil {
+		return err
+	}
+
+	for key, value := range keyValue {
+		// prevent writing null characters
+		key = strings.ReplaceAll(key, "\x00", "")
+		value = strings.ReplaceAll(value, "\x00", "")
+
+		w.File.Section(section).Key(key).SetValue(value)
+	}
+
 	if err := w.File.SaveTo(w.ConfigFilepath); err != nil {
 		return fmt.Errorf("error saving wakatime config: %s", err)
 	}
@@ -96,6 +102,22 @@ func (w *WriterConfig) Write(section string, keyValue map[string]string) error {
 	return nil
 }
 
+// reload loads the config file from disk again, if it exists.
+func (w *WriterConfig) reload() error {
+	file, err := ini.LoadSources(ini.LoadOptions{AllowPythonMultilineValues: true}, w.ConfigFilepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("error loading config file: %s", err)
+	}
+
+	w.File = file
+
+	return nil
+}
+
 // ReadInConfig reads wakatime config file in memory.
 func ReadInConfig(v *viper.Viper, configFilePath string) error {
 	v.SetConfigType("ini")
@@ -203,12 +225,12 @@ func WakaHomeDir() (string, error) {
 }
 
 // mutexClock is used to implement mutex.Clock interface.
-type mutexClock struct {
-	delay time.Duration
-}
+type mutexClock struct{}
 
-func (mc *mutexClock) After(time.Duration) <-chan time.Time {
-	return time.After(mc.delay)
+// After waits for the passed in duration, which is the lock timeout or the
+// delay between attempts to acquire the lock.
+func (*mutexClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
 }
 
 func (*mutexClock) Now() time.Time {
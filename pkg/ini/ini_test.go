@@ -2,9 +2,11 @@ package ini_test
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/wakatime/wakatime-cli/pkg/ini"
@@ -314,6 +316,46 @@ func TestWrite_NullsRemoved(t *testing.T) {
 		strings.ReplaceAll(string(actual), "\r", ""))
 }
 
+func TestWrite_Concurrent(t *testing.T) {
+	tmpFile, err := os.CreateTemp(t.TempDir(), "wakatime")
+	require.NoError(t, err)
+
+	defer tmpFile.Close()
+
+	v := viper.New()
+	v.Set("config", tmpFile.Name())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(section string) {
+			defer wg.Done()
+
+			w, err := ini.NewIniWriter(v, ini.FilePath)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = w.Write(section, map[string]string{"backoff_at": "2021-11-25T12:17:21-07:00"})
+			assert.NoError(t, err)
+		}(fmt.Sprintf("internal_destination_%d", i))
+	}
+
+	wg.Wait()
+
+	cfg, err := iniv1.Load(tmpFile.Name())
+	require.NoError(t, err)
+
+	// values written concurrently by other writers are not overwritten
+	for i := 0; i < 20; i++ {
+		section := fmt.Sprintf("internal_destination_%d", i)
+
+		assert.Equal(t, "2021-11-25T12:17:21-07:00", cfg.Section(section).Key("backoff_at").String(), section)
+	}
+}
+
 func TestWriteErr(t *testing.T) {
 	w := ini.WriterConfig{}
 
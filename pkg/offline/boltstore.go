@@ -0,0 +1,395 @@
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// enqueueTimeout is the maximum time to wait for the db file lock, before
+	// pushed heartbeats are written to the fallback spool instead.
+	enqueueTimeout = 2 * time.Second
+	// fallbackSpoolSuffix is appended to the db filepath to get the fallback
+	// spool directory.
+	fallbackSpoolSuffix = ".spool"
+)
+
+// BoltStore is the default QueueStore, which keeps the offline queue in a
+// single bolt db file. The db is opened for every operation, so multiple
+// processes can share the file.
+//
+// Pushing heartbeats does not block for long, if another process holds the
+// db file lock. Heartbeats are written to a fallback spool directory next to
+// the db file instead, which is merged into the db by the next process
+// obtaining the lock.
+type BoltStore struct {
+	collapseWindow time.Duration
+	filepath       string
+	retention      Retention
+
+	// maintained is set after the first pop, which restored, evicted and
+	// collapsed the queued heartbeats.
+	maintained   bool
+	maintainedMu sync.Mutex
+}
+
+// NewBoltStore creates a new instance of BoltStore for the db at filepath.
+func NewBoltStore(filepath string, opts ...Option) *BoltStore {
+	cfg := newConfig(opts)
+
+	return &BoltStore{
+		collapseWindow: cfg.collapseWindow,
+		filepath:       filepath,
+		retention:      cfg.retention,
+	}
+}
+
+// Push stores the provided heartbeats in the db. Heartbeats exceeding the
+// retention limits are evicted afterwards. If the db file is locked by another
+// process, heartbeats are written to the fallback spool.
+func (s *BoltStore) Push(hh []heartbeat.Heartbeat) error {
+	db, err := s.open(&bolt.Options{Timeout: enqueueTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		log.Warnf("offline db locked, pushing %d heartbeat(s) to fallback spool", len(hh))
+
+		return s.fallback().Push(hh)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	defer db.Close()
+
+	s.mergeFallback(db)
+
+	tx, err := db.Begin(true)
+	if err != nil {
+		return fmt.Errorf("failed to start db transaction: %s", err)
+	}
+
+	queue := NewQueue(tx)
+	queue.Retention = s.retention
+
+	err = queue.PushMany(hh)
+	if err != nil {
+		return fmt.Errorf("failed to push heartbeat(s) to queue: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit db transaction: %s", err)
+	}
+
+	if _, err := compactIfNeeded(db); err != nil {
+		log.Warnf("failed to compact offline db: %s", err)
+	}
+
+	return nil
+}
+
+// Pop leases up to limit heartbeats from the queue. Heartbeats with expired
+// leases from previous runs are moved back to the queue beforehand. On the
+// first pop of a store, quarantined heartbeats, which can be decoded again,
+// are moved back to the queue and heartbeats exceeding the retention limits
+// are evicted as well. If a collapse window is set, redundant heartbeats are
+// deleted then too. As each of these reads the whole queue, they only run once
+// per sync instead of once per batch.
+func (s *BoltStore) Pop(limit int) ([]Record, error) {
+	db, err := s.open(&bolt.Options{Timeout: 10 * time.Minute})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	defer db.Close()
+
+	s.mergeFallback(db)
+
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start db transaction: %s", err)
+	}
+
+	queue := NewQueue(tx)
+
+	released, err := queue.ReleaseExpired(leaseTimeout)
+	if err != nil {
+		errrb := tx.Rollback()
+		if errrb != nil {
+			log.Errorf("failed to rollback transaction: %s", errrb)
+		}
+
+		return nil, fmt.Errorf("failed to release expired heartbeat(s): %s", err)
+	}
+
+	if released > 0 {
+		log.Warnf("moved %d heartbeat(s) with expired lease back to queue", released)
+	}
+
+	s.maintainedMu.Lock()
+	maintain := !s.maintained
+	s.maintainedMu.Unlock()
+
+	if maintain {
+		if err := s.maintain(queue); err != nil {
+			errrb := tx.Rollback()
+			if errrb != nil {
+				log.Errorf("failed to rollback transaction: %s", errrb)
+			}
+
+			return nil, err
+		}
+	}
+
+	queued, err := queue.PopMany(limit)
+	if err != nil {
+		errrb := tx.Rollback()
+		if errrb != nil {
+			log.Errorf("failed to rollback transaction: %s", errrb)
+		}
+
+		return nil, fmt.Errorf("failed to pop heartbeat(s) from queue: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit db transaction: %s", err)
+	}
+
+	if maintain {
+		s.maintainedMu.Lock()
+		s.maintained = true
+		s.maintainedMu.Unlock()
+	}
+
+	return queued, nil
+}
+
+// maintain restores quarantined heartbeats, evicts heartbeats exceeding the
+// retention limits and collapses redundant heartbeats.
+func (s *BoltStore) maintain(queue *Queue) error {
+	restored, err := queue.RestoreQuarantined()
+	if err != nil {
+		return fmt.Errorf("failed to restore quarantined heartbeat(s): %s", err)
+	}
+
+	if restored > 0 {
+		log.Infof("moved %d quarantined heartbeat(s) back to queue", restored)
+	}
+
+	if _, err := queue.Evict(s.retention); err != nil {
+		return fmt.Errorf("failed to evict heartbeat(s): %s", err)
+	}
+
+	if _, err := queue.Collapse(s.collapseWindow); err != nil {
+		return fmt.Errorf("failed to collapse heartbeat(s): %s", err)
+	}
+
+	return nil
+}
+
+// Delete deletes leased heartbeats, after they have been handled.
+func (s *BoltStore) Delete(ids []string) error {
+	return s.updateLeases(func(q *Queue) error {
+		return q.Ack(ids)
+	})
+}
+
+// Release moves leased heartbeats back to the queue.
+func (s *BoltStore) Release(ids []string) error {
+	return s.updateLeases(func(q *Queue) error {
+		return q.Release(ids)
+	})
+}
+
+func (s *BoltStore) updateLeases(fn func(q *Queue) error) error {
+	db, err := s.open(&bolt.Options{Timeout: 10 * time.Minute})
+	if err != nil {
+		return fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return fn(NewQueue(tx))
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := compactIfNeeded(db); err != nil {
+		log.Warnf("failed to compact offline db: %s", err)
+	}
+
+	return nil
+}
+
+// Count returns the total number of heartbeats in the queue.
+func (s *BoltStore) Count() (int, error) {
+	db, err := openDB(s.filepath, &bolt.Options{Timeout: 30 * time.Second})
+	if err != nil {
+		return 0, fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	defer db.Close()
+
+	tx, err := db.Begin(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start db transaction: %s", err)
+	}
+
+	queue := NewQueue(tx)
+
+	count, err := queue.Count()
+	if err != nil {
+		log.Errorf("failed to count offline heartbeats: %s", err)
+
+		_ = tx.Rollback()
+
+		return count, err
+	}
+
+	err = tx.Rollback()
+	if err != nil {
+		log.Warnf("failed to rollback transaction: %s", err)
+	}
+
+	return count, nil
+}
+
+// List returns the queued heartbeats matching the passed in filter. The db is
+// opened read only.
+func (s *BoltStore) List(filter Filter) ([]Record, error) {
+	if _, err := os.Stat(s.filepath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := openDB(s.filepath, &bolt.Options{Timeout: 30 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	defer db.Close()
+
+	var records []Record
+
+	err = db.View(func(tx *bolt.Tx) error {
+		records, err = NewQueue(tx).List(filter)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offline heartbeats: %s", err)
+	}
+
+	return records, nil
+}
+
+// PushDeadLetters stores the provided dead letters in the db. If the db file is
+// locked by another process, dead letters are written to the fallback spool.
+func (s *BoltStore) PushDeadLetters(entries []DeadLetter) error {
+	db, err := s.open(&bolt.Options{Timeout: enqueueTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		log.Warnf("offline db locked, pushing %d dead letter(s) to fallback spool", len(entries))
+
+		return s.fallback().PushDeadLetters(entries)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return NewQueue(tx).PushDeadLetters(entries)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to push dead letter(s): %s", err)
+	}
+
+	log.Debugf("moved %d heartbeat(s) to dead letter bucket", len(entries))
+
+	return nil
+}
+
+// open opens the db. A broken db is repaired by salvaging all readable records
+// into a fresh db, so sending and syncing heartbeats does not break for good.
+func (s *BoltStore) open(options *bolt.Options) (*bolt.DB, error) {
+	db, err := openDB(s.filepath, options)
+	if err == nil || !isCorrupted(err) {
+		return db, err
+	}
+
+	log.Errorf("offline db is broken, repairing: %s", err)
+
+	if _, err := RepairDB(s.filepath); err != nil {
+		return nil, fmt.Errorf("failed to repair broken db: %s", err)
+	}
+
+	return openDB(s.filepath, options)
+}
+
+// fallback returns the spool, which takes pushed heartbeats while the db file
+// is locked. Retention limits are enforced once merged into the db.
+func (s *BoltStore) fallback() *SpoolStore {
+	return NewSpoolStore(s.filepath + fallbackSpoolSuffix)
+}
+
+// mergeFallback moves heartbeats and dead letters from the fallback spool into
+// the db. Failures are only logged, as spooled heartbeats will be merged by
+// the next process otherwise.
+func (s *BoltStore) mergeFallback(db *bolt.DB) {
+	spool := s.fallback()
+
+	if _, err := os.Stat(spool.dir); os.IsNotExist(err) {
+		return
+	}
+
+	records, err := spool.Pop(math.MaxInt32)
+	if err != nil {
+		log.Warnf("failed to read fallback spool: %s", err)
+	}
+
+	if len(records) > 0 {
+		hh, ids := splitRecords(records)
+
+		err = db.Update(func(tx *bolt.Tx) error {
+			queue := NewQueue(tx)
+			queue.Retention = s.retention
+
+			return queue.PushMany(hh)
+		})
+		if err != nil {
+			log.Warnf("failed to merge fallback spool into offline db: %s", err)
+
+			if err := spool.Release(ids); err != nil {
+				log.Warnf("failed to release fallback spool heartbeats: %s", err)
+			}
+
+			return
+		}
+
+		if err := spool.Delete(ids); err != nil {
+			log.Warnf("failed to delete merged fallback spool heartbeats: %s", err)
+		}
+
+		log.Debugf("merged %d heartbeat(s) from fallback spool into offline db", len(records))
+	}
+
+	err = spool.takeDeadLetters(func(entries []DeadLetter) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			return NewQueue(tx).PushDeadLetters(entries)
+		})
+	})
+	if err != nil {
+		log.Warnf("failed to merge fallback spool dead letters into offline db: %s", err)
+	}
+}
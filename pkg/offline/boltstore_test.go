@@ -0,0 +1,120 @@
+package offline_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltStore_Push_Locked(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	now := time.Now()
+
+	// hold the db file lock like a concurrently syncing process
+	db, err := bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	store := offline.NewBoltStore(fp)
+
+	start := time.Now()
+
+	err = store.Push([]heartbeat.Heartbeat{heartbeatAt(now.Add(-time.Minute))})
+	require.NoError(t, err)
+
+	err = store.PushDeadLetters([]offline.DeadLetter{
+		{
+			ID:        heartbeatAt(now.Add(-time.Hour)).ID(),
+			Heartbeat: heartbeatAt(now.Add(-time.Hour)),
+			Status:    400,
+			FailedAt:  now,
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	queued, err := os.ReadDir(filepath.Join(fp+".spool", "queue"))
+	require.NoError(t, err)
+
+	assert.Len(t, queued, 1)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	// next process obtaining the lock merges the fallback spool
+	err = store.Push([]heartbeat.Heartbeat{heartbeatAt(now)})
+	require.NoError(t, err)
+
+	db, err = bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Equal(t, []string{
+		heartbeatAt(now.Add(-time.Minute)).ID(),
+		heartbeatAt(now).ID(),
+	}, bucketIDs(t, db, "heartbeats"))
+
+	assert.Equal(t, []string{heartbeatAt(now.Add(-time.Hour)).ID()}, bucketIDs(t, db, "dead_letter"))
+
+	queued, err = os.ReadDir(filepath.Join(fp+".spool", "queue"))
+	require.NoError(t, err)
+
+	assert.Empty(t, queued)
+}
+
+func TestBoltStore_Pop_EvictsOncePerStore(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	now := time.Now()
+
+	store := offline.NewBoltStore(fp, offline.WithRetention(offline.Retention{MaxAge: 90 * time.Minute}))
+
+	err := store.Push([]heartbeat.Heartbeat{
+		heartbeatAt(now.Add(-2 * time.Hour)),
+		heartbeatAt(now.Add(-time.Minute)),
+		heartbeatAt(now),
+	})
+	require.NoError(t, err)
+
+	records, err := store.Pop(1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{heartbeatAt(now.Add(-time.Minute)).ID()}, recordIDs(records))
+
+	// queue expired heartbeats, bypassing eviction on push
+	db, err := bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).PushMany([]heartbeat.Heartbeat{
+			heartbeatAt(now.Add(-4 * time.Hour)),
+			heartbeatAt(now.Add(-3 * time.Hour)),
+		})
+	})
+	require.NoError(t, err)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	// retention was already enforced by the first pop of this store
+	records, err = store.Pop(1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{heartbeatAt(now.Add(-4 * time.Hour)).ID()}, recordIDs(records))
+
+	// a new store, as used by the next sync, enforces it again
+	records, err = offline.NewBoltStore(fp, offline.WithRetention(offline.Retention{MaxAge: 90 * time.Minute})).Pop(1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{heartbeatAt(now).ID()}, recordIDs(records))
+}
@@ -0,0 +1,132 @@
+package offline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+// WithCollapseWindow enables merging redundant heartbeats before sync. Within
+// a run of heartbeats for the same entity, project, branch and category, at
+// most one heartbeat per window is kept.
+func WithCollapseWindow(window time.Duration) Option {
+	return func(c *config) {
+		c.collapseWindow = window
+	}
+}
+
+// runKey identifies heartbeats belonging to the same run.
+type runKey struct {
+	entity   string
+	project  string
+	branch   string
+	category heartbeat.Category
+}
+
+func newRunKey(h heartbeat.Heartbeat) runKey {
+	var project, branch string
+
+	if h.Project != nil {
+		project = *h.Project
+	}
+
+	if h.Branch != nil {
+		branch = *h.Branch
+	}
+
+	return runKey{
+		entity:   h.Entity,
+		project:  project,
+		branch:   branch,
+		category: h.Category,
+	}
+}
+
+// Collapse deletes redundant heartbeats from the queue and returns the number
+// of deleted heartbeats. A run is a sequence of consecutive heartbeats with
+// the same entity, project, branch and category. Within a run, heartbeats
+// less than window after the previously kept heartbeat are deleted. Write
+// heartbeats and the first and last heartbeat of a run are always kept.
+func (q *Queue) Collapse(window time.Duration) (int, error) {
+	b := q.tx.Bucket([]byte(q.Bucket))
+	if b == nil || window <= 0 {
+		return 0, nil
+	}
+
+	var records []Record
+
+	err := b.ForEach(func(key, value []byte) error {
+		// undecodable records get quarantined once popped
+		h, err := decodeRecord(value)
+		if err != nil {
+			return nil
+		}
+
+		records = append(records, Record{ID: string(key), Heartbeat: h})
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	redundant := redundantHeartbeats(records, window)
+
+	for _, id := range redundant {
+		if err := b.Delete([]byte(id)); err != nil {
+			return 0, fmt.Errorf("failed to delete key %q: %s", id, err)
+		}
+	}
+
+	if len(redundant) > 0 {
+		log.Debugf("collapsed %d redundant heartbeat(s) in offline queue", len(redundant))
+	}
+
+	return len(redundant), nil
+}
+
+// redundantHeartbeats returns the ids of the redundant heartbeats within the
+// passed in records, which must be ordered by time.
+func redundantHeartbeats(records []Record, window time.Duration) []string {
+	var (
+		redundant []string
+		run       []Record
+	)
+
+	flush := func() {
+		if len(run) <= 2 {
+			run = nil
+			return
+		}
+
+		kept := heartbeatTime(run[0].Heartbeat)
+
+		for _, r := range run[1 : len(run)-1] {
+			t := heartbeatTime(r.Heartbeat)
+
+			isWrite := r.Heartbeat.IsWrite != nil && *r.Heartbeat.IsWrite
+			if isWrite || t.Sub(kept) >= window {
+				kept = t
+				continue
+			}
+
+			redundant = append(redundant, r.ID)
+		}
+
+		run = nil
+	}
+
+	for _, r := range records {
+		if len(run) > 0 && newRunKey(run[0].Heartbeat) != newRunKey(r.Heartbeat) {
+			flush()
+		}
+
+		run = append(run, r)
+	}
+
+	flush()
+
+	return redundant
+}
@@ -0,0 +1,99 @@
+package offline_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestQueue_Collapse(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+
+	at := func(minutes int) heartbeat.Heartbeat {
+		h := heartbeatAt(start.Add(time.Duration(minutes) * time.Minute))
+		h.IsWrite = heartbeat.PointerTo(false)
+
+		return h
+	}
+
+	write := func(h heartbeat.Heartbeat) heartbeat.Heartbeat {
+		h.IsWrite = heartbeat.PointerTo(true)
+		return h
+	}
+
+	other := func(h heartbeat.Heartbeat) heartbeat.Heartbeat {
+		h.Entity = "/tmp/other.go"
+		return h
+	}
+
+	tests := map[string]struct {
+		Heartbeats []heartbeat.Heartbeat
+		Window     time.Duration
+		Collapsed  int
+		Expected   []heartbeat.Heartbeat
+	}{
+		"disabled": {
+			Heartbeats: []heartbeat.Heartbeat{at(0), at(1), at(2)},
+			Expected:   []heartbeat.Heartbeat{at(0), at(1), at(2)},
+		},
+		"keeps first and last of run": {
+			Heartbeats: []heartbeat.Heartbeat{at(0), at(1), at(2), at(3)},
+			Window:     10 * time.Minute,
+			Collapsed:  2,
+			Expected:   []heartbeat.Heartbeat{at(0), at(3)},
+		},
+		"keeps one per window": {
+			Heartbeats: []heartbeat.Heartbeat{at(0), at(2), at(4), at(6), at(8)},
+			Window:     4 * time.Minute,
+			Collapsed:  2,
+			Expected:   []heartbeat.Heartbeat{at(0), at(4), at(8)},
+		},
+		"keeps write heartbeats": {
+			Heartbeats: []heartbeat.Heartbeat{at(0), write(at(1)), at(2), at(3)},
+			Window:     10 * time.Minute,
+			Collapsed:  1,
+			Expected:   []heartbeat.Heartbeat{at(0), write(at(1)), at(3)},
+		},
+		"different entity ends run": {
+			Heartbeats: []heartbeat.Heartbeat{at(0), at(1), other(at(2)), at(3), at(4)},
+			Window:     10 * time.Minute,
+			Collapsed:  0,
+			Expected:   []heartbeat.Heartbeat{at(0), at(1), other(at(2)), at(3), at(4)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, cleanup := initDB(t)
+			defer cleanup()
+
+			err := db.Update(func(tx *bolt.Tx) error {
+				return offline.NewQueue(tx).PushMany(test.Heartbeats)
+			})
+			require.NoError(t, err)
+
+			var collapsed int
+
+			err = db.Update(func(tx *bolt.Tx) error {
+				collapsed, err = offline.NewQueue(tx).Collapse(test.Window)
+
+				return err
+			})
+			require.NoError(t, err)
+
+			var expected []string
+			for _, h := range test.Expected {
+				expected = append(expected, h.ID())
+			}
+
+			assert.Equal(t, test.Collapsed, collapsed)
+			assert.Equal(t, expected, bucketIDs(t, db, "heartbeats"))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package offline
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/wakatime/wakatime-cli/pkg/log"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// compactMinFreeBytes is the minimum number of bytes in free pages before
+	// the db file gets compacted.
+	compactMinFreeBytes = 1024 * 1024
+	// compactTxMaxSize limits the size of a single transaction during compaction.
+	compactTxMaxSize = 64 * 1024
+	// openMaxAttempts is the maximum number of attempts to open the db, in case
+	// its file gets replaced by compaction while waiting for the file lock.
+	openMaxAttempts = 3
+)
+
+// openDB opens the bolt db at filepath. As compaction replaces the db file,
+// the file might have been swapped while waiting for the file lock. In that
+// case the stale file is closed and the db reopened. Panics of bolt on reading
+// a broken db file are returned as errors.
+func openDB(filepath string, options *bolt.Options) (*bolt.DB, error) {
+	for i := 0; i < openMaxAttempts; i++ {
+		before, statErr := os.Stat(filepath)
+
+		db, err := openBolt(filepath, options)
+		if err != nil {
+			return nil, err
+		}
+
+		// file did not exist before and has been created by bolt
+		if statErr != nil {
+			return db, nil
+		}
+
+		after, err := os.Stat(filepath)
+		if err == nil && os.SameFile(before, after) {
+			return db, nil
+		}
+
+		if err := db.Close(); err != nil {
+			log.Warnf("failed to close stale db file: %s", err)
+		}
+	}
+
+	return nil, fmt.Errorf("db file %q replaced while opening %d times", filepath, openMaxAttempts)
+}
+
+// compactIfNeeded compacts the db, if at least half of its file consists of
+// free pages, left over from deleted heartbeats. The db is closed after
+// compaction and must not be used anymore.
+func compactIfNeeded(db *bolt.DB) (bool, error) {
+	free := int64(db.Stats().FreeAlloc)
+	if free < compactMinFreeBytes {
+		return false, nil
+	}
+
+	var size int64
+
+	err := db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read db size: %s", err)
+	}
+
+	if free*2 < size {
+		return false, nil
+	}
+
+	log.Debugf("compacting offline db with %d of %d bytes free", free, size)
+
+	if err := compact(db); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// compact copies all data of the db into a new file, reclaiming the space of
+// free pages, and replaces the original file. The db gets closed.
+func compact(db *bolt.DB) error {
+	path := db.Path()
+	tmp := path + ".compact"
+
+	dst, err := bolt.Open(tmp, 0600, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open compaction db: %s", err)
+	}
+
+	err = bolt.Compact(dst, db, compactTxMaxSize)
+
+	if errClose := dst.Close(); errClose != nil && err == nil {
+		err = errClose
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to compact db: %s", err)
+	}
+
+	// On windows an open file cannot be replaced, so the lock has to be
+	// released before. Elsewhere the file is replaced while still holding the
+	// lock and waiting processes detect the swap in openDB.
+	if runtime.GOOS == "windows" {
+		if err := db.Close(); err != nil {
+			_ = os.Remove(tmp)
+
+			return fmt.Errorf("failed to close db: %s", err)
+		}
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to replace db file: %s", err)
+	}
+
+	return db.Close()
+}
@@ -0,0 +1,219 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// dbDeadLetterBucket is the bolt db bucket name for heartbeats, which
+// were permanently rejected by the api or could not be requeued.
+const dbDeadLetterBucket = "dead_letter"
+
+// DeadLetter is a heartbeat, which could not be delivered to the api, along
+// with the reason of its failure.
+type DeadLetter struct {
+	ID        string              `json:"id"`
+	Heartbeat heartbeat.Heartbeat `json:"heartbeat"`
+	Errors    []string            `json:"errors"`
+	Status    int                 `json:"status"`
+	FailedAt  time.Time           `json:"failed_at"`
+}
+
+// newDeadLetters creates dead letters for the passed in heartbeats, which all
+// failed for the same reason.
+func newDeadLetters(hh []heartbeat.Heartbeat, status int, errs ...string) []DeadLetter {
+	now := time.Now()
+
+	entries := make([]DeadLetter, len(hh))
+
+	for i, h := range hh {
+		if h.EntityRaw != "" {
+			h.Entity = h.EntityRaw
+		}
+
+		entries[i] = DeadLetter{
+			ID:        h.ID(),
+			Heartbeat: h,
+			Errors:    errs,
+			Status:    status,
+			FailedAt:  now,
+		}
+	}
+
+	return entries
+}
+
+// ListDeadLetters returns all dead letters from the offline db, or from the
+// spool directory if configured.
+func ListDeadLetters(filepath string, opts ...Option) ([]DeadLetter, error) {
+	if s, ok := newStore(filepath, newConfig(opts)).(*SpoolStore); ok {
+		return s.DeadLetters()
+	}
+
+	if _, err := os.Stat(filepath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := openDB(filepath, &bolt.Options{Timeout: 30 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	defer db.Close()
+
+	var entries []DeadLetter
+
+	err = db.View(func(tx *bolt.Tx) error {
+		entries, err = NewQueue(tx).DeadLetters()
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %s", err)
+	}
+
+	return entries, nil
+}
+
+// RetryDeadLetters moves all dead letters back into the offline queue, so they
+// will be sent again on the next sync. Returns the number of moved heartbeats.
+func RetryDeadLetters(filepath string, opts ...Option) (int, error) {
+	if s, ok := newStore(filepath, newConfig(opts)).(*SpoolStore); ok {
+		return s.RetryDeadLetters()
+	}
+
+	return updateDeadLetters(filepath, func(q *Queue) (int, error) {
+		return q.RetryDeadLetters()
+	})
+}
+
+// PurgeDeadLetters deletes all dead letters from the offline db, or from the
+// spool directory if configured. Returns the number of deleted heartbeats.
+func PurgeDeadLetters(filepath string, opts ...Option) (int, error) {
+	if s, ok := newStore(filepath, newConfig(opts)).(*SpoolStore); ok {
+		return s.PurgeDeadLetters()
+	}
+
+	return updateDeadLetters(filepath, func(q *Queue) (int, error) {
+		return q.PurgeDeadLetters()
+	})
+}
+
+func updateDeadLetters(filepath string, fn func(q *Queue) (int, error)) (int, error) {
+	db, err := openDB(filepath, &bolt.Options{Timeout: 30 * time.Second})
+	if err != nil {
+		return 0, fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	defer db.Close()
+
+	var count int
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		count, err = fn(NewQueue(tx))
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// PushDeadLetters stores the provided dead letters in the db.
+func (q *Queue) PushDeadLetters(entries []DeadLetter) error {
+	b, err := q.tx.CreateBucketIfNotExists([]byte(q.DeadLetterBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create/load bucket: %s", err)
+	}
+
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to json marshal dead letter: %s", err)
+		}
+
+		err = b.Put([]byte(entry.ID), data)
+		if err != nil {
+			return fmt.Errorf("failed to store dead letter with id %q: %s", entry.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// DeadLetters returns all dead letters in the db. Can be used within read
+// only transactions.
+func (q *Queue) DeadLetters() ([]DeadLetter, error) {
+	b := q.tx.Bucket([]byte(q.DeadLetterBucket))
+	if b == nil {
+		return nil, nil
+	}
+
+	var entries []DeadLetter
+
+	err := b.ForEach(func(key, value []byte) error {
+		var entry DeadLetter
+
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("failed to json unmarshal dead letter of key %q: %s", string(key), err)
+		}
+
+		entry.ID = string(key)
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// RetryDeadLetters moves all dead letters back into the queue.
+func (q *Queue) RetryDeadLetters() (int, error) {
+	entries, err := q.DeadLetters()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	hh := make([]heartbeat.Heartbeat, len(entries))
+	for i, entry := range entries {
+		hh[i] = entry.Heartbeat
+	}
+
+	if err := q.PushMany(hh); err != nil {
+		return 0, err
+	}
+
+	return q.PurgeDeadLetters()
+}
+
+// PurgeDeadLetters deletes all dead letters.
+func (q *Queue) PurgeDeadLetters() (int, error) {
+	b := q.tx.Bucket([]byte(q.DeadLetterBucket))
+	if b == nil {
+		return 0, nil
+	}
+
+	count := b.Stats().KeyN
+
+	if err := q.tx.DeleteBucket([]byte(q.DeadLetterBucket)); err != nil {
+		return 0, fmt.Errorf("failed to delete bucket: %s", err)
+	}
+
+	return count, nil
+}
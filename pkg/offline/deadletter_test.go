@@ -0,0 +1,134 @@
+package offline_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestQueue_PushDeadLetters(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	failedAt := time.Date(2022, 4, 10, 12, 0, 0, 0, time.UTC)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).PushDeadLetters([]offline.DeadLetter{
+			{
+				ID:        testHeartbeats()[0].ID(),
+				Heartbeat: testHeartbeats()[0],
+				Errors:    []string{"Invalid entity"},
+				Status:    http.StatusBadRequest,
+				FailedAt:  failedAt,
+			},
+		})
+	})
+	require.NoError(t, err)
+
+	var entries []offline.DeadLetter
+
+	err = db.View(func(tx *bolt.Tx) error {
+		entries, err = offline.NewQueue(tx).DeadLetters()
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []offline.DeadLetter{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: testHeartbeats()[0],
+			Errors:    []string{"Invalid entity"},
+			Status:    http.StatusBadRequest,
+			FailedAt:  failedAt,
+		},
+	}, entries)
+}
+
+func TestRetryDeadLetters(t *testing.T) {
+	f := setupDeadLetters(t)
+
+	count, err := offline.RetryDeadLetters(f)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, count)
+
+	entries, err := offline.ListDeadLetters(f)
+	require.NoError(t, err)
+
+	assert.Empty(t, entries)
+
+	records, err := offline.ListHeartbeats(f, offline.Filter{})
+	require.NoError(t, err)
+
+	require.Len(t, records, 2)
+	assert.Equal(t, testHeartbeats()[0], records[0].Heartbeat)
+	assert.Equal(t, testHeartbeats()[1], records[1].Heartbeat)
+}
+
+func TestPurgeDeadLetters(t *testing.T) {
+	f := setupDeadLetters(t)
+
+	count, err := offline.PurgeDeadLetters(f)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, count)
+
+	entries, err := offline.ListDeadLetters(f)
+	require.NoError(t, err)
+
+	assert.Empty(t, entries)
+
+	queued, err := offline.CountHeartbeats(f)
+	require.NoError(t, err)
+
+	assert.Zero(t, queued)
+}
+
+func TestPurgeDeadLetters_Empty(t *testing.T) {
+	db, cleanup := initDB(t)
+	path := db.Path()
+
+	cleanup()
+
+	count, err := offline.PurgeDeadLetters(path)
+	require.NoError(t, err)
+
+	assert.Zero(t, count)
+}
+
+func setupDeadLetters(t *testing.T) string {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).PushDeadLetters([]offline.DeadLetter{
+			{
+				ID:        testHeartbeats()[0].ID(),
+				Heartbeat: testHeartbeats()[0],
+				Status:    http.StatusBadRequest,
+			},
+			{
+				ID:        testHeartbeats()[1].ID(),
+				Heartbeat: testHeartbeats()[1],
+				Errors:    []string{"failed to requeue"},
+			},
+		})
+	})
+	require.NoError(t, err)
+
+	return f.Name()
+}
@@ -0,0 +1,359 @@
+package offline
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/log"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// errBrokenPage is returned, if bolt panicked on reading a broken db page.
+var errBrokenPage = errors.New("broken db page")
+
+// corruptSuffixFormat is the time format of the suffix appended to the
+// filepath of a broken db, when moved aside.
+const corruptSuffixFormat = "20060102T150405"
+
+// Repaired contains the result of repairing a broken offline db.
+type Repaired struct {
+	// MovedTo is the filepath, the broken db file has been moved to.
+	MovedTo string
+	// Salvaged is the number of records copied into the fresh db.
+	Salvaged int
+}
+
+// CheckDB checks the consistency of the bolt db at filepath and returns all
+// problems found. A db, which cannot be opened at all, is reported as problem
+// as well. A missing db file is not considered a problem. The db is opened
+// writable, so bolt loads and thereby validates the freelist.
+func CheckDB(filepath string) ([]string, error) {
+	if _, err := os.Stat(filepath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := openDB(filepath, &bolt.Options{Timeout: 30 * time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("failed to open db connection: %s", err)
+	}
+
+	if err != nil {
+		return []string{fmt.Sprintf("failed to open db: %s", err)}, nil
+	}
+
+	defer db.Close()
+
+	var names []string
+
+	err = safeView(db, func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, string(name))
+
+			return nil
+		})
+	})
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	var problems []string
+
+	for _, name := range names {
+		err := safeView(db, func(tx *bolt.Tx) error {
+			return checkBucket(tx.Bucket([]byte(name)))
+		})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("bucket %q: %s", name, err))
+		}
+	}
+
+	return problems, nil
+}
+
+// checkBucket reads all keys and values of a bucket and its nested buckets, so
+// every page of the bucket is visited. Unlike tx.Check, it runs in the calling
+// goroutine, so panics on broken pages can be recovered.
+func checkBucket(b *bolt.Bucket) error {
+	c := b.Cursor()
+
+	for key, value := c.First(); key != nil; key, value = c.Next() {
+		if value != nil {
+			continue
+		}
+
+		nested := b.Bucket(key)
+		if nested == nil {
+			continue
+		}
+
+		if err := checkBucket(nested); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RepairDB moves the broken bolt db at filepath aside and salvages all
+// readable records into a fresh db at filepath. Moving the file first ensures,
+// that concurrent processes do not repair the same file twice.
+func RepairDB(filepath string) (Repaired, error) {
+	movedTo := fmt.Sprintf("%s.corrupt-%s", filepath, time.Now().Format(corruptSuffixFormat))
+
+	if err := os.Rename(filepath, movedTo); err != nil {
+		if os.IsNotExist(err) {
+			// already repaired by another process
+			return Repaired{}, nil
+		}
+
+		return Repaired{}, fmt.Errorf("failed to move broken db aside: %s", err)
+	}
+
+	log.Warnf("moved broken offline db to %s", movedTo)
+
+	buckets, err := salvage(movedTo)
+	if err != nil {
+		return Repaired{MovedTo: movedTo}, err
+	}
+
+	db, err := openDB(filepath, &bolt.Options{Timeout: 10 * time.Minute})
+	if err != nil {
+		return Repaired{MovedTo: movedTo}, fmt.Errorf("failed to open fresh db: %s", err)
+	}
+
+	defer db.Close()
+
+	var salvaged int
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for name, records := range buckets {
+			b, err := tx.CreateBucketIfNotExists([]byte(name))
+			if err != nil {
+				return fmt.Errorf("failed to create/load bucket: %s", err)
+			}
+
+			for key, value := range records {
+				// keep records written since moving the broken db aside
+				if b.Get([]byte(key)) != nil {
+					continue
+				}
+
+				if err := b.Put([]byte(key), value); err != nil {
+					return fmt.Errorf("failed to store salvaged record %q: %s", key, err)
+				}
+
+				salvaged++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Repaired{MovedTo: movedTo}, fmt.Errorf("failed to store salvaged records: %s", err)
+	}
+
+	log.Infof("salvaged %d record(s) from broken offline db", salvaged)
+
+	return Repaired{MovedTo: movedTo, Salvaged: salvaged}, nil
+}
+
+// isCorrupted reports, whether an error returned on opening the db indicates
+// a broken db file.
+func isCorrupted(err error) bool {
+	return errors.Is(err, errBrokenPage) ||
+		errors.Is(err, bolt.ErrInvalid) ||
+		errors.Is(err, bolt.ErrVersionMismatch) ||
+		errors.Is(err, bolt.ErrChecksum)
+}
+
+// salvage reads all readable records of the known buckets from the db file at
+// filepath. Records are read via bolt if possible and by scanning the raw file
+// for json records otherwise.
+func salvage(filepath string) (map[string]map[string][]byte, error) {
+	buckets, err := salvageBuckets(filepath)
+	if err == nil {
+		return buckets, nil
+	}
+
+	log.Warnf("failed to read broken offline db, scanning raw file instead: %s", err)
+
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read broken db file: %s", err)
+	}
+
+	return scanRecords(data), nil
+}
+
+// salvageBuckets copies the records of the known buckets via bolt. Reading a
+// broken bucket might panic, so every bucket is read on its own.
+func salvageBuckets(filepath string) (map[string]map[string][]byte, error) {
+	db, err := openBolt(filepath, &bolt.Options{Timeout: 30 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	buckets := make(map[string]map[string][]byte)
+
+	for _, name := range []string{dbBucket, dbInFlightBucket, dbDeadLetterBucket, dbQuarantineBucket} {
+		records := make(map[string][]byte)
+
+		err := safeView(db, func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(name))
+			if b == nil {
+				return nil
+			}
+
+			return b.ForEach(func(key, value []byte) error {
+				records[string(key)] = append([]byte(nil), value...)
+
+				return nil
+			})
+		})
+		if err != nil {
+			log.Warnf("failed to read all records of bucket %q: %s", name, err)
+		}
+
+		if len(records) > 0 {
+			buckets[name] = records
+		}
+	}
+
+	return buckets, nil
+}
+
+// scanRecords searches raw db file contents for json encoded records and
+// assigns them to their bucket by their shape. Stale copies in freed pages
+// might resurrect already sent heartbeats, which is preferred over losing
+// queued ones.
+func scanRecords(data []byte) map[string]map[string][]byte {
+	buckets := make(map[string]map[string][]byte)
+
+	add := func(bucket, key string, value []byte) {
+		if buckets[bucket] == nil {
+			buckets[bucket] = make(map[string][]byte)
+		}
+
+		buckets[bucket][key] = value
+	}
+
+	for i := 0; i < len(data); i++ {
+		if data[i] != '{' {
+			continue
+		}
+
+		var raw json.RawMessage
+
+		if err := json.NewDecoder(bytes.NewReader(data[i:])).Decode(&raw); err != nil {
+			continue
+		}
+
+		var fields map[string]json.RawMessage
+
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			continue
+		}
+
+		switch {
+		case fields["leased_at"] != nil:
+			var l lease
+
+			if json.Unmarshal(raw, &l) != nil {
+				continue
+			}
+
+			h, err := decodeRecord(l.Heartbeat)
+			if err != nil || h.Entity == "" {
+				continue
+			}
+
+			add(dbInFlightBucket, h.ID(), append([]byte(nil), raw...))
+		case fields["failed_at"] != nil:
+			var entry DeadLetter
+
+			if json.Unmarshal(raw, &entry) != nil || entry.ID == "" {
+				continue
+			}
+
+			add(dbDeadLetterBucket, entry.ID, append([]byte(nil), raw...))
+		case fields["quarantined_at"] != nil:
+			var entry Quarantined
+
+			if json.Unmarshal(raw, &entry) != nil || entry.ID == "" {
+				continue
+			}
+
+			add(dbQuarantineBucket, entry.ID, append([]byte(nil), raw...))
+		default:
+			h, err := decodeRecord(raw)
+			if err != nil || h.Entity == "" || h.Time == 0 {
+				continue
+			}
+
+			add(dbBucket, h.ID(), append([]byte(nil), raw...))
+		}
+
+		// skip nested records
+		i += len(raw) - 1
+	}
+
+	return buckets
+}
+
+// openBolt opens the bolt db at filepath and turns panics, raised by bolt on
+// reading a broken freelist page, into errors. The file lock is released in
+// that case, so the broken db can be repaired. The memory mapping of the db
+// file cannot be released, as the db is not returned by bolt.
+func openBolt(filepath string, options *bolt.Options) (db *bolt.DB, err error) {
+	opts := *bolt.DefaultOptions
+	if options != nil {
+		opts = *options
+	}
+
+	var f *os.File
+
+	opts.OpenFile = func(name string, flag int, perm os.FileMode) (*os.File, error) {
+		var err error
+
+		f, err = os.OpenFile(name, flag, perm)
+
+		return f, err
+	}
+
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+
+	defer func() {
+		if r := recover(); r != nil {
+			if f != nil {
+				unlockFile(f)
+				_ = f.Close()
+			}
+
+			db, err = nil, fmt.Errorf("%w: %v", errBrokenPage, r)
+		}
+	}()
+
+	return bolt.Open(filepath, 0600, &opts)
+}
+
+// safeView runs fn within a read only transaction and turns panics and memory
+// faults, raised by bolt on reading broken pages, into errors.
+func safeView(db *bolt.DB, fn func(tx *bolt.Tx) error) (err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errBrokenPage, r)
+		}
+	}()
+
+	return db.View(fn)
+}
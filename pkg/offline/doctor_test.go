@@ -0,0 +1,206 @@
+package offline_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestCheckDB(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	_ = pushHeartbeats(t, fp, 10)
+
+	problems, err := offline.CheckDB(fp)
+	require.NoError(t, err)
+
+	assert.Empty(t, problems)
+}
+
+func TestCheckDB_FileNotExists(t *testing.T) {
+	problems, err := offline.CheckDB(filepath.Join(t.TempDir(), "nonexisting"))
+	require.NoError(t, err)
+
+	assert.Empty(t, problems)
+}
+
+func TestCheckDB_Broken(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	_ = pushHeartbeats(t, fp, 10)
+
+	breakMetaPages(t, fp)
+
+	problems, err := offline.CheckDB(fp)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, problems)
+}
+
+func TestCheckDB_BrokenPages(t *testing.T) {
+	tests := map[string]struct {
+		PageType string
+	}{
+		"data pages": {
+			PageType: "leaf",
+		},
+		"freelist page": {
+			PageType: "freelist",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+			_ = pushHeartbeats(t, fp, 100)
+
+			breakPages(t, fp, test.PageType)
+
+			problems, err := offline.CheckDB(fp)
+			require.NoError(t, err)
+
+			assert.NotEmpty(t, problems)
+		})
+	}
+}
+
+func TestRepairDB(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	pushed := pushHeartbeats(t, fp, 10)
+
+	breakMetaPages(t, fp)
+
+	repaired, err := offline.RepairDB(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, 10, repaired.Salvaged)
+	assert.FileExists(t, repaired.MovedTo)
+	assert.Contains(t, repaired.MovedTo, fp+".corrupt-")
+
+	db, err := bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Equal(t, pushed, bucketIDs(t, db, "heartbeats"))
+}
+
+func TestBoltStore_Push_Broken(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	pushed := pushHeartbeats(t, fp, 2)
+
+	breakMetaPages(t, fp)
+
+	h := heartbeatAt(time.Now())
+
+	err := offline.NewBoltStore(fp).Push([]heartbeat.Heartbeat{h})
+	require.NoError(t, err)
+
+	db, err := bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Equal(t, append(pushed, h.ID()), bucketIDs(t, db, "heartbeats"))
+
+	moved, err := filepath.Glob(fp + ".corrupt-*")
+	require.NoError(t, err)
+
+	assert.Len(t, moved, 1)
+}
+
+func TestBoltStore_Push_BrokenFreelist(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	pushed := pushHeartbeats(t, fp, 100)
+
+	breakPages(t, fp, "freelist")
+
+	h := heartbeatAt(time.Now())
+
+	err := offline.NewBoltStore(fp).Push([]heartbeat.Heartbeat{h})
+	require.NoError(t, err)
+
+	db, err := bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Equal(t, append(pushed, h.ID()), bucketIDs(t, db, "heartbeats"))
+
+	moved, err := filepath.Glob(fp + ".corrupt-*")
+	require.NoError(t, err)
+
+	assert.Len(t, moved, 1)
+}
+
+// breakPages overwrites all pages of the passed in type of a bolt db with 0xff.
+func breakPages(t *testing.T, fp string, pageType string) {
+	t.Helper()
+
+	db, err := bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	var ids []int
+
+	err = db.View(func(tx *bolt.Tx) error {
+		for id := 0; ; id++ {
+			info, err := tx.Page(id)
+			if err != nil {
+				return err
+			}
+
+			if info == nil {
+				return nil
+			}
+
+			if info.Type == pageType {
+				ids = append(ids, id)
+			}
+		}
+	})
+	require.NoError(t, err)
+
+	pageSize := db.Info().PageSize
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	require.NotEmpty(t, ids)
+
+	f, err := os.OpenFile(fp, os.O_WRONLY, 0600)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	for _, id := range ids {
+		_, err = f.WriteAt(bytes.Repeat([]byte{0xff}, pageSize), int64(id*pageSize))
+		require.NoError(t, err)
+	}
+}
+
+// breakMetaPages overwrites both meta pages of a bolt db, so it cannot be
+// opened anymore.
+func breakMetaPages(t *testing.T, fp string) {
+	t.Helper()
+
+	f, err := os.OpenFile(fp, os.O_WRONLY, 0600)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	_, err = f.WriteAt(make([]byte, 2*os.Getpagesize()), 0)
+	require.NoError(t, err)
+}
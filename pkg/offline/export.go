@@ -0,0 +1,68 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+)
+
+// ExportHeartbeats writes all queued heartbeats in the offline queue to w as
+// newline delimited json and returns the number of exported heartbeats.
+// Undecodable heartbeats are skipped.
+func ExportHeartbeats(filepath string, w io.Writer, opts ...Option) (int, error) {
+	records, err := newStore(filepath, newConfig(opts)).List(Filter{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to export offline heartbeats: %s", err)
+	}
+
+	enc := json.NewEncoder(w)
+
+	for i, r := range records {
+		if err := enc.Encode(r.Heartbeat); err != nil {
+			return i, fmt.Errorf("failed to write heartbeat with key %q: %s", r.ID, err)
+		}
+	}
+
+	return len(records), nil
+}
+
+// ImportHeartbeats pushes the passed in heartbeats to the offline queue and
+// returns the number of heartbeats, which have not been queued before.
+// Heartbeats are deduplicated by their ID.
+func ImportHeartbeats(filepath string, hh []heartbeat.Heartbeat, opts ...Option) (int, error) {
+	store := newStore(filepath, newConfig(opts))
+
+	queued, err := store.List(Filter{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to import heartbeats: %s", err)
+	}
+
+	seen := make(map[string]bool, len(queued))
+	for _, r := range queued {
+		seen[r.ID] = true
+	}
+
+	var imported int
+
+	for _, h := range hh {
+		if h.EntityRaw != "" {
+			h.Entity = h.EntityRaw
+		}
+
+		id := h.ID()
+		if seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		imported++
+	}
+
+	if err := store.Push(hh); err != nil {
+		return 0, fmt.Errorf("failed to import heartbeats: %s", err)
+	}
+
+	return imported, nil
+}
@@ -0,0 +1,109 @@
+package offline_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestExportHeartbeats(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	dataPy, err := os.ReadFile("testdata/heartbeat_py.json")
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "heartbeats", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(dataGo),
+		},
+		{
+			ID:        "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+			Heartbeat: string(dataPy),
+		},
+	})
+
+	db.Close()
+
+	var buf bytes.Buffer
+
+	count, err := offline.ExportHeartbeats(f.Name(), &buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, count)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	assert.JSONEq(t, string(dataGo), lines[0])
+	assert.JSONEq(t, string(dataPy), lines[1])
+}
+
+func TestExportHeartbeats_FileNotExists(t *testing.T) {
+	var buf bytes.Buffer
+
+	count, err := offline.ExportHeartbeats(filepath.Join(t.TempDir(), "nonexisting"), &buf)
+	require.NoError(t, err)
+
+	assert.Zero(t, count)
+	assert.Empty(t, buf.String())
+}
+
+func TestImportHeartbeats(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "heartbeats", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(dataGo),
+		},
+	})
+
+	db.Close()
+
+	imported, err := offline.ImportHeartbeats(f.Name(), []heartbeat.Heartbeat{
+		testHeartbeats()[0],
+		testHeartbeats()[1],
+		testHeartbeats()[1],
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, imported)
+
+	db, err = bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Equal(t, []string{
+		"1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		"1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+	}, bucketIDs(t, db, "heartbeats"))
+}
@@ -0,0 +1,71 @@
+package offline
+
+import (
+	"strings"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+)
+
+// Record is a heartbeat stored in the offline queue along with its key.
+type Record struct {
+	ID        string
+	Heartbeat heartbeat.Heartbeat
+}
+
+// Filter contains the criteria used to select heartbeats from the offline queue.
+// Empty values match all heartbeats.
+type Filter struct {
+	// ApiKey matches heartbeats, which will be sent using this api key.
+	ApiKey string
+	// ApiKeys is used to resolve the api key of a heartbeat, as api keys
+	// are not stored in the offline queue.
+	ApiKeys apikey.Config
+	// Entity matches heartbeats with an entity containing this value.
+	Entity string
+	// Project matches heartbeats of this project.
+	Project string
+	// Start matches heartbeats at or after this time.
+	Start time.Time
+	// End matches heartbeats at or before this time.
+	End time.Time
+	// Offset is the number of matching heartbeats to skip.
+	Offset int
+	// Limit is the maximum number of heartbeats to return. Zero means no limit.
+	Limit int
+}
+
+// Match returns true, if the heartbeat matches all criteria of the filter.
+func (f Filter) Match(h heartbeat.Heartbeat) bool {
+	if f.ApiKey != "" && h.ApiKey != f.ApiKey {
+		return false
+	}
+
+	if f.Entity != "" && !strings.Contains(h.Entity, f.Entity) {
+		return false
+	}
+
+	if f.Project != "" && (h.Project == nil || *h.Project != f.Project) {
+		return false
+	}
+
+	t := heartbeatTime(h)
+
+	if !f.Start.IsZero() && t.Before(f.Start) {
+		return false
+	}
+
+	if !f.End.IsZero() && t.After(f.End) {
+		return false
+	}
+
+	return true
+}
+
+// heartbeatTime converts the heartbeat's unix epoch timestamp into time.Time.
+func heartbeatTime(h heartbeat.Heartbeat) time.Time {
+	sec := int64(h.Time)
+
+	return time.Unix(sec, int64((h.Time-float64(sec))*float64(time.Second)))
+}
@@ -0,0 +1,75 @@
+package offline_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Match(t *testing.T) {
+	h := heartbeat.Heartbeat{
+		ApiKey:  "00000000-0000-4000-8000-000000000000",
+		Entity:  "/tmp/main.go",
+		Project: heartbeat.PointerTo("wakatime-cli"),
+		Time:    1592868367.219124,
+	}
+
+	tests := map[string]struct {
+		Filter   offline.Filter
+		Expected bool
+	}{
+		"empty filter": {
+			Expected: true,
+		},
+		"api key match": {
+			Filter:   offline.Filter{ApiKey: "00000000-0000-4000-8000-000000000000"},
+			Expected: true,
+		},
+		"api key mismatch": {
+			Filter: offline.Filter{ApiKey: "00000000-0000-4000-8000-000000000001"},
+		},
+		"entity substring": {
+			Filter:   offline.Filter{Entity: "main"},
+			Expected: true,
+		},
+		"entity mismatch": {
+			Filter: offline.Filter{Entity: "main.py"},
+		},
+		"project match": {
+			Filter:   offline.Filter{Project: "wakatime-cli"},
+			Expected: true,
+		},
+		"project mismatch": {
+			Filter: offline.Filter{Project: "wakatime"},
+		},
+		"within time range": {
+			Filter: offline.Filter{
+				Start: time.Unix(1592868367, 0),
+				End:   time.Unix(1592868368, 0),
+			},
+			Expected: true,
+		},
+		"before start": {
+			Filter: offline.Filter{Start: time.Unix(1592868368, 0)},
+		},
+		"after end": {
+			Filter: offline.Filter{End: time.Unix(1592868367, 0)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, test.Filter.Match(h))
+		})
+	}
+}
+
+func TestFilter_Match_NoProject(t *testing.T) {
+	f := offline.Filter{Project: "wakatime"}
+
+	assert.False(t, f.Match(heartbeat.Heartbeat{Entity: "/tmp/main.go"}))
+}
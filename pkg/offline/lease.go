@@ -0,0 +1,120 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// dbInFlightBucket is the bolt db bucket name for heartbeats, which were
+	// popped from the queue, but not yet acknowledged.
+	dbInFlightBucket = "in_flight"
+	// leaseTimeout is the duration after which an unacknowledged heartbeat is
+	// considered lost, e.g. due to a crash, and moved back to the queue.
+	leaseTimeout = 15 * time.Minute
+)
+
+// lease is the in-flight bucket representation of a popped heartbeat.
+type lease struct {
+	LeasedAt  time.Time       `json:"leased_at"`
+	Heartbeat json.RawMessage `json:"heartbeat"`
+}
+
+// Ack deletes leased heartbeats with the specified ids from the in-flight bucket.
+func (q *Queue) Ack(ids []string) error {
+	inFlight, err := q.tx.CreateBucketIfNotExists([]byte(q.InFlightBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create/load bucket: %s", err)
+	}
+
+	for _, id := range ids {
+		if err := inFlight.Delete([]byte(id)); err != nil {
+			return fmt.Errorf("failed to delete key %q: %s", id, err)
+		}
+	}
+
+	return nil
+}
+
+// Release moves leased heartbeats with the specified ids back to the queue.
+func (q *Queue) Release(ids []string) error {
+	inFlight, err := q.tx.CreateBucketIfNotExists([]byte(q.InFlightBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create/load bucket: %s", err)
+	}
+
+	for _, id := range ids {
+		value := inFlight.Get([]byte(id))
+		if value == nil {
+			continue
+		}
+
+		if err := q.release(id, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReleaseExpired moves leased heartbeats, which were leased longer ago
+// than timeout, back to the queue. Returns the number of moved heartbeats.
+func (q *Queue) ReleaseExpired(timeout time.Duration) (int, error) {
+	inFlight, err := q.tx.CreateBucketIfNotExists([]byte(q.InFlightBucket))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create/load bucket: %s", err)
+	}
+
+	expired := make(map[string][]byte)
+
+	err = inFlight.ForEach(func(key, value []byte) error {
+		var l lease
+
+		if err := json.Unmarshal(value, &l); err != nil {
+			return fmt.Errorf("failed to json unmarshal lease of key %q: %s", string(key), err)
+		}
+
+		if time.Since(l.LeasedAt) > timeout {
+			expired[string(key)] = value
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for id, value := range expired {
+		if err := q.release(id, value); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(expired), nil
+}
+
+func (q *Queue) release(id string, value []byte) error {
+	var l lease
+
+	if err := json.Unmarshal(value, &l); err != nil {
+		return fmt.Errorf("failed to json unmarshal lease of key %q: %s", id, err)
+	}
+
+	b, err := q.tx.CreateBucketIfNotExists([]byte(q.Bucket))
+	if err != nil {
+		return fmt.Errorf("failed to create/load bucket: %s", err)
+	}
+
+	if err := b.Put([]byte(id), l.Heartbeat); err != nil {
+		return fmt.Errorf("failed to requeue key %q: %s", id, err)
+	}
+
+	inFlight := q.tx.Bucket([]byte(q.InFlightBucket))
+
+	if err := inFlight.Delete([]byte(id)); err != nil {
+		return fmt.Errorf("failed to delete key %q: %s", id, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package offline_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestQueue_Ack(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	insertLeaseRecord(t, db, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		string(dataGo), time.Now())
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).Ack([]string{"1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true"})
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, readBucket(t, db, "in_flight"))
+	assert.Empty(t, readBucket(t, db, "heartbeats"))
+}
+
+func TestQueue_Release(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	insertLeaseRecord(t, db, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		string(dataGo), time.Now())
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).Release([]string{
+			"1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			"unknown",
+		})
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, readBucket(t, db, "in_flight"))
+
+	stored := readBucket(t, db, "heartbeats")
+
+	require.Len(t, stored, 1)
+	assert.Equal(t, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true", stored[0].ID)
+	assert.JSONEq(t, string(dataGo), stored[0].Heartbeat)
+}
+
+func TestQueue_ReleaseExpired(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	dataPy, err := os.ReadFile("testdata/heartbeat_py.json")
+	require.NoError(t, err)
+
+	insertLeaseRecord(t, db, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		string(dataGo), time.Now().Add(-time.Hour))
+	insertLeaseRecord(t, db, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+		string(dataPy), time.Now())
+
+	var released int
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		released, err = offline.NewQueue(tx).ReleaseExpired(15 * time.Minute)
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, released)
+
+	stored := readBucket(t, db, "heartbeats")
+
+	require.Len(t, stored, 1)
+	assert.Equal(t, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true", stored[0].ID)
+
+	leased := readBucket(t, db, "in_flight")
+
+	require.Len(t, leased, 1)
+	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", leased[0].ID)
+}
+
+func TestSync_ExpiredLease(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	// simulate a crash of a previous run after popping heartbeats
+	insertLeaseRecord(t, db, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		string(dataGo), time.Now().Add(-time.Hour))
+
+	db.Close()
+
+	var numCalls int
+
+	syncFn := offline.Sync(f.Name(), 10)
+
+	err = syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		numCalls++
+
+		assert.Equal(t, []heartbeat.Heartbeat{testHeartbeats()[0]}, hh)
+
+		return []heartbeat.Result{
+			{
+				Status:    http.StatusCreated,
+				Heartbeat: testHeartbeats()[0],
+			},
+		}, nil
+	})
+	require.NoError(t, err)
+
+	db, err = bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Empty(t, readBucket(t, db, "heartbeats"))
+	assert.Empty(t, readBucket(t, db, "in_flight"))
+	assert.Equal(t, 1, numCalls)
+}
+
+func TestSync_ActiveLeaseNotResent(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	// heartbeats currently being sent by another process
+	insertLeaseRecord(t, db, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		string(dataGo), time.Now())
+
+	db.Close()
+
+	var numCalls int
+
+	syncFn := offline.Sync(f.Name(), 10)
+
+	err = syncFn(context.Background(), func(_ context.Context, _ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		numCalls++
+
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	assert.Zero(t, numCalls)
+}
+
+func insertLeaseRecord(t *testing.T, db *bolt.DB, id, data string, leasedAt time.Time) {
+	t.Helper()
+
+	value, err := json.Marshal(struct {
+		LeasedAt  time.Time       `json:"leased_at"`
+		Heartbeat json.RawMessage `json:"heartbeat"`
+	}{
+		LeasedAt:  leasedAt,
+		Heartbeat: json.RawMessage(data),
+	})
+	require.NoError(t, err)
+
+	insertHeartbeatRecord(t, db, "in_flight", heartbeatRecord{
+		ID:        id,
+		Heartbeat: string(value),
+	})
+}
@@ -1,14 +1,17 @@
 package offline
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
 	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
 	"github.com/wakatime/wakatime-cli/pkg/ini"
 	"github.com/wakatime/wakatime-cli/pkg/log"
@@ -32,11 +35,40 @@ const (
 	SyncMaxDefault = 1000
 )
 
+// Option is a functional option to configure the offline queue.
+type Option func(*config)
+
+type config struct {
+	collapseWindow time.Duration
+	retention      Retention
+	spool          bool
+	spoolDir       string
+	syncWorkers    int
+}
+
+// WithRetention sets the retention policy, which is enforced whenever
+// heartbeats are pushed to or synced from the offline queue.
+func WithRetention(r Retention) Option {
+	return func(c *config) {
+		c.retention = r
+	}
+}
+
+func newConfig(opts []Option) config {
+	var c config
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
 // Sender is a noop api client, used by offline.SaveHeartbeats.
 type Sender struct{}
 
 // SendHeartbeats always returns an error.
-func (Sender) SendHeartbeats(_ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+func (Sender) SendHeartbeats(_ context.Context, _ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 	return nil, api.Err("skip sending heartbeats and only save to offline db")
 }
 
@@ -46,9 +78,11 @@ func (Sender) SendHeartbeats(_ []heartbeat.Heartbeat) ([]heartbeat.Result, error
 // failing connection to API, failed sending or errors returned by API, the
 // heartbeats will be temporarily stored in a DB and sending will be retried
 // at next usages of the wakatime cli.
-func WithQueue(filepath string) heartbeat.HandleOption {
+func WithQueue(filepath string, opts ...Option) heartbeat.HandleOption {
+	cfg := newConfig(opts)
+
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			log.Debugf("execute offline queue with file %s", filepath)
 
 			if len(hh) == 0 {
@@ -57,11 +91,13 @@ func WithQueue(filepath string) heartbeat.HandleOption {
 				return nil, nil
 			}
 
-			results, err := next(hh)
+			store := newStore(filepath, cfg)
+
+			results, err := next(ctx, hh)
 			if err != nil {
 				log.Debugf("pushing %d heartbeat(s) to queue due to error", len(hh))
 
-				requeueErr := pushHeartbeatsWithRetry(filepath, hh)
+				requeueErr := pushHeartbeatsWithRetry(store, hh)
 				if requeueErr != nil {
 					return nil, ErrOfflineEnqueue(fmt.Sprintf(
 						"failed to push heatbeats to queue after api error: %s. error: %s",
@@ -72,7 +108,7 @@ func WithQueue(filepath string) heartbeat.HandleOption {
 				return nil, err
 			}
 
-			err = handleResults(filepath, results, hh)
+			err = handleResults(store, results, hh)
 			if err != nil {
 				return nil, fmt.Errorf("failed to handle results: %s", err)
 			}
@@ -97,12 +133,12 @@ func QueueFilepath() (string, error) {
 // WithSync initializes and returns a heartbeat handle option, which
 // can be used in a heartbeat processing pipeline to pop heartbeats
 // from offline queue and send the heartbeats to WakaTime API.
-func WithSync(filepath string, syncLimit int) heartbeat.HandleOption {
+func WithSync(filepath string, syncLimit int, opts ...Option) heartbeat.HandleOption {
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(_ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, _ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			log.Debugf("execute offline sync with file %s", filepath)
 
-			err := Sync(filepath, syncLimit)(next)
+			err := Sync(filepath, syncLimit, opts...)(ctx, next)
 			if err != nil {
 				return nil, fmt.Errorf("failed to sync offline heartbeats: %s", err)
 			}
@@ -112,64 +148,118 @@ func WithSync(filepath string, syncLimit int) heartbeat.HandleOption {
 	}
 }
 
-// Sync returns a function to send queued heartbeats to the WakaTime API.
-func Sync(filepath string, syncLimit int) func(next heartbeat.Handle) error {
-	return func(next heartbeat.Handle) error {
-		var (
-			alreadySent int
-			run         int
-		)
+// Sync returns a function to send queued heartbeats to the WakaTime API. With
+// more than one sync worker configured, batches are sent concurrently. Every
+// batch is acknowledged or requeued on its own. An authentication error stops
+// all workers and throttling signaled by the api delays further requests of
+// all workers. Once the context is done, no further batches are sent.
+func Sync(filepath string, syncLimit int, opts ...Option) func(ctx context.Context, next heartbeat.Handle) error {
+	cfg := newConfig(opts)
+	store := newStore(filepath, cfg)
+
+	workers := cfg.syncWorkers
+	if workers < 1 {
+		workers = SyncWorkersDefault
+	}
 
-		for {
-			run++
+	return func(ctx context.Context, next heartbeat.Handle) error {
+		state := newSyncState(syncLimit)
 
-			if alreadySent >= syncLimit {
-				break
-			}
+		var wg sync.WaitGroup
 
-			var num = SendLimit
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
 
-			if alreadySent+SendLimit > syncLimit {
-				num = syncLimit - alreadySent
-				alreadySent += num
-			}
+			go func(worker int) {
+				defer wg.Done()
 
-			hh, err := popHeartbeats(filepath, num)
-			if err != nil {
-				return fmt.Errorf("failed to fetch heartbeat from offline queue: %s", err)
-			}
+				syncWorker(ctx, worker, store, state, next)
+			}(i + 1)
+		}
 
-			if len(hh) == 0 {
-				log.Debugln("no queued heartbeats ready for sending")
+		wg.Wait()
 
-				break
-			}
+		if state.err == nil && ctx.Err() != nil {
+			return fmt.Errorf("sync aborted: %w", ctx.Err())
+		}
 
-			log.Debugf("send %d heartbeats on sync run %d", len(hh), run)
+		return state.err
+	}
+}
 
-			results, err := next(hh)
-			if err != nil {
-				requeueErr := pushHeartbeatsWithRetry(filepath, hh)
-				if requeueErr != nil {
-					log.Warnf("failed to push heatbeats to queue after api error: %s", requeueErr)
-				}
+// syncWorker pops and sends batches of queued heartbeats, until the sync limit
+// has been reached, the queue is empty or an error occurred.
+func syncWorker(ctx context.Context, worker int, store QueueStore, state *syncState, next heartbeat.Handle) {
+	for run := 1; ; run++ {
+		if !state.wait(ctx) {
+			return
+		}
 
-				return err
-			}
+		num := state.claim()
+		if num == 0 {
+			return
+		}
 
-			err = handleResults(filepath, results, hh)
-			if err != nil {
-				return fmt.Errorf("failed to handle heatbeats api results: %s", err)
+		records, err := store.Pop(num)
+		if err != nil {
+			state.fail(fmt.Errorf("failed to fetch heartbeat from offline queue: %s", err))
+
+			return
+		}
+
+		state.unclaim(num - len(records))
+
+		if len(records) == 0 {
+			log.Debugln("no queued heartbeats ready for sending")
+
+			return
+		}
+
+		hh, ids := splitRecords(records)
+
+		log.Debugf("send %d heartbeats on sync run %d of worker %d", len(hh), run, worker)
+
+		results, err := next(ctx, hh)
+		if err != nil {
+			releaseErr := store.Release(ids)
+			if releaseErr != nil {
+				log.Warnf(
+					"failed to release heartbeats to queue after api error, will be requeued once lease expired: %s",
+					releaseErr,
+				)
 			}
+
+			state.fail(err)
+
+			return
 		}
 
-		return nil
+		if isThrottled(results) {
+			state.throttle()
+		} else {
+			state.resetThrottle()
+		}
+
+		err = handleResults(store, results, hh)
+		if err != nil {
+			state.fail(fmt.Errorf("failed to handle heatbeats api results: %s", err))
+
+			return
+		}
+
+		err = store.Delete(ids)
+		if err != nil {
+			state.fail(fmt.Errorf("failed to acknowledge sent heartbeats: %s", err))
+
+			return
+		}
 	}
 }
 
-func handleResults(filepath string, results []heartbeat.Result, hh []heartbeat.Heartbeat) error {
+func handleResults(store QueueStore, results []heartbeat.Result, hh []heartbeat.Heartbeat) error {
 	var (
 		err               error
+		rejected          []DeadLetter
 		withInvalidStatus []heartbeat.Heartbeat
 	)
 
@@ -181,17 +271,19 @@ func handleResults(filepath string, results []heartbeat.Result, hh []heartbeat.H
 		}
 
 		if result.Status == http.StatusBadRequest {
-			serialized, jsonErr := json.Marshal(result.Heartbeat)
+			serialized, jsonErr := json.Marshal(hh[n])
 			if jsonErr != nil {
 				log.Warnf(
 					"failed to json marshal heartbeat: %s. heartbeat: %#v",
 					jsonErr,
-					result.Heartbeat,
+					hh[n],
 				)
 			}
 
 			log.Debugf("heartbeat result status bad request: %s", string(serialized))
 
+			rejected = append(rejected, newDeadLetters(hh[n:n+1], result.Status, result.Errors...)...)
+
 			continue
 		}
 
@@ -201,10 +293,18 @@ func handleResults(filepath string, results []heartbeat.Result, hh []heartbeat.H
 		}
 	}
 
+	if len(rejected) > 0 {
+		log.Debugf("moving %d rejected heartbeat(s) to dead letter bucket", len(rejected))
+
+		if dlErr := store.PushDeadLetters(rejected); dlErr != nil {
+			log.Warnf("failed to push rejected heartbeats to dead letter bucket: %s", dlErr)
+		}
+	}
+
 	if len(withInvalidStatus) > 0 {
 		log.Debugf("pushing %d heartbeat(s) with invalid result to queue", len(withInvalidStatus))
 
-		err = pushHeartbeatsWithRetry(filepath, withInvalidStatus)
+		err = pushHeartbeatsWithRetry(store, withInvalidStatus)
 		if err != nil {
 			log.Warnf("failed to push heatbeats with invalid status to queue: %s", err)
 		}
@@ -217,7 +317,7 @@ func handleResults(filepath string, results []heartbeat.Result, hh []heartbeat.H
 
 		start := len(hh) - leftovers
 
-		err = pushHeartbeatsWithRetry(filepath, hh[start:])
+		err = pushHeartbeatsWithRetry(store, hh[start:])
 		if err != nil {
 			log.Warnf("failed to push leftover heatbeats to queue: %s", err)
 		}
@@ -226,39 +326,23 @@ func handleResults(filepath string, results []heartbeat.Result, hh []heartbeat.H
 	return err
 }
 
-func popHeartbeats(filepath string, limit int) ([]heartbeat.Heartbeat, error) {
-	db, err := bolt.Open(filepath, 0600, &bolt.Options{Timeout: 10 * time.Minute})
-	if err != nil {
-		return nil, fmt.Errorf("failed to open db connection: %s", err)
-	}
-
-	defer db.Close()
-
-	tx, err := db.Begin(true)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start db transaction: %s", err)
-	}
-
-	queue := NewQueue(tx)
-
-	queued, err := queue.PopMany(limit)
-	if err != nil {
-		errrb := tx.Rollback()
-		if errrb != nil {
-			log.Errorf("failed to rollback transaction: %s", errrb)
-		}
+func splitRecords(records []Record) ([]heartbeat.Heartbeat, []string) {
+	hh := make([]heartbeat.Heartbeat, len(records))
+	ids := make([]string, len(records))
 
-		return nil, fmt.Errorf("failed to pop heartbeat(s) from queue: %s", err)
+	for i, r := range records {
+		hh[i] = r.Heartbeat
+		ids[i] = r.ID
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit db transaction: %s", err)
-	}
-
-	return queued, nil
+	return hh, ids
 }
 
-func pushHeartbeatsWithRetry(filepath string, hh []heartbeat.Heartbeat) error {
+// pushHeartbeatsWithRetry pushes heartbeats back to the queue. After
+// maxRequeueAttempts failed attempts, heartbeats are moved to the dead letter
+// bucket instead. Both only wait shortly for the db file lock and fall back to
+// the spool, so a db locked by another process never blocks the run.
+func pushHeartbeatsWithRetry(store QueueStore, hh []heartbeat.Heartbeat) error {
 	var (
 		count int
 		err   error
@@ -271,6 +355,11 @@ func pushHeartbeatsWithRetry(filepath string, hh []heartbeat.Heartbeat) error {
 				log.Warnf("failed to json marshal heartbeats: %s. heartbeats: %#v", jsonErr, hh)
 			}
 
+			dlErr := store.PushDeadLetters(newDeadLetters(hh, 0, fmt.Sprintf("failed to requeue: %s", err)))
+			if dlErr != nil {
+				log.Warnf("failed to push heartbeats to dead letter bucket: %s", dlErr)
+			}
+
 			return fmt.Errorf(
 				"abort requeuing after %d unsuccessful attempts: %s. heartbeats: %s",
 				count,
@@ -279,7 +368,7 @@ func pushHeartbeatsWithRetry(filepath string, hh []heartbeat.Heartbeat) error {
 			)
 		}
 
-		err = pushHeartbeats(filepath, hh)
+		err = store.Push(hh)
 		if err != nil {
 			count++
 
@@ -296,120 +385,113 @@ func pushHeartbeatsWithRetry(filepath string, hh []heartbeat.Heartbeat) error {
 	return nil
 }
 
-func pushHeartbeats(filepath string, hh []heartbeat.Heartbeat) error {
-	db, err := bolt.Open(filepath, 0600, &bolt.Options{Timeout: 10 * time.Minute})
-	if err != nil {
-		return fmt.Errorf("failed to open db connection: %s", err)
-	}
-
-	defer db.Close()
-
-	tx, err := db.Begin(true)
-	if err != nil {
-		return fmt.Errorf("failed to start db transaction: %s", err)
-	}
-
-	queue := NewQueue(tx)
-
-	err = queue.PushMany(hh)
-	if err != nil {
-		return fmt.Errorf("failed to push heartbeat(s) to queue: %s", err)
-	}
-
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit db transaction: %s", err)
-	}
-
-	return nil
+// CountHeartbeats returns the total number of heartbeats in the offline queue.
+func CountHeartbeats(filepath string, opts ...Option) (int, error) {
+	return newStore(filepath, newConfig(opts)).Count()
 }
 
-// CountHeartbeats returns the total number of heartbeats in the offline db.
-func CountHeartbeats(filepath string) (int, error) {
-	db, err := bolt.Open(filepath, 0600, &bolt.Options{Timeout: 30 * time.Second})
-	if err != nil {
-		return 0, fmt.Errorf("failed to open db connection: %s", err)
-	}
-
-	defer db.Close()
-
-	tx, err := db.Begin(true)
-	if err != nil {
-		return 0, fmt.Errorf("failed to start db transaction: %s", err)
-	}
-
-	queue := NewQueue(tx)
-
-	count, err := queue.Count()
-	if err != nil {
-		log.Errorf("failed to count offline heartbeats: %s", err)
-
-		_ = tx.Rollback()
-
-		return count, err
-	}
-
-	err = tx.Rollback()
-	if err != nil {
-		log.Warnf("failed to rollback transaction: %s", err)
-	}
-
-	return count, nil
+// ListHeartbeats returns the heartbeats in the offline queue matching the
+// passed in filter.
+func ListHeartbeats(filepath string, filter Filter, opts ...Option) ([]Record, error) {
+	return newStore(filepath, newConfig(opts)).List(filter)
 }
 
 // Queue is a db client to temporarily store heartbeats in bolt db, in case heartbeat
 // sending to wakatime api is not possible. Transaction handling is left to the user
-// via the passed in transaction.
+// via the passed in transaction. If a retention policy is set, it is enforced
+// after pushing heartbeats.
 type Queue struct {
-	Bucket string
-	tx     *bolt.Tx
+	Bucket           string
+	DeadLetterBucket string
+	InFlightBucket   string
+	QuarantineBucket string
+	Retention        Retention
+	tx               *bolt.Tx
 }
 
 // NewQueue creates a new instance of Queue.
 func NewQueue(tx *bolt.Tx) *Queue {
 	return &Queue{
-		Bucket: dbBucket,
-		tx:     tx,
+		Bucket:           dbBucket,
+		DeadLetterBucket: dbDeadLetterBucket,
+		InFlightBucket:   dbInFlightBucket,
+		QuarantineBucket: dbQuarantineBucket,
+		tx:               tx,
 	}
 }
 
-// PopMany retrieves heartbeats with the specified ids from db.
-func (q *Queue) PopMany(limit int) ([]heartbeat.Heartbeat, error) {
+// PopMany moves up to limit heartbeats from the queue into the in-flight bucket
+// and returns them. Leased heartbeats have to be either acknowledged via Ack
+// after successful sending, or moved back to the queue via Release.
+func (q *Queue) PopMany(limit int) ([]Record, error) {
 	b, err := q.tx.CreateBucketIfNotExists([]byte(q.Bucket))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create/load bucket: %s", err)
 	}
 
+	inFlight, err := q.tx.CreateBucketIfNotExists([]byte(q.InFlightBucket))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create/load bucket: %s", err)
+	}
+
 	var (
-		heartbeats []heartbeat.Heartbeat
-		ids        []string
+		records     []Record
+		leases      = make(map[string][]byte)
+		undecodable = make(map[string][]byte)
+		decodeErrs  = make(map[string]error)
+		now         = time.Now()
 	)
 
 	// load values
 	c := b.Cursor()
 
 	for key, value := c.First(); key != nil; key, value = c.Next() {
-		if len(heartbeats) >= limit {
+		if len(records) >= limit {
 			break
 		}
 
-		var h heartbeat.Heartbeat
+		h, err := decodeRecord(value)
+		if err != nil {
+			undecodable[string(key)] = append([]byte(nil), value...)
+			decodeErrs[string(key)] = err
 
-		err := json.Unmarshal(value, &h)
+			continue
+		}
+
+		data, err := json.Marshal(lease{
+			LeasedAt:  now,
+			Heartbeat: value,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to json unmarshal heartbeat data: %s", err)
+			return nil, fmt.Errorf("failed to json marshal lease: %s", err)
 		}
 
-		heartbeats = append(heartbeats, h)
-		ids = append(ids, string(key))
+		records = append(records, Record{
+			ID:        string(key),
+			Heartbeat: h,
+		})
+		leases[string(key)] = data
 	}
 
-	for _, id := range ids {
+	for id, data := range leases {
+		if err := inFlight.Put([]byte(id), data); err != nil {
+			return nil, fmt.Errorf("failed to lease key %q: %s", id, err)
+		}
+
 		if err := b.Delete([]byte(id)); err != nil {
 			return nil, fmt.Errorf("failed to delete key %q: %s", id, err)
 		}
 	}
 
-	return heartbeats, nil
+	for id, data := range undecodable {
+		log.Warnf("moving undecodable heartbeat %q to quarantine: %s", id, decodeErrs[id])
+
+		if err := q.Quarantine(id, data, decodeErrs[id]); err != nil {
+			return nil, fmt.Errorf("failed to quarantine key %q: %s", id, err)
+		}
+	}
+
+	return records, nil
 }
 
 // PushMany stores the provided heartbeats in the db.
@@ -424,7 +506,7 @@ func (q *Queue) PushMany(hh []heartbeat.Heartbeat) error {
 			h.Entity = h.EntityRaw
 		}
 
-		data, err := json.Marshal(h)
+		data, err := encodeRecord(h)
 		if err != nil {
 			return fmt.Errorf("failed to json marshal heartbeat: %s", err)
 		}
@@ -435,9 +517,60 @@ func (q *Queue) PushMany(hh []heartbeat.Heartbeat) error {
 		}
 	}
 
+	if _, err := q.Evict(q.Retention); err != nil {
+		return fmt.Errorf("failed to evict heartbeat(s): %s", err)
+	}
+
 	return nil
 }
 
+// List returns the heartbeats in the db matching the passed in filter, ordered
+// by their key. Can be used within read only transactions.
+func (q *Queue) List(filter Filter) ([]Record, error) {
+	b := q.tx.Bucket([]byte(q.Bucket))
+	if b == nil {
+		return nil, nil
+	}
+
+	var (
+		records []Record
+		skipped int
+	)
+
+	c := b.Cursor()
+
+	for key, value := c.First(); key != nil; key, value = c.Next() {
+		if filter.Limit > 0 && len(records) >= filter.Limit {
+			break
+		}
+
+		h, err := decodeRecord(value)
+		if err != nil {
+			log.Warnf("skipping undecodable heartbeat %q: %s", string(key), err)
+
+			continue
+		}
+
+		h.ApiKey = apikey.Resolve(h.Entity, filter.ApiKeys)
+
+		if !filter.Match(h) {
+			continue
+		}
+
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+
+		records = append(records, Record{
+			ID:        string(key),
+			Heartbeat: h,
+		})
+	}
+
+	return records, nil
+}
+
 // Count returns the total number of heartbeats in the offline db.
 func (q *Queue) Count() (int, error) {
 	b, err := q.tx.CreateBucketIfNotExists([]byte(q.Bucket))
@@ -1,16 +1,19 @@
 package offline_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
 	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
 	"github.com/wakatime/wakatime-cli/pkg/offline"
 
@@ -80,7 +83,7 @@ func TestWithQueue(t *testing.T) {
 
 	opt := offline.WithQueue(f.Name())
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Len(t, hh, 2)
 		assert.Contains(t, hh, testHeartbeats()[0])
 		assert.Contains(t, hh, testHeartbeats()[1])
@@ -98,7 +101,7 @@ func TestWithQueue(t *testing.T) {
 	})
 
 	// run
-	results, err := handle([]heartbeat.Heartbeat{
+	results, err := handle(context.Background(), []heartbeat.Heartbeat{
 		testHeartbeats()[0],
 		testHeartbeats()[1],
 	})
@@ -152,7 +155,7 @@ func TestWithQueue_ApiError(t *testing.T) {
 
 	opt := offline.WithQueue(f.Name())
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, hh, []heartbeat.Heartbeat{
 			testHeartbeats()[0],
 			testHeartbeats()[1],
@@ -162,7 +165,7 @@ func TestWithQueue_ApiError(t *testing.T) {
 	})
 
 	// run
-	_, err = handle([]heartbeat.Heartbeat{
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{
 		testHeartbeats()[0],
 		testHeartbeats()[1],
 	})
@@ -199,10 +202,10 @@ func TestWithQueue_ApiError(t *testing.T) {
 	require.Len(t, stored, 2)
 
 	assert.Equal(t, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true", stored[0].ID)
-	assert.JSONEq(t, string(dataGo), stored[0].Heartbeat)
+	assert.JSONEq(t, withSchemaVersion(t, dataGo), stored[0].Heartbeat)
 
 	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", stored[1].ID)
-	assert.JSONEq(t, string(dataPy), stored[1].Heartbeat)
+	assert.JSONEq(t, withSchemaVersion(t, dataPy), stored[1].Heartbeat)
 }
 
 func TestWithQueue_InvalidResults(t *testing.T) {
@@ -214,7 +217,7 @@ func TestWithQueue_InvalidResults(t *testing.T) {
 
 	opt := offline.WithQueue(f.Name())
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, hh, testHeartbeats())
 
 		return []heartbeat.Result{
@@ -234,7 +237,7 @@ func TestWithQueue_InvalidResults(t *testing.T) {
 	})
 
 	// run
-	results, err := handle(testHeartbeats())
+	results, err := handle(context.Background(), testHeartbeats())
 	require.NoError(t, err)
 
 	// check
@@ -284,10 +287,57 @@ func TestWithQueue_InvalidResults(t *testing.T) {
 	assert.Len(t, stored, 2)
 
 	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", stored[0].ID)
-	assert.JSONEq(t, string(dataPy), stored[0].Heartbeat)
+	assert.JSONEq(t, withSchemaVersion(t, dataPy), stored[0].Heartbeat)
 
 	assert.Equal(t, "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false", stored[1].ID)
-	assert.JSONEq(t, string(dataJs), stored[1].Heartbeat)
+	assert.JSONEq(t, withSchemaVersion(t, dataJs), stored[1].Heartbeat)
+}
+
+func TestWithQueue_Locked(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	// hold the db file lock like a concurrently syncing process
+	db, err := bolt.Open(fp, 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	opt := offline.WithQueue(fp)
+
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return []heartbeat.Result{
+			{
+				Status:    400,
+				Heartbeat: testHeartbeats()[0],
+			},
+			{
+				Status:    500,
+				Heartbeat: testHeartbeats()[1],
+			},
+			{
+				Status:    201,
+				Heartbeat: testHeartbeats()[2],
+			},
+		}, nil
+	})
+
+	start := time.Now()
+
+	_, err = handle(context.Background(), testHeartbeats())
+	require.NoError(t, err)
+
+	// requeued heartbeats and dead letters must not wait for the db file lock
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	queued, err := os.ReadDir(filepath.Join(fp+".spool", "queue"))
+	require.NoError(t, err)
+
+	assert.Len(t, queued, 1)
+
+	deadLetters, err := os.ReadDir(filepath.Join(fp+".spool", "dead_letter"))
+	require.NoError(t, err)
+
+	assert.Len(t, deadLetters, 1)
 }
 
 func TestWithQueue_HandleLeftovers(t *testing.T) {
@@ -299,7 +349,7 @@ func TestWithQueue_HandleLeftovers(t *testing.T) {
 
 	opt := offline.WithQueue(f.Name())
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, hh, testHeartbeats())
 
 		return []heartbeat.Result{
@@ -311,7 +361,7 @@ func TestWithQueue_HandleLeftovers(t *testing.T) {
 	})
 
 	// run
-	results, err := handle(testHeartbeats())
+	results, err := handle(context.Background(), testHeartbeats())
 	require.NoError(t, err)
 
 	// check
@@ -352,10 +402,10 @@ func TestWithQueue_HandleLeftovers(t *testing.T) {
 	require.Len(t, stored, 2)
 
 	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", stored[0].ID)
-	assert.JSONEq(t, string(dataPy), stored[0].Heartbeat)
+	assert.JSONEq(t, withSchemaVersion(t, dataPy), stored[0].Heartbeat)
 
 	assert.Equal(t, "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false", stored[1].ID)
-	assert.JSONEq(t, string(dataJs), stored[1].Heartbeat)
+	assert.JSONEq(t, withSchemaVersion(t, dataJs), stored[1].Heartbeat)
 }
 
 func TestWithSync(t *testing.T) {
@@ -389,7 +439,7 @@ func TestWithSync(t *testing.T) {
 
 	opt := offline.WithSync(f.Name(), offline.SyncMaxDefault)
 
-	handle := opt(func(_ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, _ []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		return []heartbeat.Result{
 			{
 				Status:    http.StatusCreated,
@@ -403,7 +453,7 @@ func TestWithSync(t *testing.T) {
 	})
 
 	// run
-	results, err := handle(nil)
+	results, err := handle(context.Background(), nil)
 	require.NoError(t, err)
 
 	// check
@@ -461,7 +511,7 @@ func TestSync_MultipleRequests(t *testing.T) {
 	var numCalls int
 
 	// run
-	err = syncFn(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	err = syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		numCalls++
 
 		// first request
@@ -558,7 +608,7 @@ func TestSync_APIError(t *testing.T) {
 	var numCalls int
 
 	// run
-	err = syncFn(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	err = syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		numCalls++
 
 		assert.Equal(t, []heartbeat.Heartbeat{
@@ -600,6 +650,13 @@ func TestSync_APIError(t *testing.T) {
 	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", stored[1].ID)
 	assert.JSONEq(t, string(dataPy), stored[1].Heartbeat)
 
+	db, err = bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, readBucket(t, db, "in_flight"))
+
+	db.Close()
+
 	assert.Eventually(t, func() bool { return numCalls == 1 }, time.Second, 50*time.Millisecond)
 }
 
@@ -644,7 +701,7 @@ func TestSync_InvalidResults(t *testing.T) {
 	var numCalls int
 
 	// run
-	err = syncFn(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	err = syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		numCalls++
 
 		// first request
@@ -667,10 +724,10 @@ func TestSync_InvalidResults(t *testing.T) {
 					Errors:    []string{"Too many heartbeats"},
 					Heartbeat: testHeartbeats()[1],
 				},
-				// 400 status results will be discarded
+				// 400 status results will be moved to dead letter bucket
 				{
-					Status:    400,
-					Heartbeat: testHeartbeats()[2],
+					Status: 400,
+					Errors: []string{"Invalid entity"},
 				},
 			}, nil
 		}
@@ -714,6 +771,16 @@ func TestSync_InvalidResults(t *testing.T) {
 
 	require.Len(t, stored, 0)
 
+	deadLetters, err := offline.ListDeadLetters(f.Name())
+	require.NoError(t, err)
+
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false", deadLetters[0].ID)
+	assert.Equal(t, testHeartbeats()[2], deadLetters[0].Heartbeat)
+	assert.Equal(t, []string{"Invalid entity"}, deadLetters[0].Errors)
+	assert.Equal(t, http.StatusBadRequest, deadLetters[0].Status)
+	assert.False(t, deadLetters[0].FailedAt.IsZero())
+
 	assert.Eventually(t, func() bool { return numCalls == 2 }, time.Second, 50*time.Millisecond)
 }
 
@@ -751,7 +818,7 @@ func TestSync_SyncLimit(t *testing.T) {
 	var numCalls int
 
 	// run
-	err = syncFn(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	err = syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		numCalls++
 
 		assert.Len(t, hh, 1)
@@ -837,16 +904,23 @@ func TestQueue_PopMany(t *testing.T) {
 	// run
 	q := offline.NewQueue(tx)
 	q.Bucket = "test_bucket"
-	hh, err := q.PopMany(2)
+	records, err := q.PopMany(2)
 	require.NoError(t, err)
 
 	err = tx.Commit()
 	require.NoError(t, err)
 
 	// check
-	assert.Len(t, hh, 2)
-	assert.Contains(t, hh, testHeartbeats()[0])
-	assert.Contains(t, hh, testHeartbeats()[1])
+	assert.Equal(t, []offline.Record{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: testHeartbeats()[0],
+		},
+		{
+			ID:        "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+			Heartbeat: testHeartbeats()[1],
+		},
+	}, records)
 
 	var stored []heartbeatRecord
 
@@ -867,6 +941,13 @@ func TestQueue_PopMany(t *testing.T) {
 	assert.Len(t, stored, 1)
 	assert.Equal(t, "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false", stored[0].ID)
 	assert.JSONEq(t, string(dataJs), stored[0].Heartbeat)
+
+	// popped heartbeats are leased until acknowledged
+	leased := readBucket(t, db, "in_flight")
+
+	require.Len(t, leased, 2)
+	assert.Equal(t, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true", leased[0].ID)
+	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", leased[1].ID)
 }
 
 func TestQueue_PushMany(t *testing.T) {
@@ -933,10 +1014,10 @@ func TestQueue_PushMany(t *testing.T) {
 	assert.JSONEq(t, string(dataGo), stored[0].Heartbeat)
 
 	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", stored[1].ID)
-	assert.JSONEq(t, string(dataPy), stored[1].Heartbeat)
+	assert.JSONEq(t, withSchemaVersion(t, dataPy), stored[1].Heartbeat)
 
 	assert.Equal(t, "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false", stored[2].ID)
-	assert.JSONEq(t, string(dataJs), stored[2].Heartbeat)
+	assert.JSONEq(t, withSchemaVersion(t, dataJs), stored[2].Heartbeat)
 }
 
 func TestQueue_Count(t *testing.T) {
@@ -999,6 +1080,183 @@ func TestQueue_Count(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestListHeartbeats(t *testing.T) {
+	// setup
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	dataPy, err := os.ReadFile("testdata/heartbeat_py.json")
+	require.NoError(t, err)
+
+	dataJs, err := os.ReadFile("testdata/heartbeat_js.json")
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "heartbeats", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(dataGo),
+		},
+		{
+			ID:        "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+			Heartbeat: string(dataPy),
+		},
+		{
+			ID:        "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false",
+			Heartbeat: string(dataJs),
+		},
+	})
+
+	db.Close()
+
+	// run
+	records, err := offline.ListHeartbeats(f.Name(), offline.Filter{
+		Project: "wakatime",
+	})
+	require.NoError(t, err)
+
+	// check
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false", records[0].ID)
+	assert.Equal(t, testHeartbeats()[1], records[0].Heartbeat)
+
+	assert.Equal(t, "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false", records[1].ID)
+	assert.Equal(t, testHeartbeats()[2], records[1].Heartbeat)
+}
+
+func TestListHeartbeats_FileNotExists(t *testing.T) {
+	records, err := offline.ListHeartbeats(filepath.Join(t.TempDir(), "missing.bdb"), offline.Filter{})
+	require.NoError(t, err)
+
+	assert.Empty(t, records)
+}
+
+func TestQueue_List(t *testing.T) {
+	// setup
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	dataPy, err := os.ReadFile("testdata/heartbeat_py.json")
+	require.NoError(t, err)
+
+	dataJs, err := os.ReadFile("testdata/heartbeat_js.json")
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "test_bucket", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(dataGo),
+		},
+		{
+			ID:        "1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+			Heartbeat: string(dataPy),
+		},
+		{
+			ID:        "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false",
+			Heartbeat: string(dataJs),
+		},
+	})
+
+	tests := map[string]struct {
+		Filter   offline.Filter
+		Expected []string
+	}{
+		"all": {
+			Expected: []string{
+				"1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+				"1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+				"1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false",
+			},
+		},
+		"entity": {
+			Filter: offline.Filter{Entity: "main.py"},
+			Expected: []string{
+				"1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+			},
+		},
+		"time range": {
+			Filter: offline.Filter{
+				Start: time.Unix(1592868380, 0),
+				End:   time.Unix(1592868390, 0),
+			},
+			Expected: []string{
+				"1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+			},
+		},
+		"api key": {
+			Filter: offline.Filter{
+				ApiKey: "00000000-0000-4000-8000-000000000001",
+				ApiKeys: apikey.Config{
+					DefaultApiKey: "00000000-0000-4000-8000-000000000000",
+					MapPatterns: []apikey.MapPattern{
+						{
+							ApiKey: "00000000-0000-4000-8000-000000000001",
+							Regex:  regexp.MustCompile(`main\.js$`),
+						},
+					},
+				},
+			},
+			Expected: []string{
+				"1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false",
+			},
+		},
+		"offset and limit": {
+			Filter: offline.Filter{Offset: 1, Limit: 1},
+			Expected: []string{
+				"1592868386.079084-file-debugging-wakatime-summary-/tmp/main.py-false",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tx, err := db.Begin(false)
+			require.NoError(t, err)
+
+			defer func() { _ = tx.Rollback() }()
+
+			q := offline.NewQueue(tx)
+			q.Bucket = "test_bucket"
+
+			records, err := q.List(test.Filter)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, r := range records {
+				ids = append(ids, r.ID)
+			}
+
+			assert.Equal(t, test.Expected, ids)
+		})
+	}
+}
+
+func TestQueue_List_BucketNotExists(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	tx, err := db.Begin(false)
+	require.NoError(t, err)
+
+	defer func() { _ = tx.Rollback() }()
+
+	records, err := offline.NewQueue(tx).List(offline.Filter{})
+	require.NoError(t, err)
+
+	assert.Empty(t, records)
+}
+
 func initDB(t *testing.T) (*bolt.DB, func()) {
 	// create tmp file
 	f, err := os.CreateTemp(t.TempDir(), "")
@@ -1064,6 +1322,51 @@ func testHeartbeats() []heartbeat.Heartbeat {
 	}
 }
 
+func readBucket(t *testing.T, db *bolt.DB, bucket string) []heartbeatRecord {
+	t.Helper()
+
+	var stored []heartbeatRecord
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+
+		for key, value := c.First(); key != nil; key, value = c.Next() {
+			stored = append(stored, heartbeatRecord{
+				ID:        string(key),
+				Heartbeat: string(value),
+			})
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return stored
+}
+
+// withSchemaVersion adds the current schema version to heartbeat json data, as
+// expected for records stored by the queue.
+func withSchemaVersion(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var fields map[string]interface{}
+
+	err := json.Unmarshal(data, &fields)
+	require.NoError(t, err)
+
+	fields["schema_version"] = 1
+
+	versioned, err := json.Marshal(fields)
+	require.NoError(t, err)
+
+	return string(versioned)
+}
+
 type heartbeatRecord struct {
 	ID        string
 	Heartbeat string
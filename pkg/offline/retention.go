@@ -0,0 +1,169 @@
+package offline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/log"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Retention defines limits for the offline queue. Heartbeats exceeding any of
+// the limits are evicted oldest first. Zero values disable the respective limit.
+type Retention struct {
+	// MaxAge is the maximum age of a queued heartbeat, measured from its time.
+	MaxAge time.Duration
+	// MaxEntries is the maximum number of queued heartbeats.
+	MaxEntries int
+	// MaxSize is the maximum size of the queued heartbeat records in bytes.
+	MaxSize int64
+}
+
+// Enabled returns true, if at least one limit is set.
+func (r Retention) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxEntries > 0 || r.MaxSize > 0
+}
+
+// Evict deletes heartbeats exceeding the passed in retention limits from the
+// queue, oldest first, and returns the number of evicted heartbeats. Keys are
+// prefixed with the heartbeat time, so cursor order is chronological.
+func (q *Queue) Evict(r Retention) (int, error) {
+	b := q.tx.Bucket([]byte(q.Bucket))
+	if b == nil {
+		return 0, nil
+	}
+
+	var total int
+
+	if r.MaxAge > 0 {
+		n, err := evictOlderThan(b, time.Now().Add(-r.MaxAge))
+		if err != nil {
+			return total, fmt.Errorf("failed to evict expired heartbeats: %s", err)
+		}
+
+		if n > 0 {
+			log.Warnf("evicted %d heartbeat(s) older than %s from offline queue", n, r.MaxAge)
+		}
+
+		total += n
+	}
+
+	if r.MaxEntries > 0 {
+		n, err := evictSurplus(b, r.MaxEntries)
+		if err != nil {
+			return total, fmt.Errorf("failed to evict surplus heartbeats: %s", err)
+		}
+
+		if n > 0 {
+			log.Warnf("evicted %d heartbeat(s) from offline queue exceeding maximum of %d entries", n, r.MaxEntries)
+		}
+
+		total += n
+	}
+
+	if r.MaxSize > 0 {
+		// measure the queued records instead of the db file, which includes
+		// free pages of already sent heartbeats until it gets compacted.
+		n, err := evictAbove(b, r.MaxSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to evict heartbeats exceeding max size: %s", err)
+		}
+
+		if n > 0 {
+			log.Warnf("evicted %d heartbeat(s) from offline queue exceeding maximum size of %d bytes", n, r.MaxSize)
+		}
+
+		total += n
+	}
+
+	return total, nil
+}
+
+// evictOlderThan deletes all heartbeats with a time before t.
+func evictOlderThan(b *bolt.Bucket, t time.Time) (int, error) {
+	var keys [][]byte
+
+	c := b.Cursor()
+
+	for key, value := c.First(); key != nil; key, value = c.Next() {
+		// undecodable records get quarantined once popped
+		h, err := decodeRecord(value)
+		if err != nil {
+			continue
+		}
+
+		if !heartbeatTime(h).Before(t) {
+			break
+		}
+
+		keys = append(keys, append([]byte(nil), key...))
+	}
+
+	return deleteKeys(b, keys)
+}
+
+// evictSurplus deletes the oldest heartbeats, until not more than limit
+// heartbeats are left. Keys are counted via cursor, as bucket stats do not
+// include changes of the current transaction.
+func evictSurplus(b *bolt.Bucket, limit int) (int, error) {
+	var count int
+
+	err := b.ForEach(func(_, _ []byte) error {
+		count++
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		keys [][]byte
+		n    = count - limit
+	)
+
+	c := b.Cursor()
+
+	for key, _ := c.First(); key != nil && len(keys) < n; key, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), key...))
+	}
+
+	return deleteKeys(b, keys)
+}
+
+// evictAbove deletes the oldest heartbeats, until the total size of the
+// remaining keys and values is not above limit bytes.
+func evictAbove(b *bolt.Bucket, limit int64) (int, error) {
+	var size int64
+
+	err := b.ForEach(func(key, value []byte) error {
+		size += int64(len(key) + len(value))
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var keys [][]byte
+
+	c := b.Cursor()
+
+	for key, value := c.First(); key != nil && size > limit; key, value = c.Next() {
+		size -= int64(len(key) + len(value))
+		keys = append(keys, append([]byte(nil), key...))
+	}
+
+	return deleteKeys(b, keys)
+}
+
+func deleteKeys(b *bolt.Bucket, keys [][]byte) (int, error) {
+	for _, key := range keys {
+		if err := b.Delete(key); err != nil {
+			return 0, fmt.Errorf("failed to delete key %q: %s", string(key), err)
+		}
+	}
+
+	return len(keys), nil
+}
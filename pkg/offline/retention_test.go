@@ -0,0 +1,382 @@
+package offline_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestQueue_Evict(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		Retention   offline.Retention
+		Evicted     int
+		ExpectedIDs []string
+	}{
+		"no limits": {
+			Evicted: 0,
+			ExpectedIDs: []string{
+				heartbeatAt(now.Add(-2 * time.Hour)).ID(),
+				heartbeatAt(now.Add(-1 * time.Hour)).ID(),
+				heartbeatAt(now).ID(),
+			},
+		},
+		"max age": {
+			Retention: offline.Retention{MaxAge: 90 * time.Minute},
+			Evicted:   1,
+			ExpectedIDs: []string{
+				heartbeatAt(now.Add(-1 * time.Hour)).ID(),
+				heartbeatAt(now).ID(),
+			},
+		},
+		"max entries": {
+			Retention:   offline.Retention{MaxEntries: 1},
+			Evicted:     2,
+			ExpectedIDs: []string{heartbeatAt(now).ID()},
+		},
+		"max age and max entries": {
+			Retention:   offline.Retention{MaxAge: 90 * time.Minute, MaxEntries: 1},
+			Evicted:     2,
+			ExpectedIDs: []string{heartbeatAt(now).ID()},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, cleanup := initDB(t)
+			defer cleanup()
+
+			err := db.Update(func(tx *bolt.Tx) error {
+				return offline.NewQueue(tx).PushMany([]heartbeat.Heartbeat{
+					heartbeatAt(now),
+					heartbeatAt(now.Add(-2 * time.Hour)),
+					heartbeatAt(now.Add(-1 * time.Hour)),
+				})
+			})
+			require.NoError(t, err)
+
+			var evicted int
+
+			err = db.Update(func(tx *bolt.Tx) error {
+				evicted, err = offline.NewQueue(tx).Evict(test.Retention)
+
+				return err
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Evicted, evicted)
+			assert.Equal(t, test.ExpectedIDs, bucketIDs(t, db, "heartbeats"))
+		})
+	}
+}
+
+func TestQueue_Evict_MaxSize(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	start := time.Now().Add(-time.Hour)
+
+	var hh []heartbeat.Heartbeat
+
+	for i := 0; i < 500; i++ {
+		hh = append(hh, heartbeatAt(start.Add(time.Duration(i)*time.Second)))
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).PushMany(hh)
+	})
+	require.NoError(t, err)
+
+	var evicted int
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		evicted, err = offline.NewQueue(tx).Evict(offline.Retention{MaxSize: 64 * 1024})
+
+		return err
+	})
+	require.NoError(t, err)
+
+	ids := bucketIDs(t, db, "heartbeats")
+
+	assert.Greater(t, evicted, 0)
+	assert.Len(t, ids, 500-evicted)
+
+	// newest heartbeats are kept
+	assert.Equal(t, hh[len(hh)-1].ID(), ids[len(ids)-1])
+	assert.Equal(t, hh[evicted].ID(), ids[0])
+
+	assert.LessOrEqual(t, bucketSize(t, db, "heartbeats"), int64(64*1024))
+	assert.Greater(t, bucketSize(t, db, "heartbeats"), int64(60*1024))
+}
+
+func TestQueue_Evict_MaxSize_Drained(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	start := time.Now().Add(-time.Hour)
+
+	var hh []heartbeat.Heartbeat
+
+	for i := 0; i < 2000; i++ {
+		hh = append(hh, heartbeatAt(start.Add(time.Duration(i)*time.Second)))
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).PushMany(hh)
+	})
+	require.NoError(t, err)
+
+	// sync most of the queue, leaving free pages in the db file behind
+	err = db.Update(func(tx *bolt.Tx) error {
+		queue := offline.NewQueue(tx)
+
+		records, err := queue.PopMany(1860)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, len(records))
+		for i, r := range records {
+			ids[i] = r.ID
+		}
+
+		return queue.Ack(ids)
+	})
+	require.NoError(t, err)
+
+	var evicted int
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		// remaining heartbeats take more than half of the limit
+		require.Greater(t, tx.Size(), int64(64*1024))
+		require.Greater(t, bucketSizeTx(t, tx, "heartbeats"), int64(32*1024))
+
+		evicted, err = offline.NewQueue(tx).Evict(offline.Retention{MaxSize: 64 * 1024})
+
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Zero(t, evicted)
+	assert.Len(t, bucketIDs(t, db, "heartbeats"), 140)
+}
+
+func TestQueue_Evict_BucketNotExists(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		evicted, err := offline.NewQueue(tx).Evict(offline.Retention{MaxEntries: 1})
+		require.NoError(t, err)
+
+		assert.Zero(t, evicted)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestQueue_PushMany_Retention(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		q := offline.NewQueue(tx)
+		q.Retention = offline.Retention{MaxEntries: 2}
+
+		return q.PushMany([]heartbeat.Heartbeat{
+			heartbeatAt(now.Add(-2 * time.Hour)),
+			heartbeatAt(now.Add(-1 * time.Hour)),
+			heartbeatAt(now),
+		})
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		heartbeatAt(now.Add(-1 * time.Hour)).ID(),
+		heartbeatAt(now).ID(),
+	}, bucketIDs(t, db, "heartbeats"))
+}
+
+func TestWithQueue_Retention(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	now := time.Now()
+
+	opt := offline.WithQueue(f.Name(), offline.WithRetention(offline.Retention{MaxAge: 90 * time.Minute}))
+
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return nil, errors.New("error")
+	})
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{
+		heartbeatAt(now.Add(-2 * time.Hour)),
+		heartbeatAt(now),
+	})
+	require.Error(t, err)
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Equal(t, []string{heartbeatAt(now).ID()}, bucketIDs(t, db, "heartbeats"))
+}
+
+func TestSync_Retention(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	now := time.Now()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).PushMany([]heartbeat.Heartbeat{
+			heartbeatAt(now.Add(-2 * time.Hour)),
+			heartbeatAt(now),
+		})
+	})
+	require.NoError(t, err)
+
+	db.Close()
+
+	var sent []heartbeat.Heartbeat
+
+	syncFn := offline.Sync(f.Name(), 10, offline.WithRetention(offline.Retention{MaxAge: 90 * time.Minute}))
+
+	err = syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		sent = append(sent, hh...)
+
+		results := make([]heartbeat.Result, len(hh))
+		for i, h := range hh {
+			results[i] = heartbeat.Result{Status: 201, Heartbeat: h}
+		}
+
+		return results, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sent, 1)
+	assert.Equal(t, heartbeatAt(now).ID(), sent[0].ID())
+}
+
+func TestWithQueue_Compaction(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	start := time.Now().Add(-time.Hour)
+
+	var hh []heartbeat.Heartbeat
+
+	for i := 0; i < 5000; i++ {
+		hh = append(hh, heartbeatAt(start.Add(time.Duration(i)*time.Millisecond)))
+	}
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return offline.NewQueue(tx).PushMany(hh)
+	})
+	require.NoError(t, err)
+
+	db.Close()
+
+	before, err := os.Stat(f.Name())
+	require.NoError(t, err)
+
+	opt := offline.WithQueue(f.Name(), offline.WithRetention(offline.Retention{MaxEntries: 10}))
+
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return nil, errors.New("error")
+	})
+
+	_, err = handle(context.Background(), []heartbeat.Heartbeat{heartbeatAt(time.Now())})
+	require.Error(t, err)
+
+	after, err := os.Stat(f.Name())
+	require.NoError(t, err)
+
+	assert.Less(t, after.Size(), before.Size()/2)
+	assert.NoFileExists(t, f.Name()+".compact")
+
+	db, err = bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	ids := bucketIDs(t, db, "heartbeats")
+
+	require.Len(t, ids, 10)
+	assert.Equal(t, heartbeatAt(start.Add(4991*time.Millisecond)).ID(), ids[0])
+}
+
+func heartbeatAt(t time.Time) heartbeat.Heartbeat {
+	h := testHeartbeats()[0]
+	h.Time = float64(t.UnixNano()) / float64(time.Second)
+
+	return h
+}
+
+func bucketSize(t *testing.T, db *bolt.DB, bucket string) int64 {
+	t.Helper()
+
+	var size int64
+
+	err := db.View(func(tx *bolt.Tx) error {
+		size = bucketSizeTx(t, tx, bucket)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return size
+}
+
+func bucketSizeTx(t *testing.T, tx *bolt.Tx, bucket string) int64 {
+	t.Helper()
+
+	var size int64
+
+	err := tx.Bucket([]byte(bucket)).ForEach(func(key, value []byte) error {
+		size += int64(len(key) + len(value))
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return size
+}
+
+func bucketIDs(t *testing.T, db *bolt.DB, bucket string) []string {
+	t.Helper()
+
+	var ids []string
+
+	for _, r := range readBucket(t, db, bucket) {
+		ids = append(ids, r.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,223 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+const (
+	// dbQuarantineBucket is the bolt db bucket name for queued records, which
+	// could not be decoded.
+	dbQuarantineBucket = "quarantine"
+	// schemaVersion is the current version of the on-disk record format.
+	schemaVersion = 1
+	// schemaVersionKey is the json key of the schema version within a record.
+	schemaVersionKey = "schema_version"
+)
+
+// migration upgrades a decoded record by one schema version.
+type migration func(fields map[string]json.RawMessage) error
+
+// migrations is the registry of record migrations. The migration registered
+// for version n upgrades records from version n to version n+1.
+// nolint
+var migrations = map[int]migration{
+	// records written before schema versioning are plain heartbeats, which
+	// match version 1 apart from the missing version key.
+	0: func(_ map[string]json.RawMessage) error { return nil },
+}
+
+// record is the on-disk representation of a queued heartbeat. Heartbeat fields
+// are stored inline next to the schema version, so older versions of the cli
+// can still read records written by newer versions.
+type record struct {
+	SchemaVersion int `json:"schema_version"`
+	heartbeat.Heartbeat
+}
+
+// Quarantined is a queued record, which could not be decoded and has been
+// moved aside to not block sending the remaining heartbeats.
+type Quarantined struct {
+	ID            string          `json:"id"`
+	Data          json.RawMessage `json:"data"`
+	Error         string          `json:"error"`
+	QuarantinedAt time.Time       `json:"quarantined_at"`
+}
+
+// encodeRecord encodes a heartbeat into the current record format.
+func encodeRecord(h heartbeat.Heartbeat) ([]byte, error) {
+	return json.Marshal(record{
+		SchemaVersion: schemaVersion,
+		Heartbeat:     h,
+	})
+}
+
+// decodeRecord decodes a record of any known schema version into a heartbeat.
+// Records of older versions are upgraded via the registered migrations.
+// Records of newer versions are decoded on a best effort basis.
+func decodeRecord(data []byte) (heartbeat.Heartbeat, error) {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return heartbeat.Heartbeat{}, fmt.Errorf("failed to json unmarshal record: %s", err)
+	}
+
+	var version int
+
+	if raw, ok := fields[schemaVersionKey]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return heartbeat.Heartbeat{}, fmt.Errorf("failed to json unmarshal schema version: %s", err)
+		}
+	}
+
+	if version > schemaVersion {
+		log.Debugf("decoding record of newer schema version %d", version)
+	}
+
+	for ; version < schemaVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return heartbeat.Heartbeat{}, fmt.Errorf("missing migration for schema version %d", version)
+		}
+
+		if err := migrate(fields); err != nil {
+			return heartbeat.Heartbeat{}, fmt.Errorf("failed to migrate record from schema version %d: %s", version, err)
+		}
+	}
+
+	delete(fields, schemaVersionKey)
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return heartbeat.Heartbeat{}, fmt.Errorf("failed to json marshal migrated record: %s", err)
+	}
+
+	var h heartbeat.Heartbeat
+
+	if err := json.Unmarshal(migrated, &h); err != nil {
+		return heartbeat.Heartbeat{}, fmt.Errorf("failed to json unmarshal heartbeat data: %s", err)
+	}
+
+	return h, nil
+}
+
+// Quarantine moves an undecodable record from the queue into the quarantine bucket.
+func (q *Queue) Quarantine(id string, data []byte, decodeErr error) error {
+	b, err := q.tx.CreateBucketIfNotExists([]byte(q.QuarantineBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create/load bucket: %s", err)
+	}
+
+	entry := Quarantined{
+		ID:            id,
+		Data:          data,
+		Error:         decodeErr.Error(),
+		QuarantinedAt: time.Now(),
+	}
+
+	// keep invalid json readable by storing it as json string
+	if !json.Valid(data) {
+		entry.Data, _ = json.Marshal(string(data))
+	}
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to json marshal quarantined record: %s", err)
+	}
+
+	if err := b.Put([]byte(id), value); err != nil {
+		return fmt.Errorf("failed to store quarantined record %q: %s", id, err)
+	}
+
+	if queue := q.tx.Bucket([]byte(q.Bucket)); queue != nil {
+		if err := queue.Delete([]byte(id)); err != nil {
+			return fmt.Errorf("failed to delete key %q: %s", id, err)
+		}
+	}
+
+	return nil
+}
+
+// Quarantined returns all quarantined records. Can be used within read only
+// transactions.
+func (q *Queue) Quarantined() ([]Quarantined, error) {
+	b := q.tx.Bucket([]byte(q.QuarantineBucket))
+	if b == nil {
+		return nil, nil
+	}
+
+	var entries []Quarantined
+
+	err := b.ForEach(func(key, value []byte) error {
+		var entry Quarantined
+
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("failed to json unmarshal quarantined record %q: %s", string(key), err)
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// RestoreQuarantined moves quarantined records, which can be decoded again,
+// for example after upgrading the cli, back to the queue. Returns the number
+// of restored records.
+func (q *Queue) RestoreQuarantined() (int, error) {
+	b := q.tx.Bucket([]byte(q.QuarantineBucket))
+	if b == nil {
+		return 0, nil
+	}
+
+	restorable := make(map[string][]byte)
+
+	err := b.ForEach(func(key, value []byte) error {
+		var entry Quarantined
+
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("failed to json unmarshal quarantined record %q: %s", string(key), err)
+		}
+
+		if _, err := decodeRecord(entry.Data); err != nil {
+			return nil
+		}
+
+		restorable[string(key)] = entry.Data
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(restorable) == 0 {
+		return 0, nil
+	}
+
+	queue, err := q.tx.CreateBucketIfNotExists([]byte(q.Bucket))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create/load bucket: %s", err)
+	}
+
+	for id, data := range restorable {
+		if err := queue.Put([]byte(id), data); err != nil {
+			return 0, fmt.Errorf("failed to restore key %q: %s", id, err)
+		}
+
+		if err := b.Delete([]byte(id)); err != nil {
+			return 0, fmt.Errorf("failed to delete key %q: %s", id, err)
+		}
+	}
+
+	return len(restorable), nil
+}
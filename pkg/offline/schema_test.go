@@ -0,0 +1,212 @@
+package offline_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestQueue_PopMany_Quarantine(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "heartbeats", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(dataGo),
+		},
+		{
+			ID: "1592868386.079084-file-unknown-wakatime-summary-/tmp/main.py-false",
+			Heartbeat: `{"schema_version":2,"category":"unknown","entity":"/tmp/main.py",` +
+				`"type":"file","time":1592868386.079084}`,
+		},
+		{
+			ID:        "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false",
+			Heartbeat: `{"entity":`,
+		},
+	})
+
+	var records []offline.Record
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		records, err = offline.NewQueue(tx).PopMany(10)
+
+		return err
+	})
+	require.NoError(t, err)
+
+	require.Len(t, records, 1)
+	assert.Equal(t, testHeartbeats()[0], records[0].Heartbeat)
+
+	assert.Empty(t, readBucket(t, db, "heartbeats"))
+
+	var quarantined []offline.Quarantined
+
+	err = db.View(func(tx *bolt.Tx) error {
+		quarantined, err = offline.NewQueue(tx).Quarantined()
+
+		return err
+	})
+	require.NoError(t, err)
+
+	require.Len(t, quarantined, 2)
+
+	assert.Equal(t, "1592868386.079084-file-unknown-wakatime-summary-/tmp/main.py-false", quarantined[0].ID)
+	assert.JSONEq(t, `{"schema_version":2,"category":"unknown","entity":"/tmp/main.py",`+
+		`"type":"file","time":1592868386.079084}`, string(quarantined[0].Data))
+	assert.Contains(t, quarantined[0].Error, "invalid category")
+
+	assert.Equal(t, "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false", quarantined[1].ID)
+	assert.JSONEq(t, `"{\"entity\":"`, string(quarantined[1].Data))
+	assert.Contains(t, quarantined[1].Error, "failed to json unmarshal record")
+}
+
+func TestQueue_PopMany_NewerSchemaVersion(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	insertHeartbeatRecord(t, db, "heartbeats", heartbeatRecord{
+		ID: "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		Heartbeat: `{"schema_version":99,"new_field":"value","category":"coding","entity":"/tmp/main.go",` +
+			`"type":"file","time":1592868367.219124}`,
+	})
+
+	var records []offline.Record
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		var err error
+
+		records, err = offline.NewQueue(tx).PopMany(10)
+
+		return err
+	})
+	require.NoError(t, err)
+
+	require.Len(t, records, 1)
+	assert.Equal(t, heartbeat.Heartbeat{
+		Category:   heartbeat.CodingCategory,
+		Entity:     "/tmp/main.go",
+		EntityType: heartbeat.FileType,
+		Time:       1592868367.219124,
+	}, records[0].Heartbeat)
+}
+
+func TestQueue_RestoreQuarantined(t *testing.T) {
+	db, cleanup := initDB(t)
+	defer cleanup()
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	restorable, err := json.Marshal(offline.Quarantined{
+		ID:            "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+		Data:          dataGo,
+		Error:         "invalid category",
+		QuarantinedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	undecodable, err := json.Marshal(offline.Quarantined{
+		ID:            "1592868386.079084-file-unknown-wakatime-summary-/tmp/main.py-false",
+		Data:          json.RawMessage(`{"category":"unknown","time":1592868386.079084}`),
+		Error:         "invalid category",
+		QuarantinedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "quarantine", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(restorable),
+		},
+		{
+			ID:        "1592868386.079084-file-unknown-wakatime-summary-/tmp/main.py-false",
+			Heartbeat: string(undecodable),
+		},
+	})
+
+	var restored int
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		restored, err = offline.NewQueue(tx).RestoreQuarantined()
+
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, restored)
+
+	stored := readBucket(t, db, "heartbeats")
+	require.Len(t, stored, 1)
+
+	assert.Equal(t, "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true", stored[0].ID)
+	assert.JSONEq(t, string(dataGo), stored[0].Heartbeat)
+
+	assert.Equal(t, []string{"1592868386.079084-file-unknown-wakatime-summary-/tmp/main.py-false"},
+		bucketIDs(t, db, "quarantine"))
+}
+
+func TestSync_Quarantine(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	dataGo, err := os.ReadFile("testdata/heartbeat_go.json")
+	require.NoError(t, err)
+
+	insertHeartbeatRecords(t, db, "heartbeats", []heartbeatRecord{
+		{
+			ID:        "1592868367.219124-file-coding-wakatime-cli-heartbeat-/tmp/main.go-true",
+			Heartbeat: string(dataGo),
+		},
+		{
+			ID:        "1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false",
+			Heartbeat: `invalid`,
+		},
+	})
+
+	db.Close()
+
+	var sent []heartbeat.Heartbeat
+
+	syncFn := offline.Sync(f.Name(), 10)
+
+	err = syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		sent = append(sent, hh...)
+
+		results := make([]heartbeat.Result, len(hh))
+		for i, h := range hh {
+			results[i] = heartbeat.Result{Status: 201, Heartbeat: h}
+		}
+
+		return results, nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []heartbeat.Heartbeat{testHeartbeats()[0]}, sent)
+
+	db, err = bolt.Open(f.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Empty(t, readBucket(t, db, "heartbeats"))
+	assert.Equal(t, []string{"1592868394.084354-file-building-wakatime-todaygoal-/tmp/main.js-false"},
+		bucketIDs(t, db, "quarantine"))
+}
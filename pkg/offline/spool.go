@@ -0,0 +1,959 @@
+package offline
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+const (
+	spoolQueueDir      = "queue"
+	spoolInFlightDir   = "in_flight"
+	spoolDeadLetterDir = "dead_letter"
+	spoolQuarantineDir = "quarantine"
+	spoolTmpDir        = "tmp"
+	// spoolBatchExt is the file extension of spool batch files.
+	spoolBatchExt = ".ndjson"
+	// spoolMaxLineSize is the maximum size of a single record in a batch file.
+	spoolMaxLineSize = 1024 * 1024
+)
+
+// SpoolStore is a QueueStore, which keeps the offline queue as append-only
+// batch files in a directory. It relies on atomic renames only, instead of
+// file locks and mmap, so it is safe to be used on network filesystems.
+//
+// Every push writes a new batch file into the queue directory. Pop claims
+// batch files by renaming them into the in-flight directory, where the
+// modification time marks the start of the lease.
+type SpoolStore struct {
+	dir       string
+	retention Retention
+}
+
+// spoolLine is a single decoded record of a batch file.
+type spoolLine struct {
+	id        string
+	data      []byte
+	heartbeat heartbeat.Heartbeat
+	err       error
+}
+
+// NewSpoolStore creates a new instance of SpoolStore for the directory dir.
+func NewSpoolStore(dir string, opts ...Option) *SpoolStore {
+	cfg := newConfig(opts)
+
+	return &SpoolStore{
+		dir:       dir,
+		retention: cfg.retention,
+	}
+}
+
+// Push writes the provided heartbeats into a new batch file. Heartbeats
+// exceeding the retention limits are evicted afterwards.
+func (s *SpoolStore) Push(hh []heartbeat.Heartbeat) error {
+	if len(hh) == 0 {
+		return nil
+	}
+
+	lines := make([][]byte, len(hh))
+
+	for i, h := range hh {
+		if h.EntityRaw != "" {
+			h.Entity = h.EntityRaw
+		}
+
+		data, err := encodeRecord(h)
+		if err != nil {
+			return fmt.Errorf("failed to json marshal heartbeat: %s", err)
+		}
+
+		lines[i] = data
+	}
+
+	if err := s.writeBatch(spoolQueueDir, newBatchName(), lines); err != nil {
+		return fmt.Errorf("failed to push heartbeat(s) to spool: %s", err)
+	}
+
+	if err := s.evict(); err != nil {
+		return fmt.Errorf("failed to evict heartbeat(s): %s", err)
+	}
+
+	return nil
+}
+
+// Pop leases up to limit of the oldest heartbeats, ordered by heartbeat time.
+// Only batch files containing these heartbeats are claimed. Batch files with
+// expired leases from previous runs are moved back to the queue and heartbeats
+// exceeding the retention limits are evicted beforehand. Undecodable records
+// are moved to the quarantine directory.
+func (s *SpoolStore) Pop(limit int) ([]Record, error) {
+	if err := s.releaseExpired(); err != nil {
+		return nil, fmt.Errorf("failed to release expired heartbeat(s): %s", err)
+	}
+
+	if err := s.evict(); err != nil {
+		return nil, fmt.Errorf("failed to evict heartbeat(s): %s", err)
+	}
+
+	names, err := s.batchNames(spoolQueueDir)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		name string
+		line spoolLine
+	}
+
+	var (
+		candidates []candidate
+		claim      = make(map[string]bool)
+	)
+
+	for _, name := range names {
+		lines, err := s.readBatch(spoolQueueDir, name)
+		if errors.Is(err, os.ErrNotExist) {
+			// popped in the meantime
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range lines {
+			if line.err != nil {
+				claim[name] = true
+				continue
+			}
+
+			candidates = append(candidates, candidate{name: name, line: line})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return spoolLineLess(candidates[i].line, candidates[j].line)
+	})
+
+	selected := make(map[string]bool)
+
+	for _, c := range candidates {
+		if !selected[c.line.id] && len(selected) >= limit {
+			continue
+		}
+
+		// duplicates of a selected heartbeat are leased along with it
+		selected[c.line.id] = true
+		claim[c.name] = true
+	}
+
+	var (
+		records []Record
+		seen    = make(map[string]bool)
+	)
+
+	for _, name := range names {
+		if !claim[name] {
+			continue
+		}
+
+		claimed, err := s.claim(spoolQueueDir, name, spoolInFlightDir)
+		if err != nil {
+			return records, err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		lines, err := s.readBatch(spoolInFlightDir, name)
+		if err != nil {
+			return records, err
+		}
+
+		var leased, remaining, undecodable []spoolLine
+
+		for _, line := range lines {
+			switch {
+			case line.err != nil:
+				undecodable = append(undecodable, line)
+			case !selected[line.id]:
+				remaining = append(remaining, line)
+			case seen[line.id]:
+				// duplicate of an already leased heartbeat
+				leased = append(leased, line)
+			default:
+				seen[line.id] = true
+				leased = append(leased, line)
+				records = append(records, Record{ID: line.id, Heartbeat: line.heartbeat})
+			}
+		}
+
+		if err := s.quarantine(undecodable); err != nil {
+			return records, err
+		}
+
+		if len(remaining) > 0 || len(undecodable) > 0 {
+			if err := s.writeBatch(spoolQueueDir, name, lineData(remaining)); err != nil {
+				return records, err
+			}
+
+			if err := s.writeBatch(spoolInFlightDir, name, lineData(leased)); err != nil {
+				return records, err
+			}
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return recordLess(records[i], records[j])
+	})
+
+	return records, nil
+}
+
+// Delete deletes leased heartbeats with the specified ids.
+func (s *SpoolStore) Delete(ids []string) error {
+	return s.updateLeases(ids, func(_ string, _ []spoolLine) error {
+		return nil
+	})
+}
+
+// Release moves leased heartbeats with the specified ids back to the queue.
+func (s *SpoolStore) Release(ids []string) error {
+	return s.updateLeases(ids, func(name string, matched []spoolLine) error {
+		// keep the original position, without clashing with a remainder of
+		// the same batch, which is still queued
+		released := strings.TrimSuffix(name, spoolBatchExt) + "-" + randomHex() + spoolBatchExt
+
+		return s.writeBatch(spoolQueueDir, released, lineData(matched))
+	})
+}
+
+// updateLeases removes the records with the specified ids from the in-flight
+// batch files, after passing them to fn.
+func (s *SpoolStore) updateLeases(ids []string, fn func(name string, matched []spoolLine) error) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	lookup := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		lookup[id] = true
+	}
+
+	names, err := s.batchNames(spoolInFlightDir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		lines, err := s.readBatch(spoolInFlightDir, name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		var matched, kept []spoolLine
+
+		for _, line := range lines {
+			if lookup[line.id] {
+				matched = append(matched, line)
+				continue
+			}
+
+			kept = append(kept, line)
+		}
+
+		if len(matched) == 0 {
+			continue
+		}
+
+		if err := fn(name, matched); err != nil {
+			return err
+		}
+
+		if len(kept) == 0 {
+			if err := os.Remove(filepath.Join(s.dir, spoolInFlightDir, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove batch file: %s", err)
+			}
+
+			continue
+		}
+
+		if err := s.writeBatch(spoolInFlightDir, name, lineData(kept)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Count returns the total number of queued heartbeats.
+func (s *SpoolStore) Count() (int, error) {
+	records, err := s.List(Filter{})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(records), nil
+}
+
+// List returns the queued heartbeats matching the passed in filter, in the
+// order they were pushed. Undecodable records are skipped.
+func (s *SpoolStore) List(filter Filter) ([]Record, error) {
+	names, err := s.batchNames(spoolQueueDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		records []Record
+		seen    = make(map[string]bool)
+		skipped int
+	)
+
+	for _, name := range names {
+		lines, err := s.readBatch(spoolQueueDir, name)
+		if errors.Is(err, os.ErrNotExist) {
+			// popped in the meantime
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range lines {
+			if filter.Limit > 0 && len(records) >= filter.Limit {
+				return records, nil
+			}
+
+			if line.err != nil {
+				log.Warnf("skipping undecodable heartbeat %q in %s: %s", line.id, name, line.err)
+
+				continue
+			}
+
+			if seen[line.id] {
+				continue
+			}
+
+			seen[line.id] = true
+
+			h := line.heartbeat
+			h.ApiKey = apikey.Resolve(h.Entity, filter.ApiKeys)
+
+			if !filter.Match(h) {
+				continue
+			}
+
+			if skipped < filter.Offset {
+				skipped++
+				continue
+			}
+
+			records = append(records, Record{ID: line.id, Heartbeat: h})
+		}
+	}
+
+	return records, nil
+}
+
+// PushDeadLetters writes the provided dead letters into a new batch file in
+// the dead letter directory.
+func (s *SpoolStore) PushDeadLetters(entries []DeadLetter) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	lines := make([][]byte, len(entries))
+
+	for i, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to json marshal dead letter: %s", err)
+		}
+
+		lines[i] = data
+	}
+
+	if err := s.writeBatch(spoolDeadLetterDir, newBatchName(), lines); err != nil {
+		return fmt.Errorf("failed to push dead letter(s): %s", err)
+	}
+
+	log.Debugf("moved %d heartbeat(s) to dead letter directory", len(entries))
+
+	return nil
+}
+
+// DeadLetters returns all dead letters in the dead letter directory.
+func (s *SpoolStore) DeadLetters() ([]DeadLetter, error) {
+	names, err := s.batchNames(spoolDeadLetterDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DeadLetter
+
+	for _, name := range names {
+		batch, err := s.readDeadLetters(spoolDeadLetterDir, name)
+		if errors.Is(err, os.ErrNotExist) {
+			// taken in the meantime
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, batch...)
+	}
+
+	return entries, nil
+}
+
+// RetryDeadLetters moves all dead letters back into the queue. Returns the
+// number of moved heartbeats.
+func (s *SpoolStore) RetryDeadLetters() (int, error) {
+	var count int
+
+	err := s.takeDeadLetters(func(entries []DeadLetter) error {
+		hh := make([]heartbeat.Heartbeat, len(entries))
+		for i, entry := range entries {
+			hh[i] = entry.Heartbeat
+		}
+
+		if err := s.Push(hh); err != nil {
+			return err
+		}
+
+		count += len(entries)
+
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to retry dead letters: %s", err)
+	}
+
+	return count, nil
+}
+
+// PurgeDeadLetters deletes all dead letters. Returns the number of deleted
+// heartbeats.
+func (s *SpoolStore) PurgeDeadLetters() (int, error) {
+	var count int
+
+	err := s.takeDeadLetters(func(entries []DeadLetter) error {
+		count += len(entries)
+
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to purge dead letters: %s", err)
+	}
+
+	return count, nil
+}
+
+// takeDeadLetters passes all spooled dead letters to fn and deletes them, if fn
+// succeeds. Dead letter batch files are claimed beforehand, so concurrent
+// processes do not take the same dead letters.
+func (s *SpoolStore) takeDeadLetters(fn func(entries []DeadLetter) error) error {
+	names, err := s.batchNames(spoolDeadLetterDir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		claimed, err := s.claim(spoolDeadLetterDir, name, spoolTmpDir)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		entries, err := s.readDeadLetters(spoolTmpDir, name)
+		if err == nil {
+			err = fn(entries)
+		}
+
+		if err != nil {
+			if errRename := os.Rename(
+				filepath.Join(s.dir, spoolTmpDir, name),
+				filepath.Join(s.dir, spoolDeadLetterDir, name),
+			); errRename != nil {
+				log.Warnf("failed to move back dead letter batch file %q: %s", name, errRename)
+			}
+
+			return err
+		}
+
+		if err := os.Remove(filepath.Join(s.dir, spoolTmpDir, name)); err != nil {
+			log.Warnf("failed to remove dead letter batch file %q: %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// readDeadLetters reads and decodes all dead letters of a batch file.
+func (s *SpoolStore) readDeadLetters(subdir, name string) ([]DeadLetter, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, subdir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+
+	var entries []DeadLetter
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry DeadLetter
+
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to json unmarshal dead letter in %q: %s", name, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// releaseExpired moves in-flight batch files, which were leased longer than
+// leaseTimeout ago, back to the queue.
+func (s *SpoolStore) releaseExpired() error {
+	names, err := s.batchNames(spoolInFlightDir)
+	if err != nil {
+		return err
+	}
+
+	var released int
+
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(s.dir, spoolInFlightDir, name))
+		if err != nil {
+			continue
+		}
+
+		if time.Since(info.ModTime()) < leaseTimeout {
+			continue
+		}
+
+		err = os.Rename(filepath.Join(s.dir, spoolInFlightDir, name), filepath.Join(s.dir, spoolQueueDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to release batch file %q: %s", name, err)
+		}
+
+		if err == nil {
+			released++
+		}
+	}
+
+	if released > 0 {
+		log.Warnf("moved %d spool batch(es) with expired lease back to queue", released)
+	}
+
+	return nil
+}
+
+// evict deletes queued heartbeats exceeding the retention limits, oldest first
+// by heartbeat time.
+func (s *SpoolStore) evict() error {
+	if !s.retention.Enabled() {
+		return nil
+	}
+
+	names, err := s.batchNames(spoolQueueDir)
+	if err != nil {
+		return err
+	}
+
+	var (
+		batches = make([][]spoolLine, len(names))
+		evicted = make([]int, len(names))
+		count   int
+		size    int64
+	)
+
+	for i, name := range names {
+		batches[i], err = s.readBatch(spoolQueueDir, name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, line := range batches[i] {
+			count++
+			size += int64(len(line.data)) + 1
+		}
+	}
+
+	// records are evicted oldest first by heartbeat time, across all batches
+	type position struct {
+		batch int
+		index int
+	}
+
+	var order []position
+
+	for i := range batches {
+		for j := range batches[i] {
+			order = append(order, position{batch: i, index: j})
+		}
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return spoolLineLess(batches[order[a].batch][order[a].index], batches[order[b].batch][order[b].index])
+	})
+
+	dropped := make(map[position]bool)
+
+	// drop marks the oldest records matching fn as evicted and returns their number
+	drop := func(fn func(line spoolLine) bool) int {
+		var n int
+
+		for _, pos := range order {
+			if dropped[pos] {
+				continue
+			}
+
+			line := batches[pos.batch][pos.index]
+
+			if !fn(line) {
+				continue
+			}
+
+			dropped[pos] = true
+			count--
+			size -= int64(len(line.data)) + 1
+			evicted[pos.batch]++
+			n++
+		}
+
+		return n
+	}
+
+	if r := s.retention; r.MaxAge > 0 {
+		cutoff := time.Now().Add(-r.MaxAge)
+
+		n := drop(func(line spoolLine) bool {
+			return line.err == nil && heartbeatTime(line.heartbeat).Before(cutoff)
+		})
+		if n > 0 {
+			log.Warnf("evicted %d heartbeat(s) older than %s from offline spool", n, r.MaxAge)
+		}
+	}
+
+	if r := s.retention; r.MaxEntries > 0 && count > r.MaxEntries {
+		n := drop(func(_ spoolLine) bool {
+			return count > r.MaxEntries
+		})
+		log.Warnf("evicted %d heartbeat(s) from offline spool exceeding maximum of %d entries", n, r.MaxEntries)
+	}
+
+	if r := s.retention; r.MaxSize > 0 && size > r.MaxSize {
+		n := drop(func(_ spoolLine) bool {
+			return size > r.MaxSize
+		})
+		log.Warnf("evicted %d heartbeat(s) from offline spool exceeding maximum size of %d bytes", n, r.MaxSize)
+	}
+
+	for i, name := range names {
+		if evicted[i] == 0 {
+			continue
+		}
+
+		claimed, err := s.claim(spoolQueueDir, name, spoolTmpDir)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		// a concurrent pop may have rewritten the batch since it was read, so
+		// only the evicted records are dropped from the claimed file
+		lines, err := s.readBatch(spoolTmpDir, name)
+		if err != nil {
+			return err
+		}
+
+		drops := make(map[string]int)
+
+		for j, line := range batches[i] {
+			if dropped[position{batch: i, index: j}] {
+				drops[string(line.data)]++
+			}
+		}
+
+		var kept []spoolLine
+
+		for _, line := range lines {
+			if drops[string(line.data)] > 0 {
+				drops[string(line.data)]--
+				continue
+			}
+
+			kept = append(kept, line)
+		}
+
+		if len(kept) > 0 {
+			if err := s.writeBatch(spoolQueueDir, name, lineData(kept)); err != nil {
+				return err
+			}
+		}
+
+		if err := os.Remove(filepath.Join(s.dir, spoolTmpDir, name)); err != nil {
+			log.Warnf("failed to remove evicted batch file: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// quarantine writes undecodable records into a new batch file in the
+// quarantine directory.
+func (s *SpoolStore) quarantine(lines []spoolLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	data := make([][]byte, len(lines))
+
+	for i, line := range lines {
+		log.Warnf("moving undecodable heartbeat to quarantine: %s", line.err)
+
+		entry := Quarantined{
+			ID:            line.id,
+			Data:          line.data,
+			Error:         line.err.Error(),
+			QuarantinedAt: time.Now(),
+		}
+
+		if !json.Valid(line.data) {
+			entry.Data, _ = json.Marshal(string(line.data))
+		}
+
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to json marshal quarantined record: %s", err)
+		}
+
+		data[i] = encoded
+	}
+
+	return s.writeBatch(spoolQuarantineDir, newBatchName(), data)
+}
+
+// claim moves a batch file from one directory into another and reports,
+// whether this process won the race for it. The modification time is set to
+// now, which marks the start of a lease.
+func (s *SpoolStore) claim(from, name, to string) (bool, error) {
+	if err := os.MkdirAll(filepath.Join(s.dir, to), 0700); err != nil {
+		return false, fmt.Errorf("failed to create spool directory: %s", err)
+	}
+
+	target := filepath.Join(s.dir, to, name)
+
+	err := os.Rename(filepath.Join(s.dir, from, name), target)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to claim batch file %q: %s", name, err)
+	}
+
+	now := time.Now()
+
+	if err := os.Chtimes(target, now, now); err != nil {
+		log.Warnf("failed to set lease time of batch file %q: %s", name, err)
+	}
+
+	return true, nil
+}
+
+// batchNames returns the sorted batch file names in the passed in sub directory.
+func (s *SpoolStore) batchNames(subdir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, subdir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %s", err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != spoolBatchExt {
+			continue
+		}
+
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// readBatch reads and decodes all records of a batch file.
+func (s *SpoolStore) readBatch(subdir, name string) ([]spoolLine, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, subdir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+
+	var lines []spoolLine
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), spoolMaxLineSize)
+
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+
+		line := spoolLine{data: append([]byte(nil), raw...)}
+
+		line.heartbeat, line.err = decodeRecord(line.data)
+		if line.err == nil {
+			line.id = line.heartbeat.ID()
+		} else {
+			line.id = fmt.Sprintf("%s-%d", strings.TrimSuffix(name, spoolBatchExt), len(lines))
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file %q: %s", name, err)
+	}
+
+	return lines, nil
+}
+
+// writeBatch atomically writes a batch file, by writing into the tmp
+// directory first and renaming it to its final name afterwards.
+func (s *SpoolStore) writeBatch(subdir, name string, lines [][]byte) error {
+	for _, dir := range []string{spoolTmpDir, subdir} {
+		if err := os.MkdirAll(filepath.Join(s.dir, dir), 0700); err != nil {
+			return fmt.Errorf("failed to create spool directory: %s", err)
+		}
+	}
+
+	if len(lines) == 0 {
+		err := os.Remove(filepath.Join(s.dir, subdir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove batch file: %s", err)
+		}
+
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Join(s.dir, spoolTmpDir), "batch-*")
+	if err != nil {
+		return fmt.Errorf("failed to create batch file: %s", err)
+	}
+
+	w := bufio.NewWriter(f)
+
+	for _, line := range lines {
+		_, _ = w.Write(line)
+		_ = w.WriteByte('\n')
+	}
+
+	err = w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("failed to write batch file: %s", err)
+	}
+
+	if err := os.Rename(f.Name(), filepath.Join(s.dir, subdir, name)); err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("failed to move batch file into place: %s", err)
+	}
+
+	return nil
+}
+
+func lineData(lines []spoolLine) [][]byte {
+	data := make([][]byte, len(lines))
+
+	for i, line := range lines {
+		data[i] = line.data
+	}
+
+	return data
+}
+
+// newBatchName returns a unique batch file name, which sorts by creation time.
+func newBatchName() string {
+	return fmt.Sprintf("%020d-%s%s", time.Now().UnixNano(), randomHex(), spoolBatchExt)
+}
+
+func randomHex() string {
+	b := make([]byte, 4)
+
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().Nanosecond())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// spoolLineLess orders records by heartbeat time, like the time prefixed keys
+// of the bolt db. Undecodable records are ordered last.
+func spoolLineLess(a, b spoolLine) bool {
+	if a.err != nil || b.err != nil {
+		return a.err == nil && b.err != nil
+	}
+
+	return recordLess(Record{ID: a.id, Heartbeat: a.heartbeat}, Record{ID: b.id, Heartbeat: b.heartbeat})
+}
+
+func recordLess(a, b Record) bool {
+	if a.Heartbeat.Time != b.Heartbeat.Time {
+		return a.Heartbeat.Time < b.Heartbeat.Time
+	}
+
+	return a.ID < b.ID
+}
@@ -0,0 +1,118 @@
+package offline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
+)
+
+// ageBucket groups queued heartbeats by their age.
+type ageBucket struct {
+	Label  string
+	MaxAge time.Duration
+}
+
+// ageBuckets are the age groups of queue statistics, in ascending order.
+// nolint
+var ageBuckets = []ageBucket{
+	{Label: "<1h", MaxAge: time.Hour},
+	{Label: "<1d", MaxAge: 24 * time.Hour},
+	{Label: "<7d", MaxAge: 7 * 24 * time.Hour},
+	{Label: "<30d", MaxAge: 30 * 24 * time.Hour},
+	{Label: ">=30d"},
+}
+
+// Stats contains statistics about the heartbeats in the offline queue. Api
+// keys are masked.
+type Stats struct {
+	Count      int            `json:"count"`
+	Bytes      int64          `json:"bytes"`
+	Oldest     *time.Time     `json:"oldest"`
+	Newest     *time.Time     `json:"newest"`
+	ByAge      map[string]int `json:"by_age"`
+	ByApiKey   map[string]int `json:"by_api_key"`
+	ByCategory map[string]int `json:"by_category"`
+	ByDay      map[string]int `json:"by_day"`
+	ByProject  map[string]int `json:"by_project"`
+}
+
+// QueueStats returns statistics about the heartbeats in the offline queue.
+// The api keys of heartbeats are resolved via the passed in config. Days are
+// calendar days in local time. Bytes is the size of the encoded records.
+func QueueStats(filepath string, apiKeys apikey.Config, opts ...Option) (Stats, error) {
+	records, err := newStore(filepath, newConfig(opts)).List(Filter{ApiKeys: apiKeys})
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to list offline heartbeats: %s", err)
+	}
+
+	return newStats(records, time.Now())
+}
+
+func newStats(records []Record, now time.Time) (Stats, error) {
+	stats := Stats{
+		ByAge:      make(map[string]int),
+		ByApiKey:   make(map[string]int),
+		ByCategory: make(map[string]int),
+		ByDay:      make(map[string]int),
+		ByProject:  make(map[string]int),
+	}
+
+	for _, r := range records {
+		h := r.Heartbeat
+
+		data, err := encodeRecord(h)
+		if err != nil {
+			return Stats{}, fmt.Errorf("failed to json marshal heartbeat %q: %s", r.ID, err)
+		}
+
+		t := heartbeatTime(h)
+
+		var project string
+		if h.Project != nil {
+			project = *h.Project
+		}
+
+		stats.Count++
+		stats.Bytes += int64(len(data))
+		stats.ByAge[ageLabel(now.Sub(t))]++
+		stats.ByApiKey[apikey.Mask(h.ApiKey)]++
+		stats.ByCategory[h.Category.String()]++
+		stats.ByDay[t.Local().Format("2006-01-02")]++
+		stats.ByProject[project]++
+
+		if stats.Oldest == nil || t.Before(*stats.Oldest) {
+			oldest := t
+			stats.Oldest = &oldest
+		}
+
+		if stats.Newest == nil || t.After(*stats.Newest) {
+			newest := t
+			stats.Newest = &newest
+		}
+	}
+
+	return stats, nil
+}
+
+// ageLabel returns the label of the smallest age bucket the age fits into.
+func ageLabel(age time.Duration) string {
+	for _, bucket := range ageBuckets {
+		if bucket.MaxAge == 0 || age < bucket.MaxAge {
+			return bucket.Label
+		}
+	}
+
+	return ageBuckets[len(ageBuckets)-1].Label
+}
+
+// AgeLabels returns the labels of the age groups of Stats.ByAge, youngest first.
+func AgeLabels() []string {
+	labels := make([]string, len(ageBuckets))
+
+	for i, bucket := range ageBuckets {
+		labels[i] = bucket.Label
+	}
+
+	return labels
+}
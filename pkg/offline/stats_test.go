@@ -0,0 +1,73 @@
+package offline_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/apikey"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+	"github.com/wakatime/wakatime-cli/pkg/regex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueStats(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	now := time.Now()
+
+	old := heartbeatAt(now.Add(-40 * 24 * time.Hour))
+	old.Project = heartbeat.PointerTo("legacy")
+	old.Category = heartbeat.DebuggingCategory
+
+	recent := heartbeatAt(now.Add(-30 * time.Minute))
+	recent.Entity = "/tmp/other.go"
+
+	err := offline.NewBoltStore(fp).Push([]heartbeat.Heartbeat{old, heartbeatAt(now.Add(-2 * time.Hour)), recent})
+	require.NoError(t, err)
+
+	stats, err := offline.QueueStats(fp, apikey.Config{
+		DefaultApiKey: "00000000-0000-4000-8000-000000000000",
+		MapPatterns: []apikey.MapPattern{
+			{
+				ApiKey: "00000000-0000-4000-8000-000000000001",
+				Regex:  regex.MustCompile("other"),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, stats.Count)
+	assert.Greater(t, stats.Bytes, int64(0))
+	assert.WithinDuration(t, now.Add(-40*24*time.Hour), *stats.Oldest, time.Millisecond)
+	assert.WithinDuration(t, now.Add(-30*time.Minute), *stats.Newest, time.Millisecond)
+	assert.Equal(t, map[string]int{"<1h": 1, "<1d": 1, ">=30d": 1}, stats.ByAge)
+	assert.Equal(t, map[string]int{"<hidden>0000": 2, "<hidden>0001": 1}, stats.ByApiKey)
+	assert.Equal(t, map[string]int{"coding": 2, "debugging": 1}, stats.ByCategory)
+	assert.Equal(t, map[string]int{"wakatime-cli": 2, "legacy": 1}, stats.ByProject)
+	assert.Equal(t, 3, sum(stats.ByDay))
+	assert.Equal(t, 1, stats.ByDay[now.Add(-40*24*time.Hour).Format("2006-01-02")])
+}
+
+func TestQueueStats_Empty(t *testing.T) {
+	stats, err := offline.QueueStats(filepath.Join(t.TempDir(), "nonexisting"), apikey.Config{})
+	require.NoError(t, err)
+
+	assert.Zero(t, stats.Count)
+	assert.Nil(t, stats.Oldest)
+	assert.Nil(t, stats.Newest)
+	assert.Empty(t, stats.ByProject)
+}
+
+func sum(counts map[string]int) int {
+	var total int
+
+	for _, count := range counts {
+		total += count
+	}
+
+	return total
+}
@@ -0,0 +1,91 @@
+package offline
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/ini"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+const (
+	// BackendBolt is the name of the default storage backend, which keeps the
+	// offline queue in a bolt db file.
+	BackendBolt = "bolt"
+	// BackendSpool is the name of the storage backend, which keeps the offline
+	// queue in a spool directory.
+	BackendSpool = "spool"
+	// spoolDirname is the default spool directory name.
+	spoolDirname = ".wakatime-spool"
+)
+
+// QueueStore is a storage backend for the offline queue. Implementations must
+// be safe to be used by multiple processes at the same time.
+type QueueStore interface {
+	// Push stores the provided heartbeats. Heartbeats are deduplicated by their ID.
+	Push(hh []heartbeat.Heartbeat) error
+	// Pop leases up to limit of the oldest heartbeats. Leased heartbeats are
+	// not returned by Pop, Count or List, until they are either deleted via
+	// Delete after successful sending, or moved back to the queue via Release.
+	// Leases not handled within leaseTimeout are released automatically.
+	Pop(limit int) ([]Record, error)
+	// Delete deletes leased heartbeats with the specified ids.
+	Delete(ids []string) error
+	// Release moves leased heartbeats with the specified ids back to the queue.
+	Release(ids []string) error
+	// Count returns the total number of queued heartbeats.
+	Count() (int, error)
+	// List returns the queued heartbeats matching the passed in filter, oldest first.
+	List(filter Filter) ([]Record, error)
+	// PushDeadLetters stores heartbeats, which will never be sent again.
+	PushDeadLetters(entries []DeadLetter) error
+}
+
+// WithSpool stores the offline queue in a spool directory via SpoolStore
+// instead of the bolt db file. An empty dir defaults to SpoolDirpath.
+func WithSpool(dir string) Option {
+	return func(c *config) {
+		c.spool = true
+		c.spoolDir = dir
+	}
+}
+
+// SpoolDirpath returns the default path for the offline queue spool directory.
+// If the user's $HOME folder cannot be detected, it defaults to the current
+// directory.
+func SpoolDirpath() (string, error) {
+	home, err := ini.WakaHomeDir()
+	if err != nil {
+		return spoolDirname, fmt.Errorf("failed getting user's home directory, defaulting to current directory: %s", err)
+	}
+
+	return filepath.Join(home, spoolDirname), nil
+}
+
+// newStore returns the configured QueueStore. The bolt db at filepath is used
+// unless a spool directory was configured.
+func newStore(filepath string, cfg config) QueueStore {
+	if !cfg.spool {
+		return &BoltStore{
+			collapseWindow: cfg.collapseWindow,
+			filepath:       filepath,
+			retention:      cfg.retention,
+		}
+	}
+
+	dir := cfg.spoolDir
+	if dir == "" {
+		var err error
+
+		dir, err = SpoolDirpath()
+		if err != nil {
+			log.Warnf("failed to load spool directory: %s", err)
+		}
+	}
+
+	return &SpoolStore{
+		dir:       dir,
+		retention: cfg.retention,
+	}
+}
@@ -0,0 +1,425 @@
+package offline_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltStore(t *testing.T) {
+	testQueueStore(t, func(t *testing.T) offline.QueueStore {
+		return offline.NewBoltStore(filepath.Join(t.TempDir(), "offline.bdb"))
+	})
+}
+
+func TestSpoolStore(t *testing.T) {
+	testQueueStore(t, func(t *testing.T) offline.QueueStore {
+		return offline.NewSpoolStore(filepath.Join(t.TempDir(), "spool"))
+	})
+}
+
+func TestSpoolStore_Retention(t *testing.T) {
+	now := time.Now()
+
+	store := offline.NewSpoolStore(
+		t.TempDir(),
+		offline.WithRetention(offline.Retention{MaxAge: 90 * time.Minute, MaxEntries: 2}),
+	)
+
+	err := store.Push([]heartbeat.Heartbeat{heartbeatAt(now.Add(-2 * time.Hour))})
+	require.NoError(t, err)
+
+	err = store.Push([]heartbeat.Heartbeat{
+		heartbeatAt(now.Add(-60 * time.Minute)),
+		heartbeatAt(now.Add(-30 * time.Minute)),
+	})
+	require.NoError(t, err)
+
+	err = store.Push([]heartbeat.Heartbeat{heartbeatAt(now)})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		heartbeatAt(now.Add(-30 * time.Minute)).ID(),
+		heartbeatAt(now).ID(),
+	}, recordIDs(listAll(t, store)))
+}
+
+func TestSpoolStore_Retention_Order(t *testing.T) {
+	now := time.Now()
+
+	store := offline.NewSpoolStore(t.TempDir(), offline.WithRetention(offline.Retention{MaxEntries: 2}))
+
+	err := store.Push([]heartbeat.Heartbeat{
+		heartbeatAt(now),
+		heartbeatAt(now.Add(-2 * time.Hour)),
+	})
+	require.NoError(t, err)
+
+	err = store.Push([]heartbeat.Heartbeat{heartbeatAt(now.Add(-1 * time.Hour))})
+	require.NoError(t, err)
+
+	records, err := store.Pop(10)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		heartbeatAt(now.Add(-1 * time.Hour)).ID(),
+		heartbeatAt(now).ID(),
+	}, recordIDs(records))
+}
+
+func TestSpoolStore_DeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	store := offline.NewSpoolStore(dir)
+
+	err := store.PushDeadLetters([]offline.DeadLetter{
+		{
+			ID:        heartbeatAt(now.Add(-time.Hour)).ID(),
+			Heartbeat: heartbeatAt(now.Add(-time.Hour)),
+			Errors:    []string{"Invalid entity"},
+			Status:    400,
+			FailedAt:  now,
+		},
+	})
+	require.NoError(t, err)
+
+	err = store.PushDeadLetters([]offline.DeadLetter{
+		{
+			ID:        heartbeatAt(now).ID(),
+			Heartbeat: heartbeatAt(now),
+			Status:    500,
+			FailedAt:  now,
+		},
+	})
+	require.NoError(t, err)
+
+	entries, err := offline.ListDeadLetters("", offline.WithSpool(dir))
+	require.NoError(t, err)
+
+	require.Len(t, entries, 2)
+	assert.Equal(t, heartbeatAt(now.Add(-time.Hour)).ID(), entries[0].ID)
+	assert.Equal(t, []string{"Invalid entity"}, entries[0].Errors)
+	assert.Equal(t, 500, entries[1].Status)
+
+	count, err := offline.RetryDeadLetters("", offline.WithSpool(dir))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, count)
+
+	entries, err = offline.ListDeadLetters("", offline.WithSpool(dir))
+	require.NoError(t, err)
+
+	assert.Empty(t, entries)
+
+	assert.Equal(t, []string{
+		heartbeatAt(now.Add(-time.Hour)).ID(),
+		heartbeatAt(now).ID(),
+	}, recordIDs(listAll(t, store)))
+
+	err = store.PushDeadLetters([]offline.DeadLetter{
+		{
+			ID:        heartbeatAt(now).ID(),
+			Heartbeat: heartbeatAt(now),
+			Status:    400,
+			FailedAt:  now,
+		},
+	})
+	require.NoError(t, err)
+
+	count, err = offline.PurgeDeadLetters("", offline.WithSpool(dir))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count)
+
+	entries, err = store.DeadLetters()
+	require.NoError(t, err)
+
+	assert.Empty(t, entries)
+}
+
+func TestSpoolStore_Pop_Quarantine(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.MkdirAll(filepath.Join(dir, "queue"), 0700)
+	require.NoError(t, err)
+
+	err = os.WriteFile(
+		filepath.Join(dir, "queue", "00000000000000000001-00000000.ndjson"),
+		[]byte("{invalid\n"),
+		0600,
+	)
+	require.NoError(t, err)
+
+	store := offline.NewSpoolStore(dir)
+
+	h := heartbeatAt(time.Now())
+
+	err = store.Push([]heartbeat.Heartbeat{h})
+	require.NoError(t, err)
+
+	records, err := store.Pop(10)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{h.ID()}, recordIDs(records))
+
+	quarantined, err := os.ReadDir(filepath.Join(dir, "quarantine"))
+	require.NoError(t, err)
+
+	assert.Len(t, quarantined, 1)
+}
+
+// testQueueStore is the conformance test suite, which every QueueStore
+// implementation must pass.
+func testQueueStore(t *testing.T, newStore func(t *testing.T) offline.QueueStore) {
+	now := time.Now()
+
+	hh := []heartbeat.Heartbeat{
+		heartbeatAt(now.Add(-3 * time.Minute)),
+		heartbeatAt(now.Add(-2 * time.Minute)),
+		heartbeatAt(now.Add(-1 * time.Minute)),
+	}
+
+	ids := []string{hh[0].ID(), hh[1].ID(), hh[2].ID()}
+
+	t.Run("push count list", func(t *testing.T) {
+		store := newStore(t)
+
+		err := store.Push(hh[:2])
+		require.NoError(t, err)
+
+		err = store.Push(hh[2:])
+		require.NoError(t, err)
+
+		count, err := store.Count()
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, count)
+		assert.Equal(t, ids, recordIDs(listAll(t, store)))
+	})
+
+	t.Run("push deduplicates", func(t *testing.T) {
+		store := newStore(t)
+
+		err := store.Push(hh)
+		require.NoError(t, err)
+
+		err = store.Push(hh[:1])
+		require.NoError(t, err)
+
+		count, err := store.Count()
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("pop oldest first", func(t *testing.T) {
+		store := newStore(t)
+
+		err := store.Push([]heartbeat.Heartbeat{hh[2], hh[0]})
+		require.NoError(t, err)
+
+		err = store.Push(hh[1:2])
+		require.NoError(t, err)
+
+		records, err := store.Pop(2)
+		require.NoError(t, err)
+
+		require.Len(t, records, 2)
+		assert.Equal(t, ids[:2], recordIDs(records))
+		assert.Equal(t, hh[0].Time, records[0].Heartbeat.Time)
+		assert.Equal(t, hh[1].Time, records[1].Heartbeat.Time)
+
+		count, err := store.Count()
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, count)
+		assert.Equal(t, ids[2:], recordIDs(listAll(t, store)))
+	})
+
+	t.Run("pop empty", func(t *testing.T) {
+		store := newStore(t)
+
+		records, err := store.Pop(10)
+		require.NoError(t, err)
+
+		assert.Empty(t, records)
+
+		count, err := store.Count()
+		require.NoError(t, err)
+
+		assert.Zero(t, count)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		store := newStore(t)
+
+		err := store.Push(hh)
+		require.NoError(t, err)
+
+		records, err := store.Pop(10)
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+
+		err = store.Delete(recordIDs(records))
+		require.NoError(t, err)
+
+		count, err := store.Count()
+		require.NoError(t, err)
+
+		assert.Zero(t, count)
+
+		records, err = store.Pop(10)
+		require.NoError(t, err)
+
+		assert.Empty(t, records)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		store := newStore(t)
+
+		err := store.Push(hh)
+		require.NoError(t, err)
+
+		records, err := store.Pop(10)
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+
+		ids := recordIDs(records)
+
+		err = store.Delete(ids[:1])
+		require.NoError(t, err)
+
+		err = store.Release(ids[1:])
+		require.NoError(t, err)
+
+		assert.Equal(t, ids[1:], recordIDs(listAll(t, store)))
+
+		records, err = store.Pop(10)
+		require.NoError(t, err)
+
+		assert.Equal(t, ids[1:], recordIDs(records))
+	})
+
+	t.Run("list filter", func(t *testing.T) {
+		store := newStore(t)
+
+		other := heartbeatAt(now)
+		other.Entity = "/tmp/other.go"
+
+		err := store.Push(append(hh, other))
+		require.NoError(t, err)
+
+		records, err := store.List(offline.Filter{Entity: "/tmp/other.go"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{other.ID()}, recordIDs(records))
+
+		records, err = store.List(offline.Filter{Offset: 1, Limit: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, ids[1:], recordIDs(records))
+	})
+
+	t.Run("push dead letters", func(t *testing.T) {
+		store := newStore(t)
+
+		err := store.PushDeadLetters([]offline.DeadLetter{
+			{
+				ID:        hh[0].ID(),
+				Heartbeat: hh[0],
+				Status:    400,
+				FailedAt:  now,
+			},
+		})
+		require.NoError(t, err)
+
+		count, err := store.Count()
+		require.NoError(t, err)
+
+		assert.Zero(t, count)
+	})
+
+	t.Run("concurrent pop", func(t *testing.T) {
+		store := newStore(t)
+
+		var pushed []heartbeat.Heartbeat
+
+		for i := 0; i < 20; i++ {
+			h := heartbeatAt(now.Add(time.Duration(i) * time.Second))
+
+			err := store.Push([]heartbeat.Heartbeat{h})
+			require.NoError(t, err)
+
+			pushed = append(pushed, h)
+		}
+
+		var (
+			mu     sync.Mutex
+			popped []string
+			wg     sync.WaitGroup
+		)
+
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				records, err := store.Pop(5)
+				assert.NoError(t, err)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				popped = append(popped, recordIDs(records)...)
+			}()
+		}
+
+		wg.Wait()
+
+		// every heartbeat is leased at most once
+		seen := make(map[string]bool)
+
+		for _, id := range popped {
+			assert.False(t, seen[id], "heartbeat %q popped twice", id)
+
+			seen[id] = true
+		}
+
+		var expected []string
+		for _, h := range pushed {
+			expected = append(expected, h.ID())
+		}
+
+		assert.Subset(t, expected, popped)
+		assert.NotEmpty(t, popped)
+	})
+}
+
+func listAll(t *testing.T, store offline.QueueStore) []offline.Record {
+	t.Helper()
+
+	records, err := store.List(offline.Filter{})
+	require.NoError(t, err)
+
+	return records
+}
+
+func recordIDs(records []offline.Record) []string {
+	var ids []string
+
+	for _, r := range records {
+		ids = append(ids, r.ID)
+	}
+
+	return ids
+}
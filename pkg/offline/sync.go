@@ -0,0 +1,174 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/log"
+)
+
+const (
+	// SyncWorkersDefault is the default number of concurrent sync workers.
+	SyncWorkersDefault = 1
+	// SyncWorkersMax is the maximum number of concurrent sync workers.
+	SyncWorkersMax = 16
+	// throttleMinDelay is the initial delay between requests, once the api
+	// signals throttling.
+	throttleMinDelay = time.Second
+	// throttleMaxDelay is the maximum delay between requests, while the api
+	// keeps signaling throttling.
+	throttleMaxDelay = time.Minute
+)
+
+// WithSyncWorkers sets the number of workers, which send queued heartbeats
+// concurrently during sync.
+func WithSyncWorkers(n int) Option {
+	return func(c *config) {
+		c.syncWorkers = n
+	}
+}
+
+// syncState is the state shared between concurrent sync workers.
+type syncState struct {
+	mu        sync.Mutex
+	remaining int
+	delay     time.Duration
+	resumeAt  time.Time
+	err       error
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+func newSyncState(syncLimit int) *syncState {
+	return &syncState{
+		remaining: syncLimit,
+		stop:      make(chan struct{}),
+	}
+}
+
+// claim reserves the number of heartbeats to pop for the next batch. Returns
+// 0, once the sync limit has been reached.
+func (s *syncState) claim() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	num := SendLimit
+	if s.remaining < num {
+		num = s.remaining
+	}
+
+	s.remaining -= num
+
+	return num
+}
+
+// unclaim returns unused reservations, if fewer heartbeats were popped.
+func (s *syncState) unclaim(num int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remaining += num
+}
+
+// wait blocks while sending is throttled. Returns false, if all workers have
+// been stopped or the context is done in the meantime.
+func (s *syncState) wait(ctx context.Context) bool {
+	s.mu.Lock()
+	delay := time.Until(s.resumeAt)
+	s.mu.Unlock()
+
+	if delay <= 0 {
+		select {
+		case <-s.stop:
+			return false
+		case <-ctx.Done():
+			return false
+		default:
+			return true
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-s.stop:
+		return false
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// throttle delays all further requests, doubling the delay on every
+// consecutive call, up to throttleMaxDelay.
+func (s *syncState) throttle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.delay == 0:
+		s.delay = throttleMinDelay
+	case s.delay < throttleMaxDelay:
+		s.delay *= 2
+	}
+
+	if s.delay > throttleMaxDelay {
+		s.delay = throttleMaxDelay
+	}
+
+	s.resumeAt = time.Now().Add(s.delay)
+
+	log.Warnf("api is throttling heartbeats, delaying sync for %s", s.delay)
+}
+
+// resetThrottle resets the delay after a batch has been sent without throttling.
+func (s *syncState) resetThrottle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delay = 0
+}
+
+// fail records the error of a worker. Authentication and rate limit errors
+// stop all workers, as no further request can succeed respectively is allowed
+// before the deadline signaled by the api. The first error is kept, unless it
+// gets superseded by one of these.
+func (s *syncState) fail(err error) {
+	var (
+		errauth      api.ErrAuth
+		errratelimit api.ErrRateLimit
+	)
+
+	isFatal := errors.As(err, &errauth) || errors.As(err, &errratelimit)
+
+	s.mu.Lock()
+
+	if s.err == nil || isFatal {
+		s.err = err
+	}
+
+	s.mu.Unlock()
+
+	if isFatal {
+		s.stopOnce.Do(func() { close(s.stop) })
+	}
+}
+
+// isThrottled reports, whether the api signaled throttling for any of the
+// sent heartbeats.
+func isThrottled(results []heartbeat.Result) bool {
+	for _, result := range results {
+		if result.Status == http.StatusTooManyRequests {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,262 @@
+package offline_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/api"
+	"github.com/wakatime/wakatime-cli/pkg/heartbeat"
+	"github.com/wakatime/wakatime-cli/pkg/offline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync_Workers(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	pushed := pushHeartbeats(t, fp, 100)
+
+	var (
+		mu      sync.Mutex
+		sent    []string
+		active  int
+		maxSeen int
+	)
+
+	syncFn := offline.Sync(fp, 1000, offline.WithSyncWorkers(4))
+
+	err := syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		mu.Lock()
+		active++
+
+		if active > maxSeen {
+			maxSeen = active
+		}
+		mu.Unlock()
+
+		// outlast the 50ms polling interval of workers waiting for the db file lock
+		time.Sleep(200 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		active--
+
+		for _, h := range hh {
+			sent = append(sent, h.ID())
+		}
+
+		return createdResults(hh), nil
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, pushed, sent)
+	assert.Greater(t, maxSeen, 1)
+
+	count, err := offline.CountHeartbeats(fp)
+	require.NoError(t, err)
+
+	assert.Zero(t, count)
+}
+
+func TestSync_ContextDone(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	_ = pushHeartbeats(t, fp, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var numCalls int
+
+	syncFn := offline.Sync(fp, 1000)
+
+	err := syncFn(ctx, func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		numCalls++
+
+		// the run deadline passes while sending the first batch
+		cancel()
+
+		return createdResults(hh), nil
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, "sync aborted: context canceled", err.Error())
+	assert.Equal(t, 1, numCalls)
+
+	count, err := offline.CountHeartbeats(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, 100-offline.SendLimit, count)
+}
+
+func TestSync_Workers_SyncLimit(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	_ = pushHeartbeats(t, fp, 100)
+
+	var (
+		mu   sync.Mutex
+		sent int
+	)
+
+	syncFn := offline.Sync(fp, 60, offline.WithSyncWorkers(4))
+
+	err := syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		sent += len(hh)
+
+		return createdResults(hh), nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 60, sent)
+
+	count, err := offline.CountHeartbeats(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, 40, count)
+}
+
+func TestSync_Workers_ErrAuth(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	_ = pushHeartbeats(t, fp, 100)
+
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+
+	syncFn := offline.Sync(fp, 1000, offline.WithSyncWorkers(4))
+
+	err := syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls++
+
+		return nil, api.ErrAuth("unauthorized")
+	})
+	require.Error(t, err)
+
+	var errauth api.ErrAuth
+
+	assert.True(t, errors.As(err, &errauth))
+	assert.LessOrEqual(t, calls, 4)
+
+	// failed batches are released back to the queue
+	count, err := offline.CountHeartbeats(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, 100, count)
+}
+
+func TestSync_Workers_ErrRateLimit(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	_ = pushHeartbeats(t, fp, 100)
+
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+
+	syncFn := offline.Sync(fp, 1000, offline.WithSyncWorkers(4))
+
+	err := syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+
+		if call > 1 {
+			return nil, api.Err("failed")
+		}
+
+		// ensure the rate limit error supersedes errors of other workers
+		time.Sleep(50 * time.Millisecond)
+
+		return nil, api.ErrRateLimit{Msg: "rate limited", RetryAfter: time.Minute}
+	})
+	require.Error(t, err)
+
+	var errratelimit api.ErrRateLimit
+
+	require.True(t, errors.As(err, &errratelimit))
+
+	assert.Equal(t, time.Minute, errratelimit.RetryAfter)
+	assert.LessOrEqual(t, calls, 4)
+
+	count, err := offline.CountHeartbeats(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, 100, count)
+}
+
+func TestSync_Throttled(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "offline.bdb")
+
+	_ = pushHeartbeats(t, fp, 2)
+
+	var calls []time.Time
+
+	syncFn := offline.Sync(fp, 1000)
+
+	err := syncFn(context.Background(), func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		calls = append(calls, time.Now())
+
+		if len(calls) == 1 {
+			return []heartbeat.Result{
+				{Status: 201, Heartbeat: hh[0]},
+				{Status: 429, Heartbeat: hh[1]},
+			}, nil
+		}
+
+		return createdResults(hh), nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, calls, 2)
+	assert.GreaterOrEqual(t, calls[1].Sub(calls[0]), time.Second)
+}
+
+func pushHeartbeats(t *testing.T, fp string, n int) []string {
+	t.Helper()
+
+	start := time.Now().Add(-time.Hour)
+
+	var (
+		hh  []heartbeat.Heartbeat
+		ids []string
+	)
+
+	for i := 0; i < n; i++ {
+		h := heartbeatAt(start.Add(time.Duration(i) * time.Second))
+
+		hh = append(hh, h)
+		ids = append(ids, h.ID())
+	}
+
+	err := offline.NewBoltStore(fp).Push(hh)
+	require.NoError(t, err)
+
+	return ids
+}
+
+func createdResults(hh []heartbeat.Heartbeat) []heartbeat.Result {
+	results := make([]heartbeat.Result, len(hh))
+
+	for i, h := range hh {
+		results[i] = heartbeat.Result{Status: 201, Heartbeat: h}
+	}
+
+	return results
+}
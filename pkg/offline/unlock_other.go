@@ -0,0 +1,14 @@
+//go:build !windows
+
+package offline
+
+import (
+	"os"
+	"syscall"
+)
+
+// unlockFile releases the file lock of a db file explicitly. Closing the file
+// does not release the lock, while bolt still has the file memory mapped.
+func unlockFile(f *os.File) {
+	_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
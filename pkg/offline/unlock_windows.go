@@ -0,0 +1,9 @@
+//go:build windows
+
+package offline
+
+import "os"
+
+// unlockFile is a no-op on windows, where the file lock is released on closing
+// the file handle.
+func unlockFile(_ *os.File) {}
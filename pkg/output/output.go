@@ -0,0 +1,46 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Output represents the format in which a command prints its results.
+type Output int
+
+const (
+	// TextOutput renders human readable text. This is the default.
+	TextOutput Output = iota
+	// JSONOutput renders machine readable json.
+	JSONOutput
+)
+
+const (
+	textOutputString = "text"
+	jsonOutputString = "json"
+)
+
+// Parse parses an output format from a string. An empty string
+// defaults to TextOutput.
+func Parse(s string) (Output, error) {
+	switch strings.ToLower(s) {
+	case "", textOutputString:
+		return TextOutput, nil
+	case jsonOutputString:
+		return JSONOutput, nil
+	default:
+		return 0, fmt.Errorf("invalid output %q", s)
+	}
+}
+
+// String implements fmt.Stringer interface.
+func (o Output) String() string {
+	switch o {
+	case TextOutput:
+		return textOutputString
+	case JSONOutput:
+		return jsonOutputString
+	default:
+		return ""
+	}
+}
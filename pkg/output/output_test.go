@@ -0,0 +1,40 @@
+package output_test
+
+import (
+	"testing"
+
+	"github.com/wakatime/wakatime-cli/pkg/output"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]output.Output{
+		"":     output.TextOutput,
+		"text": output.TextOutput,
+		"json": output.JSONOutput,
+		"JSON": output.JSONOutput,
+	}
+
+	for value, expected := range tests {
+		t.Run(value, func(t *testing.T) {
+			parsed, err := output.Parse(value)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, parsed)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := output.Parse("yaml")
+	require.Error(t, err)
+
+	assert.Equal(t, `invalid output "yaml"`, err.Error())
+}
+
+func TestOutput_String(t *testing.T) {
+	assert.Equal(t, "text", output.TextOutput.String())
+	assert.Equal(t, "json", output.JSONOutput.String())
+}
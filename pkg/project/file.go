@@ -2,6 +2,7 @@ package project
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,7 +19,7 @@ type File struct {
 // Detect get information from a .wakatime-project file about the project for
 // a given file. First line of .wakatime-project sets the project
 // name. Second line sets the current branch name.
-func (f File) Detect() (Result, bool, error) {
+func (f File) Detect(_ context.Context) (Result, bool, error) {
 	fp, ok := FindFileOrDirectory(f.Filepath, WakaTimeProjectFile)
 	if !ok {
 		return Result{}, false, nil
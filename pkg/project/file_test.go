@@ -1,6 +1,7 @@
 package project_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -20,7 +21,7 @@ func TestFile_Detect_FileExists(t *testing.T) {
 		Filepath: rp,
 	}
 
-	result, detected, err := f.Detect()
+	result, detected, err := f.Detect(context.Background())
 	require.NoError(t, err)
 
 	expected := project.Result{
@@ -52,7 +53,7 @@ func TestFile_Detect_ParentFolderExists(t *testing.T) {
 		Filepath: dir,
 	}
 
-	result, detected, err := f.Detect()
+	result, detected, err := f.Detect(context.Background())
 	require.NoError(t, err)
 
 	expected := project.Result{
@@ -77,7 +78,7 @@ func TestFile_Detect_NoFileFound(t *testing.T) {
 		Filepath: tmpDir,
 	}
 
-	result, detected, err := f.Detect()
+	result, detected, err := f.Detect(context.Background())
 	require.NoError(t, err)
 
 	expected := project.Result{}
@@ -96,7 +97,7 @@ func TestFile_Detect_InvalidPath(t *testing.T) {
 		Filepath: tmpFile.Name(),
 	}
 
-	_, detected, err := f.Detect()
+	_, detected, err := f.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.False(t, detected)
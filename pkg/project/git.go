@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -19,7 +20,7 @@ type Git struct {
 
 // Detect gets information about the git project for a given file.
 // It tries to return a project and branch name.
-func (g Git) Detect() (Result, bool, error) {
+func (g Git) Detect(_ context.Context) (Result, bool, error) {
 	fp := g.Filepath
 
 	// Take only the directory
@@ -1,6 +1,7 @@
 package project_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -24,7 +25,7 @@ func TestGit_Detect(t *testing.T) {
 		Filepath: filepath.Join(fp, "wakatime-cli/src/pkg/file.go"),
 	}
 
-	result, detected, err := g.Detect()
+	result, detected, err := g.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -43,7 +44,7 @@ func TestGit_Detect_BranchWithSlash(t *testing.T) {
 		Filepath: filepath.Join(fp, "wakatime-cli/src/pkg/file.go"),
 	}
 
-	result, detected, err := g.Detect()
+	result, detected, err := g.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -62,7 +63,7 @@ func TestGit_Detect_DetachedHead(t *testing.T) {
 		Filepath: filepath.Join(fp, "wakatime-cli/src/pkg/file.go"),
 	}
 
-	result, detected, err := g.Detect()
+	result, detected, err := g.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -101,7 +102,7 @@ func TestGit_Detect_GitConfigFile_File(t *testing.T) {
 				Filepath: test.Filepath,
 			}
 
-			result, detected, err := g.Detect()
+			result, detected, err := g.Detect(context.Background())
 			require.NoError(t, err)
 
 			assert.True(t, detected)
@@ -122,7 +123,7 @@ func TestGit_Detect_Worktree(t *testing.T) {
 		Filepath: filepath.Join(fp, "api/src/pkg/file.go"),
 	}
 
-	result, detected, err := g.Detect()
+	result, detected, err := g.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -142,7 +143,7 @@ func TestGit_Detect_Submodule(t *testing.T) {
 		SubmodulePatterns: []regex.Regex{regexp.MustCompile("not_matching")},
 	}
 
-	result, detected, err := g.Detect()
+	result, detected, err := g.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -162,7 +163,7 @@ func TestGit_Detect_SubmoduleDisabled(t *testing.T) {
 		SubmodulePatterns: []regex.Regex{regexp.MustCompile(".*billing.*")},
 	}
 
-	result, detected, err := g.Detect()
+	result, detected, err := g.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
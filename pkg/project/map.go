@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"path/filepath"
 
 	"github.com/wakatime/wakatime-cli/pkg/log"
@@ -25,7 +26,7 @@ type Map struct {
 // Will result in file '/home/user/projects/foo/src/main.c' to have
 // project name 'new project name' and file '/home/user/projects/bar42/main.c'
 // to have project name 'project42'.
-func (m Map) Detect() (Result, bool, error) {
+func (m Map) Detect(_ context.Context) (Result, bool, error) {
 	result, ok := matchPattern(m.Filepath, m.Patterns)
 	if !ok {
 		return Result{}, false, nil
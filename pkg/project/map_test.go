@@ -1,6 +1,7 @@
 package project_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -30,7 +31,7 @@ func TestMap_Detect(t *testing.T) {
 		},
 	}
 
-	result, detected, err := m.Detect()
+	result, detected, err := m.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -60,7 +61,7 @@ func TestMap_Detect_RegexReplace(t *testing.T) {
 		},
 	}
 
-	result, detected, err := m.Detect()
+	result, detected, err := m.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -87,7 +88,7 @@ func TestMap_Detect_NoMatch(t *testing.T) {
 		},
 	}
 
-	result, detected, err := m.Detect()
+	result, detected, err := m.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.False(t, detected)
@@ -101,7 +102,7 @@ func TestMap_Detect_ZeroPatterns(t *testing.T) {
 		Patterns: []project.MapPattern{},
 	}
 
-	_, detected, err := m.Detect()
+	_, detected, err := m.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.False(t, detected)
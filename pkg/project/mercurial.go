@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -15,7 +16,7 @@ type Mercurial struct {
 }
 
 // Detect gets information about the mercurial project for a given file.
-func (m Mercurial) Detect() (Result, bool, error) {
+func (m Mercurial) Detect(_ context.Context) (Result, bool, error) {
 	var fp string
 
 	// Take only the directory
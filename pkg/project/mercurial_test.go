@@ -1,6 +1,7 @@
 package project_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -18,7 +19,7 @@ func TestMercurial_Detect(t *testing.T) {
 		Filepath: filepath.Join(fp, "wakatime-cli/src/pkg/file.go"),
 	}
 
-	result, detected, err := m.Detect()
+	result, detected, err := m.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -37,7 +38,7 @@ func TestMercurial_Detect_BranchWithSlash(t *testing.T) {
 		Filepath: filepath.Join(fp, "wakatime-cli/src/pkg/file.go"),
 	}
 
-	result, detected, err := m.Detect()
+	result, detected, err := m.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -56,7 +57,7 @@ func TestMercurial_Detect_NoBranch(t *testing.T) {
 		Filepath: filepath.Join(fp, "wakatime-cli/src/pkg/file.go"),
 	}
 
-	result, detected, err := m.Detect()
+	result, detected, err := m.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
@@ -30,7 +31,7 @@ const (
 
 // Detecter is a common interface for project.
 type Detecter interface {
-	Detect() (Result, bool, error)
+	Detect(ctx context.Context) (Result, bool, error)
 	String() string
 }
 
@@ -65,7 +66,7 @@ type MapPattern struct {
 // the --alternate-project arg.
 func WithDetection(config Config) heartbeat.HandleOption {
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			for n, h := range hh {
 				log.Debugln("execute project detection for: ", h.Entity)
 
@@ -76,14 +77,14 @@ func WithDetection(config Config) heartbeat.HandleOption {
 					continue
 				}
 
-				result := Detect(h.Entity, config.MapPatterns)
+				result := Detect(ctx, h.Entity, config.MapPatterns)
 
 				if h.ProjectOverride != "" {
 					result.Project = h.ProjectOverride
 				}
 
 				if result.Project == "" || result.Branch == "" {
-					revControlResult := DetectWithRevControl(h.Entity, config.SubmodulePatterns)
+					revControlResult := DetectWithRevControl(ctx, h.Entity, config.SubmodulePatterns)
 
 					result.Branch = firstNonEmptyString(result.Branch, revControlResult.Branch)
 					result.Folder = firstNonEmptyString(result.Folder, revControlResult.Folder)
@@ -105,13 +106,13 @@ func WithDetection(config Config) heartbeat.HandleOption {
 				hh[n].ProjectPath = result.Folder
 			}
 
-			return next(hh)
+			return next(ctx, hh)
 		}
 	}
 }
 
 // Detect finds the current project and branch from config plugins.
-func Detect(entity string, patterns []MapPattern) Result {
+func Detect(ctx context.Context, entity string, patterns []MapPattern) Result {
 	var configPlugins = []Detecter{
 		File{
 			Filepath: entity,
@@ -125,7 +126,7 @@ func Detect(entity string, patterns []MapPattern) Result {
 	for _, p := range configPlugins {
 		log.Debugln("execute", p.String())
 
-		result, detected, err := p.Detect()
+		result, detected, err := p.Detect(ctx)
 		if err != nil {
 			log.Errorf("unexpected error occurred at %q: %s", p.String(), err)
 			continue
@@ -138,7 +139,7 @@ func Detect(entity string, patterns []MapPattern) Result {
 }
 
 // DetectWithRevControl finds the current project and branch from rev control.
-func DetectWithRevControl(entity string, submodulePatterns []regex.Regex) Result {
+func DetectWithRevControl(ctx context.Context, entity string, submodulePatterns []regex.Regex) Result {
 	var revControlPlugins = []Detecter{
 		Git{
 			Filepath:          entity,
@@ -158,7 +159,7 @@ func DetectWithRevControl(entity string, submodulePatterns []regex.Regex) Result
 	for _, p := range revControlPlugins {
 		log.Debugln("execute", p.String())
 
-		result, detected, err := p.Detect()
+		result, detected, err := p.Detect(ctx)
 		if err != nil {
 			log.Errorf("unexpected error occurred at %q: %s", p.String(), err)
 			continue
@@ -1,6 +1,7 @@
 package project_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -68,7 +69,7 @@ func TestWithDetection_EntityNotFile(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			opt := project.WithDetection(project.Config{})
 
-			handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+			handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 				assert.Equal(t, []heartbeat.Heartbeat{
 					test.Expected,
 				}, hh)
@@ -76,7 +77,7 @@ func TestWithDetection_EntityNotFile(t *testing.T) {
 				return nil, nil
 			})
 
-			_, err := handle(test.Heartbeats)
+			_, err := handle(context.Background(), test.Heartbeats)
 			require.NoError(t, err)
 		})
 	}
@@ -95,7 +96,7 @@ func TestWithDetection_OverrideTakesPrecedence(t *testing.T) {
 
 	opt := project.WithDetection(project.Config{})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				Entity:           entity,
@@ -111,7 +112,7 @@ func TestWithDetection_OverrideTakesPrecedence(t *testing.T) {
 		return nil, nil
 	})
 
-	_, err := handle([]heartbeat.Heartbeat{
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{
 		{
 			EntityType:       heartbeat.FileType,
 			Entity:           entity,
@@ -127,7 +128,7 @@ func TestWithDetection_NonExistingEntity_AlternateUsed(t *testing.T) {
 
 	opt := project.WithDetection(project.Config{})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				Entity:           entity,
@@ -141,7 +142,7 @@ func TestWithDetection_NonExistingEntity_AlternateUsed(t *testing.T) {
 		return nil, nil
 	})
 
-	_, err := handle([]heartbeat.Heartbeat{
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{
 		{
 			EntityType:       heartbeat.FileType,
 			Entity:           entity,
@@ -156,7 +157,7 @@ func TestWithDetection_NonExistingEntity_OverrideTakesPrecedence(t *testing.T) {
 
 	opt := project.WithDetection(project.Config{})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
 				Entity:          entity,
@@ -170,7 +171,7 @@ func TestWithDetection_NonExistingEntity_OverrideTakesPrecedence(t *testing.T) {
 		return nil, nil
 	})
 
-	_, err := handle([]heartbeat.Heartbeat{
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{
 		{
 			EntityType:      heartbeat.FileType,
 			Entity:          entity,
@@ -195,7 +196,7 @@ func TestWithDetection_ObfuscateProject(t *testing.T) {
 		ShouldObfuscateProject: true,
 	})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.NotEmpty(t, hh[0].Project)
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
@@ -210,7 +211,7 @@ func TestWithDetection_ObfuscateProject(t *testing.T) {
 		return nil, nil
 	})
 
-	_, err := handle([]heartbeat.Heartbeat{
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{
 		{
 			EntityType: heartbeat.FileType,
 			Entity:     entity,
@@ -242,7 +243,7 @@ func TestWithDetection_WakatimeProjectTakesPrecedence(t *testing.T) {
 		ShouldObfuscateProject: true,
 	})
 
-	handle := opt(func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+	handle := opt(func(_ context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 		assert.NotEmpty(t, hh[0].Project)
 		assert.Equal(t, []heartbeat.Heartbeat{
 			{
@@ -258,7 +259,7 @@ func TestWithDetection_WakatimeProjectTakesPrecedence(t *testing.T) {
 		return nil, nil
 	})
 
-	_, err := handle([]heartbeat.Heartbeat{
+	_, err := handle(context.Background(), []heartbeat.Heartbeat{
 		{
 			EntityType:       heartbeat.FileType,
 			Entity:           entity,
@@ -269,7 +270,7 @@ func TestWithDetection_WakatimeProjectTakesPrecedence(t *testing.T) {
 }
 
 func TestDetect_FileDetected(t *testing.T) {
-	result := project.Detect("testdata/entity.any", []project.MapPattern{})
+	result := project.Detect(context.Background(), "testdata/entity.any", []project.MapPattern{})
 
 	assert.Equal(t, "master", result.Branch)
 	assert.Contains(t, result.Folder, "testdata")
@@ -295,7 +296,7 @@ func TestDetect_MapDetected(t *testing.T) {
 		},
 	}
 
-	result := project.Detect(tmpFile.Name(), patterns)
+	result := project.Detect(context.Background(), tmpFile.Name(), patterns)
 
 	assert.Empty(t, result.Branch)
 	assert.Contains(t, result.Folder, filepath.Dir(tmpFile.Name()))
@@ -306,6 +307,7 @@ func TestDetectWithRevControl_GitDetected(t *testing.T) {
 	fp := setupTestGitBasic(t)
 
 	result := project.DetectWithRevControl(
+		context.Background(),
 		filepath.Join(fp, "wakatime-cli/src/pkg/file.go"),
 		[]regex.Regex{},
 	)
@@ -324,7 +326,7 @@ func TestDetect_NoProjectDetected(t *testing.T) {
 
 	defer tmpFile.Close()
 
-	result := project.Detect(tmpFile.Name(), []project.MapPattern{})
+	result := project.Detect(context.Background(), tmpFile.Name(), []project.MapPattern{})
 
 	assert.Empty(t, result.Branch)
 	assert.Empty(t, result.Folder)
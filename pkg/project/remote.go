@@ -0,0 +1,19 @@
+package project
+
+import "time"
+
+// Remote represents a project of the user as stored by the api.
+type Remote struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	LastHeartbeatAt *time.Time `json:"last_heartbeat_at"`
+	Repository      string     `json:"repository,omitempty"`
+}
+
+// RemotePage is a single page of the projects of the user as returned by the api.
+type RemotePage struct {
+	Projects   []Remote `json:"projects"`
+	Page       int      `json:"page"`
+	TotalPages int      `json:"total_pages"`
+	Total      int      `json:"total"`
+}
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"path/filepath"
@@ -16,9 +17,10 @@ type Subversion struct {
 	Filepath string
 }
 
-// Detect gets information about the svn project for a given file.
-func (s Subversion) Detect() (Result, bool, error) {
-	binary, ok := findSvnBinary()
+// Detect gets information about the svn project for a given file. Running svn
+// is aborted, once the context is done.
+func (s Subversion) Detect(ctx context.Context) (Result, bool, error) {
+	binary, ok := findSvnBinary(ctx)
 	if !ok {
 		log.Debugln("svn binary not found")
 		return Result{}, false, nil
@@ -37,7 +39,7 @@ func (s Subversion) Detect() (Result, bool, error) {
 		return Result{}, false, nil
 	}
 
-	info, ok, err := svnInfo(filepath.Join(svnConfigFile, "..", ".."), binary)
+	info, ok, err := svnInfo(ctx, filepath.Join(svnConfigFile, "..", ".."), binary)
 	if err != nil {
 		return Result{}, false, Err(fmt.Errorf("failed to get svn info: %w", err).Error())
 	}
@@ -53,12 +55,12 @@ func (s Subversion) Detect() (Result, bool, error) {
 	}, true, nil
 }
 
-func svnInfo(fp string, binary string) (map[string]string, bool, error) {
-	if runtime.GOOS == "darwin" && !hasXcodeTools() {
+func svnInfo(ctx context.Context, fp string, binary string) (map[string]string, bool, error) {
+	if runtime.GOOS == "darwin" && !hasXcodeTools(ctx) {
 		return nil, false, nil
 	}
 
-	cmd := exec.Command(binary, "info", fp)
+	cmd := exec.CommandContext(ctx, binary, "info", fp)
 	out, err := cmd.Output()
 
 	if err != nil {
@@ -77,7 +79,7 @@ func svnInfo(fp string, binary string) (map[string]string, bool, error) {
 	return result, true, nil
 }
 
-func findSvnBinary() (string, bool) {
+func findSvnBinary(ctx context.Context) (string, bool) {
 	locations := []string{
 		"svn",
 		"/usr/bin/svn",
@@ -85,7 +87,7 @@ func findSvnBinary() (string, bool) {
 	}
 
 	for _, loc := range locations {
-		cmd := exec.Command(loc, "--version")
+		cmd := exec.CommandContext(ctx, loc, "--version")
 
 		err := cmd.Run()
 		if err != nil {
@@ -99,8 +101,8 @@ func findSvnBinary() (string, bool) {
 	return "", false
 }
 
-func hasXcodeTools() bool {
-	cmd := exec.Command("/usr/bin/xcode-select", "-p")
+func hasXcodeTools(ctx context.Context) bool {
+	cmd := exec.CommandContext(ctx, "/usr/bin/xcode-select", "-p")
 
 	return cmd.Run() == nil
 }
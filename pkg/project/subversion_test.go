@@ -1,6 +1,7 @@
 package project_test
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -21,7 +22,7 @@ func TestSubversion_Detect(t *testing.T) {
 		Filepath: filepath.Join(fp, "wakatime-cli", "src", "pkg", "file.go"),
 	}
 
-	result, detected, err := s.Detect()
+	result, detected, err := s.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -41,7 +42,7 @@ func TestSubversion_Detect_Branch(t *testing.T) {
 		Filepath: filepath.Join(fp, "wakatime-cli/src/pkg/file.go"),
 	}
 
-	result, detected, err := s.Detect()
+	result, detected, err := s.Detect(context.Background())
 	require.NoError(t, err)
 
 	assert.True(t, detected)
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"path/filepath"
 	"runtime"
 )
@@ -12,7 +13,7 @@ type Tfvc struct {
 }
 
 // Detect gets information about the tfvc project for a given file.
-func (t Tfvc) Detect() (Result, bool, error) {
+func (t Tfvc) Detect(_ context.Context) (Result, bool, error) {
 	var fp string
 
 	// Take only the directory
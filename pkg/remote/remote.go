@@ -2,6 +2,7 @@ package remote
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -50,7 +51,7 @@ type Client struct {
 // download to a temporary directory.
 func WithDetection() heartbeat.HandleOption {
 	return func(next heartbeat.Handle) heartbeat.Handle {
-		return func(hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
+		return func(ctx context.Context, hh []heartbeat.Heartbeat) ([]heartbeat.Result, error) {
 			log.Debugln("execute remote file detection")
 
 			var (
@@ -71,6 +72,12 @@ func WithDetection() heartbeat.HandleOption {
 					continue
 				}
 
+				if ctx.Err() != nil {
+					log.Warnf("skip downloading remote file %s: %s", h.Entity, ctx.Err())
+
+					continue
+				}
+
 				if tmpDir == "" {
 					tmpDir, err = os.MkdirTemp(os.TempDir(), "")
 					if err != nil {
@@ -94,7 +101,7 @@ func WithDetection() heartbeat.HandleOption {
 					continue
 				}
 
-				err = c.DownloadFile(tmpFile.Name())
+				err = c.DownloadFile(ctx, tmpFile.Name())
 				if err != nil {
 					log.Errorf("failed to download file to temporary folder: %s", err)
 
@@ -106,7 +113,7 @@ func WithDetection() heartbeat.HandleOption {
 				hh[i].EntityRaw = h.Entity
 			}
 
-			return next(hh)
+			return next(ctx, hh)
 		}
 	}
 }
@@ -157,9 +164,10 @@ func NewClient(address string) (Client, error) {
 	}, nil
 }
 
-// DownloadFile downloads a remote file and copy to a local file.
-func (c Client) DownloadFile(localFile string) error {
-	conn, sc, err := c.Connect()
+// DownloadFile downloads a remote file and copy to a local file. The download
+// is aborted, once the context is done.
+func (c Client) DownloadFile(ctx context.Context, localFile string) error {
+	conn, sc, err := c.Connect(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to connect to sftp host: %s", err)
 	}
@@ -167,6 +175,9 @@ func (c Client) DownloadFile(localFile string) error {
 	defer conn.Close()
 	defer sc.Close()
 
+	stop := closeOnDone(ctx, conn)
+	defer stop()
+
 	srcFile, err := sc.OpenFile(c.Path, os.O_RDONLY)
 	if err != nil {
 		return fmt.Errorf("failed to open remote file: %s", err)
@@ -190,9 +201,9 @@ func (c Client) DownloadFile(localFile string) error {
 }
 
 // Connect connects to sftp host.
-func (c Client) Connect() (*ssh.Client, *sftp.Client, error) {
+func (c Client) Connect(ctx context.Context) (*ssh.Client, *sftp.Client, error) {
 	// Initialize client configuration
-	sshClient, err := c.sshClient()
+	sshClient, err := c.sshClient(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -349,7 +360,7 @@ func (c Client) warnIfUsingRevokedHostKeys() {
 	}
 }
 
-func (c Client) sshClient() (*ssh.Client, error) {
+func (c Client) sshClient(ctx context.Context) (*ssh.Client, error) {
 	var auths []ssh.AuthMethod
 
 	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
@@ -385,7 +396,7 @@ func (c Client) sshClient() (*ssh.Client, error) {
 		config.HostKeyCallback = ssh.InsecureIgnoreHostKey() // nolint:gosec
 
 		// Connect to server
-		client, err := dial(addr, &config)
+		client, err := dial(ctx, addr, &config)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to '%s': %s", addr, err)
 		}
@@ -404,7 +415,7 @@ func (c Client) sshClient() (*ssh.Client, error) {
 		config.HostKeyCallback = ssh.InsecureIgnoreHostKey() // nolint:gosec
 
 		// Connect to server
-		client, err := dial(addr, &config)
+		client, err := dial(ctx, addr, &config)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to '%s': %s", addr, err)
 		}
@@ -420,7 +431,7 @@ func (c Client) sshClient() (*ssh.Client, error) {
 		config.HostKeyCallback = ssh.FixedHostKey(hostKey)
 
 		// Connect to server
-		client, err := dial(addr, &config)
+		client, err := dial(ctx, addr, &config)
 		if err != nil {
 			log.Warnf("failed to connect to '%s': %s", addr, err)
 
@@ -492,11 +503,40 @@ func contains(values []string, args ...string) bool {
 	return false
 }
 
-func dial(addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
-	conn, err := ssh.Dial("tcp", addr, config)
+func dial(ctx context.Context, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	dialer := net.Dialer{Timeout: config.Timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial to '%s': %s", addr, err)
 	}
 
-	return conn, nil
+	// abort a hanging handshake, once the context is done
+	stop := closeOnDone(ctx, conn)
+	defer stop()
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("failed to dial to '%s': %s", addr, err)
+	}
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// closeOnDone closes c, once the context is done. The returned function stops
+// watching the context.
+func closeOnDone(ctx context.Context, c io.Closer) func() {
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = c.Close()
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }
 }
@@ -0,0 +1,165 @@
+package summary
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateFormat is the format of the start and end dates of a date range.
+const DateFormat = "2006-01-02"
+
+// Presets of date ranges, which can be passed to ParseRange instead of a start date.
+const (
+	// PresetToday is the current day.
+	PresetToday = "today"
+	// PresetYesterday is the day before the current day.
+	PresetYesterday = "yesterday"
+	// PresetLast7Days are the last 7 days, including the current day.
+	PresetLast7Days = "last_7_days"
+	// PresetLast30Days are the last 30 days, including the current day.
+	PresetLast30Days = "last_30_days"
+	// PresetThisWeek is the current week, starting on monday.
+	PresetThisWeek = "this_week"
+	// PresetLastWeek is the week before the current week, starting on monday.
+	PresetLastWeek = "last_week"
+	// PresetThisMonth is the current month.
+	PresetThisMonth = "this_month"
+	// PresetLastMonth is the month before the current month.
+	PresetLastMonth = "last_month"
+)
+
+// Item represents tracked working time of a single project, language, editor,
+// branch or machine.
+type Item struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+	Text    string  `json:"text"`
+}
+
+// Range represents the tracked working time of a date range, including
+// breakdowns by project, language, editor, branch and machine. Items are
+// ordered by descending time.
+type Range struct {
+	Start     string  `json:"start"`
+	End       string  `json:"end"`
+	Seconds   float64 `json:"seconds"`
+	Text      string  `json:"text"`
+	Projects  []Item  `json:"projects"`
+	Languages []Item  `json:"languages"`
+	Editors   []Item  `json:"editors"`
+	Branches  []Item  `json:"branches"`
+	Machines  []Item  `json:"machines"`
+}
+
+// Presets returns all presets of date ranges.
+func Presets() []string {
+	return []string{
+		PresetToday,
+		PresetYesterday,
+		PresetLast7Days,
+		PresetLast30Days,
+		PresetThisWeek,
+		PresetLastWeek,
+		PresetThisMonth,
+		PresetLastMonth,
+	}
+}
+
+// ParseRange parses a date range from either a preset or a start date and
+// an optional end date in YYYY-MM-DD format. The end date defaults to the
+// start date. Dates are relative to the location of now.
+func ParseRange(start, end string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if from, to, ok := parsePreset(start, today); ok {
+		if end != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("end date cannot be combined with preset %q", start)
+		}
+
+		return from, to, nil
+	}
+
+	from, err := time.ParseInLocation(DateFormat, start, now.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf(
+			"invalid start date %q. must be YYYY-MM-DD or one of %s",
+			start,
+			strings.Join(Presets(), ", "),
+		)
+	}
+
+	if end == "" {
+		return from, from, nil
+	}
+
+	to, err := time.ParseInLocation(DateFormat, end, now.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end date %q. must be YYYY-MM-DD", end)
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end date %s is before start date %s", end, start)
+	}
+
+	return from, to, nil
+}
+
+func parsePreset(preset string, today time.Time) (time.Time, time.Time, bool) {
+	// days since monday
+	weekday := (int(today.Weekday()) + 6) % 7
+	firstOfMonth := today.AddDate(0, 0, 1-today.Day())
+
+	switch preset {
+	case PresetToday:
+		return today, today, true
+	case PresetYesterday:
+		return today.AddDate(0, 0, -1), today.AddDate(0, 0, -1), true
+	case PresetLast7Days:
+		return today.AddDate(0, 0, -6), today, true
+	case PresetLast30Days:
+		return today.AddDate(0, 0, -29), today, true
+	case PresetThisWeek:
+		return today.AddDate(0, 0, -weekday), today, true
+	case PresetLastWeek:
+		return today.AddDate(0, 0, -weekday-7), today.AddDate(0, 0, -weekday-1), true
+	case PresetThisMonth:
+		return firstOfMonth, today, true
+	case PresetLastMonth:
+		return firstOfMonth.AddDate(0, -1, 0), firstOfMonth.AddDate(0, 0, -1), true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+// FormatSeconds formats a duration in seconds the way the api does,
+// e.g. "2 hrs 5 mins".
+func FormatSeconds(seconds float64) string {
+	secs := int(seconds)
+	hours := secs / 3600
+	mins := secs % 3600 / 60
+
+	var parts []string
+
+	if hours > 0 {
+		parts = append(parts, plural(hours, "hr"))
+	}
+
+	if mins > 0 {
+		parts = append(parts, plural(mins, "min"))
+	}
+
+	if len(parts) == 0 {
+		return plural(secs, "sec")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,146 @@
+package summary_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wakatime/wakatime-cli/pkg/summary"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	// thursday
+	now := time.Date(2021, 9, 16, 15, 4, 5, 0, time.UTC)
+
+	tests := map[string]struct {
+		Start         string
+		End           string
+		ExpectedStart string
+		ExpectedEnd   string
+	}{
+		"today": {
+			Start:         "today",
+			ExpectedStart: "2021-09-16",
+			ExpectedEnd:   "2021-09-16",
+		},
+		"yesterday": {
+			Start:         "yesterday",
+			ExpectedStart: "2021-09-15",
+			ExpectedEnd:   "2021-09-15",
+		},
+		"last 7 days": {
+			Start:         "last_7_days",
+			ExpectedStart: "2021-09-10",
+			ExpectedEnd:   "2021-09-16",
+		},
+		"last 30 days": {
+			Start:         "last_30_days",
+			ExpectedStart: "2021-08-18",
+			ExpectedEnd:   "2021-09-16",
+		},
+		"this week": {
+			Start:         "this_week",
+			ExpectedStart: "2021-09-13",
+			ExpectedEnd:   "2021-09-16",
+		},
+		"last week": {
+			Start:         "last_week",
+			ExpectedStart: "2021-09-06",
+			ExpectedEnd:   "2021-09-12",
+		},
+		"this month": {
+			Start:         "this_month",
+			ExpectedStart: "2021-09-01",
+			ExpectedEnd:   "2021-09-16",
+		},
+		"last month": {
+			Start:         "last_month",
+			ExpectedStart: "2021-08-01",
+			ExpectedEnd:   "2021-08-31",
+		},
+		"start date": {
+			Start:         "2021-09-01",
+			ExpectedStart: "2021-09-01",
+			ExpectedEnd:   "2021-09-01",
+		},
+		"start and end date": {
+			Start:         "2021-09-01",
+			End:           "2021-09-03",
+			ExpectedStart: "2021-09-01",
+			ExpectedEnd:   "2021-09-03",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end, err := summary.ParseRange(test.Start, test.End, now)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.ExpectedStart, start.Format(summary.DateFormat))
+			assert.Equal(t, test.ExpectedEnd, end.Format(summary.DateFormat))
+		})
+	}
+}
+
+func TestParseRange_Err(t *testing.T) {
+	now := time.Date(2021, 9, 16, 15, 4, 5, 0, time.UTC)
+
+	tests := map[string]struct {
+		Start    string
+		End      string
+		Expected string
+	}{
+		"invalid start": {
+			Start: "last_year",
+			Expected: `invalid start date "last_year". must be YYYY-MM-DD or one of today, yesterday,` +
+				` last_7_days, last_30_days, this_week, last_week, this_month, last_month`,
+		},
+		"invalid end": {
+			Start:    "2021-09-01",
+			End:      "2021-09",
+			Expected: `invalid end date "2021-09". must be YYYY-MM-DD`,
+		},
+		"end before start": {
+			Start:    "2021-09-02",
+			End:      "2021-09-01",
+			Expected: "end date 2021-09-01 is before start date 2021-09-02",
+		},
+		"preset with end": {
+			Start:    "yesterday",
+			End:      "2021-09-01",
+			Expected: `end date cannot be combined with preset "yesterday"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := summary.ParseRange(test.Start, test.End, now)
+			require.Error(t, err)
+
+			assert.Equal(t, test.Expected, err.Error())
+		})
+	}
+}
+
+func TestFormatSeconds(t *testing.T) {
+	tests := map[string]struct {
+		Seconds  float64
+		Expected string
+	}{
+		"zero":           {Seconds: 0, Expected: "0 secs"},
+		"one sec":        {Seconds: 1.7, Expected: "1 sec"},
+		"secs":           {Seconds: 59, Expected: "59 secs"},
+		"one min":        {Seconds: 60, Expected: "1 min"},
+		"one hr":         {Seconds: 3600, Expected: "1 hr"},
+		"hrs and mins":   {Seconds: 7500, Expected: "2 hrs 5 mins"},
+		"hr and one min": {Seconds: 3660, Expected: "1 hr 1 min"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, summary.FormatSeconds(test.Seconds))
+		})
+	}
+}
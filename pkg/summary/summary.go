@@ -8,14 +8,20 @@ import (
 
 // Category represents tracked working time of a specific category.
 type Category struct {
-	Category string
-	Total    string
+	Category string  `json:"category"`
+	Percent  float64 `json:"percent"`
+	Seconds  float64 `json:"seconds"`
+	Total    string  `json:"total"`
 }
 
-// Summary represents the tracked working time for a single day.
+// Summary represents the tracked working time for a single day. Language and
+// Project are the ones with the most tracked time, if provided by the api.
 type Summary struct {
-	Total      string
-	ByCategory []Category
+	Total      string     `json:"total"`
+	Seconds    float64    `json:"seconds"`
+	ByCategory []Category `json:"categories"`
+	Language   string     `json:"language,omitempty"`
+	Project    string     `json:"project,omitempty"`
 }
 
 // RenderToday generates a text representation from summary of the current day.